@@ -0,0 +1,439 @@
+// Package client implements a client for the Rumour HTTP API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Error is returned when the server responds with an error.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("rumour: %s (status %d)", e.Message, e.StatusCode)
+}
+
+// IsNotFound returns true if err is a not found error.
+func IsNotFound(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.StatusCode == http.StatusNotFound
+}
+
+// ListOptions contain the filtering, sorting and pagination options of
+// list endpoints.
+type ListOptions struct {
+	// Prefix only includes names starting with the prefix.
+	Prefix string
+	// Match only includes names matching the regular expression.
+	Match string
+	// MinLag only includes entries with at least the given total lag.
+	MinLag int64
+	// Sort sorts by "name" (default) or "lag".
+	Sort string
+	// Limit limits the number of entries.
+	Limit int
+	// After is the Next cursor of the previous page.
+	After string
+}
+
+func (o *ListOptions) values() url.Values {
+	v := make(url.Values)
+	if o == nil {
+		return v
+	}
+	if o.Prefix != "" {
+		v.Set("prefix", o.Prefix)
+	}
+	if o.Match != "" {
+		v.Set("match", o.Match)
+	}
+	if o.MinLag > 0 {
+		v.Set("min_lag", strconv.FormatInt(o.MinLag, 10))
+	}
+	if o.Sort != "" {
+		v.Set("sort", o.Sort)
+	}
+	if o.Limit > 0 {
+		v.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.After != "" {
+		v.Set("after", o.After)
+	}
+	return v
+}
+
+// --------------------------------------------------------------------
+
+// Client is a Rumour API client.
+type Client struct {
+	baseURL string
+	http    *http.Client
+}
+
+// New inits a client for the server at baseURL, e.g. "http://rumour:8080".
+// The HTTP client is optional and defaults to http.DefaultClient.
+func New(baseURL string, hc *http.Client) *Client {
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	return &Client{baseURL: strings.TrimSuffix(baseURL, "/"), http: hc}
+}
+
+// Clusters returns the cluster names.
+func (c *Client) Clusters(ctx context.Context) ([]string, error) {
+	var res struct {
+		Clusters []string `json:"clusters"`
+	}
+	if err := c.get(ctx, "/v1/clusters", nil, &res); err != nil {
+		return nil, err
+	}
+	return res.Clusters, nil
+}
+
+// Cluster returns cluster details.
+func (c *Client) Cluster(ctx context.Context, cluster string) (*ClusterDetail, error) {
+	res := new(ClusterDetail)
+	if err := c.get(ctx, join("v1", "clusters", cluster), nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Topics lists the topics of a cluster. Options are optional.
+func (c *Client) Topics(ctx context.Context, cluster string, opt *ListOptions) (*TopicList, error) {
+	res := new(TopicList)
+	if err := c.get(ctx, join("v1", "clusters", cluster, "topics"), opt.values(), res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Topic returns the log end offsets of a topic.
+func (c *Client) Topic(ctx context.Context, cluster, topic string) (*TopicDetail, error) {
+	res := new(TopicDetail)
+	if err := c.get(ctx, join("v1", "clusters", cluster, "topics", topic), nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// TopicConsumers lists the consumer groups reading a topic. Options are
+// optional.
+func (c *Client) TopicConsumers(ctx context.Context, cluster, topic string, opt *ListOptions) (*TopicConsumerList, error) {
+	res := new(TopicConsumerList)
+	if err := c.get(ctx, join("v1", "clusters", cluster, "topics", topic, "consumers"), opt.values(), res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ConsumerGroups lists the consumer group names of a cluster. Options are
+// optional.
+func (c *Client) ConsumerGroups(ctx context.Context, cluster string, opt *ListOptions) (*ConsumerGroupList, error) {
+	res := new(ConsumerGroupList)
+	if err := c.get(ctx, join("v1", "clusters", cluster, "consumers"), opt.values(), res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Consumers lists the consumer groups of a cluster, including their topics.
+// Options are optional.
+func (c *Client) Consumers(ctx context.Context, cluster string, opt *ListOptions) (*ConsumerList, error) {
+	query := opt.values()
+	query.Set("expand", "true")
+
+	res := new(ConsumerList)
+	if err := c.get(ctx, join("v1", "clusters", cluster, "consumers"), query, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Consumer returns the topics of a consumer group.
+func (c *Client) Consumer(ctx context.Context, cluster, group string) (*ConsumerDetail, error) {
+	res := new(ConsumerDetail)
+	if err := c.get(ctx, join("v1", "clusters", cluster, "consumers", group), nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// TopClusterConsumers ranks the consumer lags of a cluster. Empty rankings
+// default to RankByLag, limits <= 0 to the server default.
+func (c *Client) TopClusterConsumers(ctx context.Context, cluster string, by RankBy, limit int) (*TopConsumerList, error) {
+	res := new(TopConsumerList)
+	if err := c.get(ctx, join("v1", "clusters", cluster, "consumers", "top"), rankValues(by, limit), res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// TopConsumers ranks consumer lags across all clusters. Empty rankings
+// default to RankByLag, limits <= 0 to the server default.
+func (c *Client) TopConsumers(ctx context.Context, by RankBy, limit int) (*TopConsumerList, error) {
+	res := new(TopConsumerList)
+	if err := c.get(ctx, "/v1/top", rankValues(by, limit), res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Alerts returns active alerts.
+func (c *Client) Alerts(ctx context.Context) ([]Alert, error) {
+	var res struct {
+		Alerts []Alert `json:"alerts"`
+	}
+	if err := c.get(ctx, "/v1/alerts", nil, &res); err != nil {
+		return nil, err
+	}
+	return res.Alerts, nil
+}
+
+// Silences returns active silences.
+func (c *Client) Silences(ctx context.Context) ([]Silence, error) {
+	var res struct {
+		Silences []Silence `json:"silences"`
+	}
+	if err := c.get(ctx, "/v1/silences", nil, &res); err != nil {
+		return nil, err
+	}
+	return res.Silences, nil
+}
+
+// Silence returns a silence by ID.
+func (c *Client) Silence(ctx context.Context, id string) (*Silence, error) {
+	res := new(Silence)
+	if err := c.get(ctx, join("v1", "silences", id), nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// CreateSilence creates a silence.
+func (c *Client) CreateSilence(ctx context.Context, req *SilenceRequest) (*Silence, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	res := new(Silence)
+	if err := c.do(ctx, http.MethodPost, "/v1/silences", nil, bytes.NewReader(body), res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// DeleteSilence deletes a silence by ID.
+func (c *Client) DeleteSilence(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, join("v1", "silences", id), nil, nil, nil)
+}
+
+// ResetOffsets resets the offsets of an inactive consumer group. It requires
+// the admin API to be enabled.
+func (c *Client) ResetOffsets(ctx context.Context, cluster, group string, req *OffsetReset) (*OffsetResetResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	res := new(OffsetResetResponse)
+	if err := c.do(ctx, http.MethodPost, join("v1", "clusters", cluster, "consumers", group, "offsets"), nil, bytes.NewReader(body), res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// DeleteConsumerGroup deletes an inactive consumer group. It requires the
+// admin API to be enabled.
+func (c *Client) DeleteConsumerGroup(ctx context.Context, cluster, group string) error {
+	return c.do(ctx, http.MethodDelete, join("v1", "clusters", cluster, "consumers", group), nil, nil, nil)
+}
+
+// SetCluster adds a cluster or updates its config and returns the applied
+// config. It requires the admin API to be enabled.
+func (c *Client) SetCluster(ctx context.Context, cluster string, cfg *ClusterConfig) (*ClusterConfig, error) {
+	body, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	res := new(ClusterConfig)
+	if err := c.do(ctx, http.MethodPut, join("v1", "clusters", cluster), nil, bytes.NewReader(body), res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// DeleteCluster stops monitoring a cluster and removes it. It requires the
+// admin API to be enabled.
+func (c *Client) DeleteCluster(ctx context.Context, cluster string) error {
+	return c.do(ctx, http.MethodDelete, join("v1", "clusters", cluster), nil, nil, nil)
+}
+
+// Refresh triggers an immediate refresh of a cluster. If wait is true, it
+// blocks until the refresh has completed. It requires the admin API to be
+// enabled.
+func (c *Client) Refresh(ctx context.Context, cluster string, what RefreshKind, wait bool) error {
+	query := url.Values{"what": {string(what)}}
+	if wait {
+		query.Set("wait", "true")
+	}
+	return c.do(ctx, http.MethodPost, join("v1", "clusters", cluster, "refresh"), query, nil, nil)
+}
+
+// PauseCluster pauses monitoring of a cluster. It requires the admin API to be
+// enabled.
+func (c *Client) PauseCluster(ctx context.Context, cluster string) error {
+	return c.do(ctx, http.MethodPost, join("v1", "clusters", cluster, "pause"), nil, nil, nil)
+}
+
+// ResumeCluster resumes monitoring of a paused cluster. It requires the admin
+// API to be enabled.
+func (c *Client) ResumeCluster(ctx context.Context, cluster string) error {
+	return c.do(ctx, http.MethodPost, join("v1", "clusters", cluster, "resume"), nil, nil, nil)
+}
+
+// OpenAPI returns the OpenAPI specification of the server.
+func (c *Client) OpenAPI(ctx context.Context) (json.RawMessage, error) {
+	var res json.RawMessage
+	if err := c.get(ctx, "/v1/openapi.json", nil, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// TopicV2 returns the partitions of a topic.
+func (c *Client) TopicV2(ctx context.Context, cluster, topic string) (*TopicDetailV2, error) {
+	res := new(TopicDetailV2)
+	if err := c.get(ctx, join("v2", "clusters", cluster, "topics", topic), nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// TopicConsumersV2 lists the partition offsets of consumer groups reading a
+// topic. Options are optional.
+func (c *Client) TopicConsumersV2(ctx context.Context, cluster, topic string, opt *ListOptions) (*TopicConsumerListV2, error) {
+	res := new(TopicConsumerListV2)
+	if err := c.get(ctx, join("v2", "clusters", cluster, "topics", topic, "consumers"), opt.values(), res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ConsumersV2 lists the partition offsets of all consumer groups of a
+// cluster. Options are optional.
+func (c *Client) ConsumersV2(ctx context.Context, cluster string, opt *ListOptions) (*ConsumerListV2, error) {
+	query := opt.values()
+	query.Set("expand", "true")
+
+	res := new(ConsumerListV2)
+	if err := c.get(ctx, join("v2", "clusters", cluster, "consumers"), query, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ConsumerV2 returns the partition offsets of a consumer group.
+func (c *Client) ConsumerV2(ctx context.Context, cluster, group string) (*ConsumerDetailV2, error) {
+	res := new(ConsumerDetailV2)
+	if err := c.get(ctx, join("v2", "clusters", cluster, "consumers", group), nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ConsumerHistoryV2 returns the recent lag history of a consumer group.
+func (c *Client) ConsumerHistoryV2(ctx context.Context, cluster, group string) (*ConsumerHistoryV2, error) {
+	res := new(ConsumerHistoryV2)
+	if err := c.get(ctx, join("v2", "clusters", cluster, "consumers", group, "history"), nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, res interface{}) error {
+	return c.do(ctx, http.MethodGet, path, query, nil, res)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body io.Reader, res interface{}) error {
+	resp, err := c.send(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if res == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(res)
+}
+
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body io.Reader) (*http.Response, error) {
+	u := c.baseURL + path
+	if len(query) != 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		return nil, decodeError(resp)
+	}
+	return resp, nil
+}
+
+func decodeError(resp *http.Response) error {
+	var msg struct {
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil || msg.Message == "" {
+		msg.Message = http.StatusText(resp.StatusCode)
+	}
+	return &Error{StatusCode: resp.StatusCode, Message: msg.Message}
+}
+
+func rankValues(by RankBy, limit int) url.Values {
+	v := make(url.Values)
+	if by != "" {
+		v.Set("by", string(by))
+	}
+	if limit > 0 {
+		v.Set("limit", strconv.Itoa(limit))
+	}
+	return v
+}
+
+func join(segments ...string) string {
+	var b strings.Builder
+	for _, s := range segments {
+		b.WriteByte('/')
+		b.WriteString(url.PathEscape(s))
+	}
+	return b.String()
+}
@@ -0,0 +1,333 @@
+package client_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bsm/rumour/client"
+	"github.com/bsm/rumour/internal/alert"
+	"github.com/bsm/rumour/internal/rumour"
+	"github.com/bsm/rumour/internal/server"
+	"github.com/go-chi/httplog"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Client", func() {
+	var subject *client.Client
+	var state *rumour.State
+	var srv *httptest.Server
+	var ctx = context.Background()
+
+	BeforeEach(func() {
+		state = rumour.NewState([]string{"main"})
+		cluster := state.Cluster("main")
+		cluster.UpdateBrokers([]string{"10.0.0.1:9092"})
+		cluster.UpdateTopic("orders", []int64{100, 100})
+		cluster.UpdateTopic("payments", []int64{50})
+		cluster.UpdateConsumerOffsets("orders-worker", "orders", 1515151515, []int64{40, 90})
+		cluster.UpdateConsumerOffsets("payments-worker", "payments", 1515151515, []int64{45})
+
+		alerts, err := alert.NewEngine(state, &alert.Config{
+			Clusters: map[string]alert.ClusterConfig{"main": {Rules: []alert.Rule{
+				{Name: "lagging", Warn: alert.Threshold{TotalLag: 50}},
+			}}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		alerts.Evaluate(time.Now())
+
+		dir, err := os.MkdirTemp("", "rumour-client")
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(os.RemoveAll, dir)
+
+		name := filepath.Join(dir, "credentials.json")
+		Expect(os.WriteFile(name, []byte(`{"tokens": [{"name": "admin", "token": "4dm1n", "admin": true}]}`), 0600)).To(Succeed())
+		auth, err := server.NewAuth(name)
+		Expect(err).NotTo(HaveOccurred())
+
+		srv = httptest.NewServer(server.NewHTTP(":0", state, alerts, server.Options{
+			Log:      httplog.Options{LogLevel: "error"},
+			Auth:     auth,
+			Admin:    stubAdmin{state: state},
+			AuditLog: server.NewAuditLog(io.Discard),
+		}).Handler)
+		subject = client.New(srv.URL+"/", &http.Client{Transport: bearerToken("4dm1n")})
+	})
+
+	AfterEach(func() {
+		srv.Close()
+	})
+
+	It("should retrieve clusters", func() {
+		Expect(subject.Clusters(ctx)).To(Equal([]string{"main"}))
+		Expect(subject.Cluster(ctx, "main")).To(Equal(&client.ClusterDetail{
+			Cluster:   "main",
+			Brokers:   []string{"10.0.0.1:9092"},
+			Topics:    []string{"orders", "payments"},
+			Consumers: []string{"orders-worker", "payments-worker"},
+		}))
+
+		_, err := subject.Cluster(ctx, "missing")
+		Expect(err).To(MatchError("rumour: not found (status 404)"))
+		Expect(client.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should retrieve topics", func() {
+		Expect(subject.Topics(ctx, "main", nil)).To(Equal(&client.TopicList{
+			Cluster: "main",
+			Topics:  []string{"orders", "payments"},
+		}))
+		Expect(subject.Topics(ctx, "main", &client.ListOptions{Sort: "lag", Limit: 1})).To(Equal(&client.TopicList{
+			Cluster: "main",
+			Topics:  []string{"orders"},
+			Next:    "bGFnOjcwOm9yZGVycw",
+		}))
+		Expect(subject.Topic(ctx, "main", "orders")).To(Equal(&client.TopicDetail{
+			Cluster: "main",
+			Topic:   "orders",
+			Offsets: []int64{100, 100},
+		}))
+
+		_, err := subject.Topics(ctx, "main", &client.ListOptions{Match: "("})
+		Expect(err).To(MatchError("rumour: invalid match (status 400)"))
+	})
+
+	It("should retrieve consumers", func() {
+		groups, err := subject.ConsumerGroups(ctx, "main", &client.ListOptions{Prefix: "orders"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(groups.Consumers).To(Equal([]string{"orders-worker"}))
+
+		consumers, err := subject.Consumers(ctx, "main", &client.ListOptions{Sort: "lag"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(consumers.Consumers).To(HaveLen(2))
+		Expect(consumers.Consumers[0].Group).To(Equal("orders-worker"))
+		Expect(consumers.Consumers[0].Summary.TotalLag).To(Equal(int64(70)))
+
+		consumer, err := subject.Consumer(ctx, "main", "payments-worker")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(consumer.Topics).To(HaveLen(1))
+		Expect(consumer.Topics[0].Offsets).To(Equal([]client.ConsumerOffset{{Offset: 45, Lag: 5}}))
+
+		tcs, err := subject.TopicConsumers(ctx, "main", "orders", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tcs.Consumers).To(HaveLen(1))
+		Expect(tcs.Consumers[0].Group).To(Equal("orders-worker"))
+	})
+
+	It("should rank consumers", func() {
+		top, err := subject.TopClusterConsumers(ctx, "main", "", 1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(top.Cluster).To(Equal("main"))
+		Expect(top.By).To(Equal(client.RankByLag))
+		Expect(top.Consumers).To(HaveLen(1))
+		Expect(top.Consumers[0].Group).To(Equal("orders-worker"))
+
+		top, err = subject.TopConsumers(ctx, client.RankByGrowth, 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(top.Consumers).To(HaveLen(2))
+		Expect(top.Consumers[0].Cluster).To(Equal("main"))
+	})
+
+	It("should retrieve v2 resources", func() {
+		topic, err := subject.TopicV2(ctx, "main", "payments")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(topic.Partitions).To(HaveLen(1))
+		Expect(*topic.Partitions[0].LogEndOffset).To(Equal(int64(50)))
+
+		tcs, err := subject.TopicConsumersV2(ctx, "main", "orders", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tcs.Consumers).To(HaveLen(1))
+		Expect(tcs.Consumers[0].Partitions).To(HaveLen(2))
+
+		consumers, err := subject.ConsumersV2(ctx, "main", &client.ListOptions{MinLag: 10})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(consumers.Consumers).To(HaveLen(1))
+
+		consumer, err := subject.ConsumerV2(ctx, "main", "orders-worker")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(consumer.Cluster).To(Equal("main"))
+		Expect(consumer.Group).To(Equal("orders-worker"))
+		Expect(*consumer.Topics[0].Partitions[0].Lag).To(Equal(int64(60)))
+
+		history, err := subject.ConsumerHistoryV2(ctx, "main", "orders-worker")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(history.Topics).To(Equal([]client.ConsumerTopicHistory{
+			{Topic: "orders", Samples: []client.LagSample{{Timestamp: 1515151515, Lag: 70}}},
+		}))
+	})
+
+	It("should manage alerts and silences", func() {
+		alerts, err := subject.Alerts(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(alerts).To(HaveLen(1))
+		Expect(alerts[0].Group).To(Equal("orders-worker"))
+		Expect(alerts[0].Severity).To(Equal("warn"))
+
+		silence, err := subject.CreateSilence(ctx, &client.SilenceRequest{
+			Topic:    "orders",
+			Duration: time.Hour,
+			Comment:  "backfill",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(silence.ID).NotTo(BeEmpty())
+		Expect(silence.EndsAt - silence.StartsAt).To(Equal(int64(3600)))
+
+		Expect(subject.Silence(ctx, silence.ID)).To(Equal(silence))
+		Expect(subject.Silences(ctx)).To(Equal([]client.Silence{*silence}))
+
+		Expect(subject.DeleteSilence(ctx, silence.ID)).To(Succeed())
+		Expect(subject.Silences(ctx)).To(BeEmpty())
+		Expect(client.IsNotFound(subject.DeleteSilence(ctx, silence.ID))).To(BeTrue())
+	})
+
+	It("should reset offsets", func() {
+		Expect(subject.ResetOffsets(ctx, "main", "orders-worker", &client.OffsetReset{
+			Mode:   client.ResetToEarliest,
+			DryRun: true,
+		})).To(Equal(&client.OffsetResetResponse{
+			Cluster:  "main",
+			Consumer: "orders-worker",
+			DryRun:   true,
+			Changes: []client.OffsetChange{
+				{Topic: "orders", Partition: 0, OldOffset: 40, NewOffset: 0},
+				{Topic: "orders", Partition: 1, OldOffset: 90, NewOffset: 0},
+			},
+		}))
+
+		_, err := subject.ResetOffsets(ctx, "main", "orders-worker", &client.OffsetReset{Mode: "bad"})
+		Expect(err).To(MatchError(`rumour: invalid offset reset: unsupported mode "bad" (status 400)`))
+	})
+
+	It("should delete consumer groups", func() {
+		Expect(subject.DeleteConsumerGroup(ctx, "main", "payments-worker")).To(Succeed())
+		Expect(subject.ConsumerGroups(ctx, "main", nil)).To(Equal(&client.ConsumerGroupList{
+			Cluster:   "main",
+			Consumers: []string{"orders-worker"},
+		}))
+
+		err := subject.DeleteConsumerGroup(ctx, "main", "missing")
+		Expect(client.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should refresh clusters", func() {
+		Expect(subject.Refresh(ctx, "main", client.RefreshOffsets, false)).To(Succeed())
+		Expect(subject.Refresh(ctx, "main", client.RefreshMeta, true)).To(MatchError(`rumour: refresh rate limited (status 429)`))
+		Expect(client.IsNotFound(subject.Refresh(ctx, "missing", client.RefreshMeta, false))).To(BeTrue())
+	})
+
+	It("should pause and resume clusters", func() {
+		Expect(subject.PauseCluster(ctx, "main")).To(Succeed())
+		detail, err := subject.Cluster(ctx, "main")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(detail.Paused).To(BeTrue())
+
+		Expect(subject.ResumeCluster(ctx, "main")).To(Succeed())
+		detail, err = subject.Cluster(ctx, "main")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(detail.Paused).To(BeFalse())
+		Expect(client.IsNotFound(subject.PauseCluster(ctx, "missing"))).To(BeTrue())
+	})
+
+	It("should manage clusters", func() {
+		Expect(subject.SetCluster(ctx, "prio", &client.ClusterConfig{
+			Brokers:     []string{"10.0.0.1:9192"},
+			MetaRefresh: 2 * time.Minute,
+		})).To(Equal(&client.ClusterConfig{
+			Name:          "prio",
+			Brokers:       []string{"10.0.0.1:9192"},
+			MetaRefresh:   2 * time.Minute,
+			OffsetRefresh: 30 * time.Second,
+		}))
+		Expect(subject.Clusters(ctx)).To(Equal([]string{"main", "prio"}))
+
+		Expect(subject.DeleteCluster(ctx, "prio")).To(Succeed())
+		Expect(subject.Clusters(ctx)).To(Equal([]string{"main"}))
+		Expect(client.IsNotFound(subject.DeleteCluster(ctx, "prio"))).To(BeTrue())
+	})
+
+	It("should retrieve the OpenAPI spec", func() {
+		spec, err := subject.OpenAPI(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(spec)).To(ContainSubstring(`"openapi"`))
+	})
+
+	It("should stream events", func() {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		stream, err := subject.Events(ctx, &client.EventFilter{Topic: "payments"})
+		Expect(err).NotTo(HaveOccurred())
+		defer stream.Close()
+
+		cluster := state.Cluster("main")
+		cluster.UpdateTopic("orders", []int64{110, 110})
+		cluster.UpdateTopic("payments", []int64{60})
+
+		event, err := stream.Next()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(event.Type).To(Equal(client.EventTopicOffsets))
+		Expect(event.Cluster).To(Equal("main"))
+		Expect(event.Topic).To(Equal("payments"))
+		Expect(event.Offsets).To(Equal([]int64{60}))
+
+		_, err = subject.Events(ctx, &client.EventFilter{Cluster: "missing"})
+		Expect(client.IsNotFound(err)).To(BeTrue())
+	})
+})
+
+// bearerToken authenticates requests with a bearer token.
+type bearerToken string
+
+func (t bearerToken) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+string(t))
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// stubAdmin resets the offsets of the orders-worker group to zero, deletes
+// groups, except for unknown ones, and refuses to wait for refreshes. Like
+// the fetcher, it adds new clusters to the state.
+type stubAdmin struct{ state *rumour.State }
+
+func (stubAdmin) Pause(_ string) error  { return nil }
+func (stubAdmin) Resume(_ string) error { return nil }
+
+func (a stubAdmin) SetCluster(cc *rumour.ClusterConfig) (bool, error) {
+	if cc.MetaRefresh == 0 {
+		cc.MetaRefresh = rumour.DefaultMetaRefresh
+	}
+	if cc.OffsetRefresh == 0 {
+		cc.OffsetRefresh = rumour.DefaultOffsetRefresh
+	}
+	a.state.AddCluster(cc.Name)
+	return cc.Name != "main", nil
+}
+
+func (stubAdmin) RemoveCluster(_ string) error { return nil }
+
+func (stubAdmin) Refresh(_ context.Context, _ string, _ rumour.RefreshKind, wait bool) error {
+	if wait {
+		return rumour.ErrRefreshLimited
+	}
+	return nil
+}
+
+func (stubAdmin) DeleteConsumerGroup(_ context.Context, _, group string) error {
+	if group == "missing" {
+		return rumour.ErrGroupNotFound
+	}
+	return nil
+}
+
+func (stubAdmin) ResetOffsets(_ context.Context, _, _ string, _ *rumour.OffsetReset) ([]rumour.OffsetChange, error) {
+	return []rumour.OffsetChange{
+		{Topic: "orders", Partition: 0, OldOffset: 40, NewOffset: 0},
+		{Topic: "orders", Partition: 1, OldOffset: 90, NewOffset: 0},
+	}, nil
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// EventFilter restricts event streams to matching events. Empty fields
+// match everything.
+type EventFilter struct {
+	Cluster string
+	Group   string
+	Topic   string
+}
+
+func (f *EventFilter) values() url.Values {
+	v := make(url.Values)
+	if f == nil {
+		return v
+	}
+	if f.Cluster != "" {
+		v.Set("cluster", f.Cluster)
+	}
+	if f.Group != "" {
+		v.Set("consumer", f.Group)
+	}
+	if f.Topic != "" {
+		v.Set("topic", f.Topic)
+	}
+	return v
+}
+
+// EventStream is a stream of state change events.
+type EventStream struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+}
+
+// Events subscribes to state change events. The filter is optional. Streams
+// must be closed after use.
+func (c *Client) Events(ctx context.Context, filter *EventFilter) (*EventStream, error) {
+	resp, err := c.send(ctx, http.MethodGet, "/v1/events", filter.values(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	return &EventStream{body: resp.Body, scanner: scanner}, nil
+}
+
+// Next blocks until the next event is received. It returns io.EOF when the
+// server closes the stream.
+func (s *EventStream) Next() (*Event, error) {
+	var data []string
+	for s.scanner.Scan() {
+		line := s.scanner.Text()
+		switch {
+		case line == "":
+			if len(data) == 0 {
+				continue
+			}
+
+			ev := new(Event)
+			if err := json.Unmarshal([]byte(strings.Join(data, "\n")), ev); err != nil {
+				return nil, err
+			}
+			return ev, nil
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := s.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// Close closes the stream.
+func (s *EventStream) Close() error {
+	return s.body.Close()
+}
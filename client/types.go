@@ -0,0 +1,400 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// LagSummary contains aggregated lag numbers.
+type LagSummary struct {
+	TotalLag        int64  `json:"total_lag"`
+	MaxLag          int64  `json:"max_lag"`
+	MaxLagTopic     string `json:"max_lag_topic,omitempty"`
+	MaxLagPartition int    `json:"max_lag_partition"`
+	Partitions      int    `json:"partitions"`
+	Uncommitted     int    `json:"uncommitted_partitions"`
+}
+
+// ConsumerOffset contains the offset and lag of a consumer partition.
+type ConsumerOffset struct {
+	Offset int64 `json:"offset"`
+	Lag    int64 `json:"lag"`
+}
+
+// ConsumerTopic contains the offsets of a consumer group on a topic.
+type ConsumerTopic struct {
+	Topic     string           `json:"topic"`
+	Timestamp int64            `json:"timestamp"`
+	Offsets   []ConsumerOffset `json:"offsets"`
+	Summary   LagSummary       `json:"summary"`
+}
+
+// Consumer contains the topics of a consumer group.
+type Consumer struct {
+	Group   string          `json:"consumer"`
+	Topics  []ConsumerTopic `json:"topics"`
+	Summary LagSummary      `json:"summary"`
+}
+
+// TopicConsumer contains the offsets of a consumer group on a topic.
+type TopicConsumer struct {
+	Group     string           `json:"consumer"`
+	Timestamp int64            `json:"timestamp"`
+	Offsets   []ConsumerOffset `json:"offsets"`
+	Summary   LagSummary       `json:"summary"`
+}
+
+// RankBy determines the order of ranked consumer lags.
+type RankBy string
+
+// Supported rankings.
+const (
+	RankByLag     RankBy = "lag"
+	RankByTimeLag RankBy = "time_lag"
+	RankByGrowth  RankBy = "growth"
+)
+
+// ConsumerLag contains the lag of a consumer group on a topic.
+type ConsumerLag struct {
+	Cluster   string     `json:"cluster,omitempty"`
+	Group     string     `json:"consumer"`
+	Topic     string     `json:"topic"`
+	Timestamp int64      `json:"timestamp"`
+	Summary   LagSummary `json:"summary"`
+	TimeLag   int64      `json:"time_lag"`
+	Growth    int64      `json:"growth"`
+	Trend     string     `json:"trend,omitempty"`
+}
+
+// TopicPartition contains the log end offset of a topic partition. Unknown
+// offsets are nil.
+type TopicPartition struct {
+	Partition    int    `json:"partition"`
+	LogEndOffset *int64 `json:"log_end_offset"`
+}
+
+// PartitionOffset contains explicit consumer partition offsets. Unknown values
+// are nil.
+type PartitionOffset struct {
+	Partition    int    `json:"partition"`
+	Offset       *int64 `json:"offset"`
+	LogEndOffset *int64 `json:"log_end_offset"`
+	Lag          *int64 `json:"lag"`
+}
+
+// ConsumerTopicPartitions contains partition offsets of a consumer group
+// on a topic.
+type ConsumerTopicPartitions struct {
+	Topic      string            `json:"topic,omitempty"`
+	Group      string            `json:"consumer,omitempty"`
+	Timestamp  int64             `json:"timestamp"`
+	Partitions []PartitionOffset `json:"partitions"`
+	Summary    LagSummary        `json:"summary"`
+}
+
+// ConsumerPartitions contains the partition offsets of a consumer group.
+type ConsumerPartitions struct {
+	Group   string                    `json:"consumer"`
+	Topics  []ConsumerTopicPartitions `json:"topics"`
+	Summary LagSummary                `json:"summary"`
+}
+
+// Alert contains alert information.
+type Alert struct {
+	Cluster  string `json:"cluster"`
+	Group    string `json:"group"`
+	Topic    string `json:"topic"`
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Status   string `json:"status"`
+	TotalLag int64  `json:"total_lag"`
+	MaxLag   int64  `json:"max_lag"`
+	TimeLag  int64  `json:"time_lag"`
+	StartsAt int64  `json:"starts_at"`
+	EndsAt   int64  `json:"ends_at,omitempty"`
+	Silenced bool   `json:"silenced"`
+}
+
+// Silence mutes alerts of matching clusters, groups and topics.
+type Silence struct {
+	ID        string `json:"id"`
+	Cluster   string `json:"cluster,omitempty"`
+	Group     string `json:"group,omitempty"`
+	Topic     string `json:"topic,omitempty"`
+	StartsAt  int64  `json:"starts_at"`
+	EndsAt    int64  `json:"ends_at"`
+	Comment   string `json:"comment,omitempty"`
+	CreatedBy string `json:"created_by,omitempty"`
+}
+
+// SilenceRequest creates a silence. It starts immediately unless StartsAt
+// is set. Duration is used when EndsAt is not set.
+type SilenceRequest struct {
+	Cluster   string        `json:"cluster,omitempty"`
+	Group     string        `json:"group,omitempty"`
+	Topic     string        `json:"topic,omitempty"`
+	StartsAt  int64         `json:"starts_at,omitempty"`
+	EndsAt    int64         `json:"ends_at,omitempty"`
+	Duration  time.Duration `json:"-"`
+	Comment   string        `json:"comment,omitempty"`
+	CreatedBy string        `json:"created_by,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r *SilenceRequest) MarshalJSON() ([]byte, error) {
+	type plain SilenceRequest
+	v := struct {
+		*plain
+		Duration string `json:"duration,omitempty"`
+	}{plain: (*plain)(r)}
+	if r.Duration > 0 {
+		v.Duration = r.Duration.String()
+	}
+	return json.Marshal(v)
+}
+
+// ClusterConfig configures a monitored cluster. Refresh intervals default to
+// 180s and 30s respectively.
+type ClusterConfig struct {
+	Name          string        `json:"name,omitempty"`
+	Brokers       []string      `json:"brokers"`
+	MetaRefresh   time.Duration `json:"-"`
+	OffsetRefresh time.Duration `json:"-"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c *ClusterConfig) MarshalJSON() ([]byte, error) {
+	type plain ClusterConfig
+	v := struct {
+		*plain
+		MetaRefresh   string `json:"meta_refresh,omitempty"`
+		OffsetRefresh string `json:"offset_refresh,omitempty"`
+	}{plain: (*plain)(c)}
+	if c.MetaRefresh > 0 {
+		v.MetaRefresh = c.MetaRefresh.String()
+	}
+	if c.OffsetRefresh > 0 {
+		v.OffsetRefresh = c.OffsetRefresh.String()
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *ClusterConfig) UnmarshalJSON(data []byte) error {
+	type plain ClusterConfig
+	v := struct {
+		*plain
+		MetaRefresh   string `json:"meta_refresh"`
+		OffsetRefresh string `json:"offset_refresh"`
+	}{plain: (*plain)(c)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	var err error
+	if v.MetaRefresh != "" {
+		if c.MetaRefresh, err = time.ParseDuration(v.MetaRefresh); err != nil {
+			return err
+		}
+	}
+	if v.OffsetRefresh != "" {
+		if c.OffsetRefresh, err = time.ParseDuration(v.OffsetRefresh); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RefreshKind determines what is refreshed on demand.
+type RefreshKind string
+
+// Supported refresh kinds.
+const (
+	RefreshMeta    RefreshKind = "meta"
+	RefreshOffsets RefreshKind = "offsets"
+)
+
+// ResetMode determines how offsets are reset.
+type ResetMode string
+
+// Supported reset modes.
+const (
+	ResetToEarliest  ResetMode = "earliest"
+	ResetToLatest    ResetMode = "latest"
+	ResetToTimestamp ResetMode = "timestamp"
+	ResetToOffset    ResetMode = "offset"
+	ResetByShift     ResetMode = "shift"
+)
+
+// OffsetReset resets consumer group offsets. Topics default to the topics of
+// the group while it is tracked, partitions to all partitions of the topics.
+// Timestamp is used by ResetToTimestamp, Offset by ResetToOffset and Shift by
+// ResetByShift.
+type OffsetReset struct {
+	Topics     []string  `json:"topics,omitempty"`
+	Partitions []int32   `json:"partitions,omitempty"`
+	Mode       ResetMode `json:"mode"`
+	Timestamp  int64     `json:"timestamp,omitempty"`
+	Offset     int64     `json:"offset,omitempty"`
+	Shift      int64     `json:"shift,omitempty"`
+	DryRun     bool      `json:"dry_run,omitempty"`
+}
+
+// OffsetChange describes the change of a consumer partition offset.
+// OldOffset is -1 if the partition had no committed offset.
+type OffsetChange struct {
+	Topic     string `json:"topic"`
+	Partition int32  `json:"partition"`
+	OldOffset int64  `json:"old_offset"`
+	NewOffset int64  `json:"new_offset"`
+}
+
+// EventType identifies the kind of a state change event.
+type EventType string
+
+// Supported event types.
+const (
+	EventTopicOffsets    EventType = "topic_offsets"
+	EventConsumerOffsets EventType = "consumer_offsets"
+	EventGroupAdded      EventType = "group_added"
+	EventGroupExpired    EventType = "group_expired"
+	EventGroupDeleted    EventType = "group_deleted"
+	EventFetchError      EventType = "fetch_error"
+
+	// EventLagged is sent after events were dropped because the stream could
+	// not keep up, tracked state should be reloaded.
+	EventLagged EventType = "lagged"
+)
+
+// Event describes a change of the cluster state.
+type Event struct {
+	Type      EventType   `json:"type"`
+	Cluster   string      `json:"cluster"`
+	Group     string      `json:"consumer,omitempty"`
+	Topic     string      `json:"topic,omitempty"`
+	Timestamp int64       `json:"timestamp"`
+	Offsets   []int64     `json:"offsets,omitempty"`
+	Summary   *LagSummary `json:"summary,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
+
+// --------------------------------------------------------------------
+
+// ClusterDetail contains the brokers, topics and consumer groups of a cluster.
+// Paused clusters retain their last known state.
+type ClusterDetail struct {
+	Cluster   string   `json:"cluster"`
+	Brokers   []string `json:"brokers"`
+	Topics    []string `json:"topics"`
+	Consumers []string `json:"consumers"`
+	Paused    bool     `json:"paused,omitempty"`
+}
+
+// TopicList lists the topic names of a cluster.
+type TopicList struct {
+	Cluster string   `json:"cluster"`
+	Topics  []string `json:"topics"`
+	Next    string   `json:"next,omitempty"`
+}
+
+// TopicDetail contains the log end offsets of a topic.
+type TopicDetail struct {
+	Cluster string  `json:"cluster"`
+	Topic   string  `json:"topic"`
+	Offsets []int64 `json:"offsets"`
+}
+
+// TopicConsumerList lists the consumer groups reading a topic.
+type TopicConsumerList struct {
+	Cluster   string          `json:"cluster"`
+	Topic     string          `json:"topic"`
+	Consumers []TopicConsumer `json:"consumers"`
+	Next      string          `json:"next,omitempty"`
+}
+
+// ConsumerGroupList lists the consumer group names of a cluster.
+type ConsumerGroupList struct {
+	Cluster   string   `json:"cluster"`
+	Consumers []string `json:"consumers"`
+	Next      string   `json:"next,omitempty"`
+}
+
+// ConsumerList lists the consumer groups of a cluster with their topics.
+type ConsumerList struct {
+	Cluster   string     `json:"cluster"`
+	Consumers []Consumer `json:"consumers"`
+	Next      string     `json:"next,omitempty"`
+}
+
+// ConsumerDetail contains the topics of a consumer group.
+type ConsumerDetail struct {
+	Cluster  string          `json:"cluster"`
+	Consumer string          `json:"consumer"`
+	Topics   []ConsumerTopic `json:"topics"`
+	Summary  LagSummary      `json:"summary"`
+}
+
+// TopConsumerList contains ranked consumer lags. The cluster is empty for
+// rankings across all clusters.
+type TopConsumerList struct {
+	Cluster   string        `json:"cluster,omitempty"`
+	By        RankBy        `json:"by"`
+	Consumers []ConsumerLag `json:"consumers"`
+}
+
+// OffsetResetResponse contains the offset changes of a consumer group.
+type OffsetResetResponse struct {
+	Cluster  string         `json:"cluster"`
+	Consumer string         `json:"consumer"`
+	DryRun   bool           `json:"dry_run"`
+	Changes  []OffsetChange `json:"changes"`
+}
+
+// TopicDetailV2 contains the partitions of a topic.
+type TopicDetailV2 struct {
+	Cluster    string           `json:"cluster"`
+	Topic      string           `json:"topic"`
+	Partitions []TopicPartition `json:"partitions"`
+}
+
+// TopicConsumerListV2 lists the partition offsets of consumer groups
+// reading a topic.
+type TopicConsumerListV2 struct {
+	Cluster   string                    `json:"cluster"`
+	Topic     string                    `json:"topic"`
+	Consumers []ConsumerTopicPartitions `json:"consumers"`
+	Next      string                    `json:"next,omitempty"`
+}
+
+// ConsumerListV2 lists the partition offsets of consumer groups.
+type ConsumerListV2 struct {
+	Cluster   string               `json:"cluster"`
+	Consumers []ConsumerPartitions `json:"consumers"`
+	Next      string               `json:"next,omitempty"`
+}
+
+// ConsumerDetailV2 contains the partition offsets of a consumer group.
+type ConsumerDetailV2 struct {
+	Cluster string `json:"cluster"`
+	ConsumerPartitions
+}
+
+// LagSample is a point-in-time total lag measurement.
+type LagSample struct {
+	Timestamp int64 `json:"timestamp"`
+	Lag       int64 `json:"lag"`
+}
+
+// ConsumerTopicHistory contains the recent lag history of a consumer group
+// on a topic.
+type ConsumerTopicHistory struct {
+	Topic   string      `json:"topic"`
+	Samples []LagSample `json:"samples"`
+}
+
+// ConsumerHistoryV2 contains the recent lag history of a consumer group.
+type ConsumerHistoryV2 struct {
+	Cluster  string                 `json:"cluster"`
+	Consumer string                 `json:"consumer"`
+	Topics   []ConsumerTopicHistory `json:"topics"`
+}
@@ -0,0 +1,197 @@
+package client_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bsm/rumour/client"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Types", func() {
+	var schemas map[string]map[string]interface{}
+
+	// response schemas and the client types they decode into
+	responses := map[string]func() interface{}{
+		"LagSummary":              func() interface{} { return new(client.LagSummary) },
+		"ConsumerOffset":          func() interface{} { return new(client.ConsumerOffset) },
+		"ConsumerTopic":           func() interface{} { return new(client.ConsumerTopic) },
+		"Consumer":                func() interface{} { return new(client.Consumer) },
+		"TopicConsumer":           func() interface{} { return new(client.TopicConsumer) },
+		"RankBy":                  func() interface{} { return new(client.RankBy) },
+		"ConsumerLag":             func() interface{} { return new(client.ConsumerLag) },
+		"LagSample":               func() interface{} { return new(client.LagSample) },
+		"TopicPartition":          func() interface{} { return new(client.TopicPartition) },
+		"PartitionOffset":         func() interface{} { return new(client.PartitionOffset) },
+		"ConsumerTopicPartitions": func() interface{} { return new(client.ConsumerTopicPartitions) },
+		"ConsumerPartitions":      func() interface{} { return new(client.ConsumerPartitions) },
+		"Alert":                   func() interface{} { return new(client.Alert) },
+		"Silence":                 func() interface{} { return new(client.Silence) },
+		"OffsetChange":            func() interface{} { return new(client.OffsetChange) },
+		"Event":                   func() interface{} { return new(client.Event) },
+		"ClusterDetail":           func() interface{} { return new(client.ClusterDetail) },
+		"ClusterConfig":           func() interface{} { return new(client.ClusterConfig) },
+		"TopicList":               func() interface{} { return new(client.TopicList) },
+		"TopicDetail":             func() interface{} { return new(client.TopicDetail) },
+		"TopicConsumerList":       func() interface{} { return new(client.TopicConsumerList) },
+		"ConsumerGroupList":       func() interface{} { return new(client.ConsumerGroupList) },
+		"ConsumerList":            func() interface{} { return new(client.ConsumerList) },
+		"ConsumerDetail":          func() interface{} { return new(client.ConsumerDetail) },
+		"TopConsumerList":         func() interface{} { return new(client.TopConsumerList) },
+		"OffsetResetResponse":     func() interface{} { return new(client.OffsetResetResponse) },
+		"TopicDetailV2":           func() interface{} { return new(client.TopicDetailV2) },
+		"TopicConsumerListV2":     func() interface{} { return new(client.TopicConsumerListV2) },
+		"ConsumerListV2":          func() interface{} { return new(client.ConsumerListV2) },
+		"ConsumerDetailV2":        func() interface{} { return new(client.ConsumerDetailV2) },
+		"ConsumerTopicHistory":    func() interface{} { return new(client.ConsumerTopicHistory) },
+		"ConsumerHistoryV2":       func() interface{} { return new(client.ConsumerHistoryV2) },
+	}
+
+	// request schemas and fully populated client values
+	requests := map[string]interface{}{
+		"SilenceRequest": &client.SilenceRequest{
+			Cluster: "main", Group: "orders-*", Topic: "orders", StartsAt: 1, EndsAt: 2,
+			Duration: time.Hour, Comment: "backfill", CreatedBy: "alice",
+		},
+		"OffsetReset": &client.OffsetReset{
+			Topics: []string{"orders"}, Partitions: []int32{0}, Mode: client.ResetToOffset,
+			Timestamp: 1, Offset: 2, Shift: 3, DryRun: true,
+		},
+		"ClusterConfig": &client.ClusterConfig{
+			Name: "main", Brokers: []string{"10.0.0.1:9092"}, MetaRefresh: time.Minute, OffsetRefresh: time.Second,
+		},
+	}
+
+	// schemas which are decoded into plain Go values by the client
+	plain := []string{"Error", "ClusterList", "AlertList", "SilenceList"}
+
+	BeforeEach(func() {
+		data, err := os.ReadFile("../internal/server/openapi.json")
+		Expect(err).NotTo(HaveOccurred())
+
+		var spec struct {
+			Components struct {
+				Schemas map[string]map[string]interface{} `json:"schemas"`
+			} `json:"components"`
+		}
+		Expect(json.Unmarshal(data, &spec)).To(Succeed())
+		schemas = spec.Components.Schemas
+	})
+
+	It("should cover all schemas", func() {
+		var missing []string
+		for name := range schemas {
+			_, isResponse := responses[name]
+			_, isRequest := requests[name]
+			if !isResponse && !isRequest && !containsString(plain, name) {
+				missing = append(missing, name)
+			}
+		}
+		sort.Strings(missing)
+		Expect(missing).To(BeEmpty())
+	})
+
+	It("should decode schema examples", func() {
+		for name, fn := range responses {
+			schema, ok := schemas[name]
+			Expect(ok).To(BeTrue(), name)
+
+			data, err := json.Marshal(schemaExample(schemas, schema))
+			Expect(err).NotTo(HaveOccurred())
+
+			dec := json.NewDecoder(bytes.NewReader(data))
+			dec.DisallowUnknownFields()
+			v := fn()
+			Expect(dec.Decode(v)).To(Succeed(), "%s: %s", name, data)
+			Expect(encodedFields(v)).To(ConsistOf(schemaFields(schema)), name)
+		}
+	})
+
+	It("should encode requests", func() {
+		for name, v := range requests {
+			schema, ok := schemas[name]
+			Expect(ok).To(BeTrue(), name)
+			Expect(encodedFields(v)).To(ConsistOf(schemaFields(schema)), name)
+		}
+	})
+})
+
+// schemaExample builds an example value with all properties of a schema.
+func schemaExample(schemas map[string]map[string]interface{}, schema map[string]interface{}) interface{} {
+	if ref, ok := schema["$ref"].(string); ok {
+		return schemaExample(schemas, schemas[strings.TrimPrefix(ref, "#/components/schemas/")])
+	}
+	if alts, ok := schema["oneOf"].([]interface{}); ok {
+		return schemaExample(schemas, alts[0].(map[string]interface{}))
+	}
+	if v, ok := schema["example"]; ok {
+		return v
+	}
+	if v, ok := schema["default"]; ok {
+		return v
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		return enum[0]
+	}
+
+	switch schema["type"] {
+	case "object":
+		res := make(map[string]interface{})
+		props, _ := schema["properties"].(map[string]interface{})
+		for name, prop := range props {
+			res[name] = schemaExample(schemas, prop.(map[string]interface{}))
+		}
+		return res
+	case "array":
+		return []interface{}{schemaExample(schemas, schema["items"].(map[string]interface{}))}
+	case "integer":
+		return 1
+	case "number":
+		return 1.5
+	case "boolean":
+		return true
+	}
+	return "x"
+}
+
+// schemaFields returns the property names of an object schema.
+func schemaFields(schema map[string]interface{}) []string {
+	props, _ := schema["properties"].(map[string]interface{})
+	res := make([]string, 0, len(props))
+	for name := range props {
+		res = append(res, name)
+	}
+	return res
+}
+
+// encodedFields returns the JSON field names of an encoded value.
+func encodedFields(v interface{}) []string {
+	data, err := json.Marshal(v)
+	Expect(err).NotTo(HaveOccurred())
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil // not an object
+	}
+
+	res := make([]string, 0, len(fields))
+	for name := range fields {
+		res = append(res, name)
+	}
+	return res
+}
+
+func containsString(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
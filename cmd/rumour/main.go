@@ -2,15 +2,22 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"log"
+	"net"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/bsm/rumour/internal/alert"
 	"github.com/bsm/rumour/internal/rumour"
 	"github.com/bsm/rumour/internal/server"
 	"github.com/go-chi/httplog"
 	"github.com/kelseyhightower/envconfig"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 func main() {
@@ -24,9 +31,26 @@ func run(ctx context.Context) error {
 	defer cancel()
 
 	var rc struct {
-		Clusters []string `default:"default"`
-		HTTP     struct {
-			Addr string `default:":8080"`
+		Clusters     []string `default:"default"`
+		ClustersFile string   `split_words:"true"`
+		HTTP         struct {
+			Addr             string `default:":8080"`
+			Burrow           bool   `default:"false"`
+			AuthFile         string `split_words:"true"`
+			AuthHealthChecks bool   `split_words:"true" default:"false"`
+			Admin            bool   `default:"false"`
+			AuditLog         string `split_words:"true"`
+			TLS              struct {
+				Cert     string
+				Key      string
+				ClientCA string `split_words:"true"`
+			}
+		}
+		GRPC struct {
+			Addr string
+		}
+		Alerts struct {
+			Config string
 		}
 		Log struct {
 			Level string `default:"info"`
@@ -39,12 +63,24 @@ func run(ctx context.Context) error {
 	}
 
 	var cc []rumour.ClusterConfig
-	for _, cluster := range rc.Clusters {
-		c := rumour.ClusterConfig{Name: cluster}
-		if err := envconfig.Process("rumour_"+cluster, &c); err != nil {
+	if rc.ClustersFile != "" {
+		configs, err := rumour.LoadClusterConfigs(rc.ClustersFile)
+		if err != nil {
 			return err
 		}
-		cc = append(cc, c)
+
+		cc, rc.Clusters = configs, nil
+		for _, c := range configs {
+			rc.Clusters = append(rc.Clusters, c.Name)
+		}
+	} else {
+		for _, cluster := range rc.Clusters {
+			c := rumour.ClusterConfig{Name: cluster}
+			if err := envconfig.Process("rumour_"+cluster, &c); err != nil {
+				return err
+			}
+			cc = append(cc, c)
+		}
 	}
 
 	state := rumour.NewState(rc.Clusters)
@@ -52,21 +88,109 @@ func run(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	srv := server.NewHTTP(rc.HTTP.Addr, state, httplog.Options{
-		LogLevel: rc.Log.Level,
-		JSON:     rc.Log.JSON,
-		Tags:     rc.Log.Tags,
+
+	var alerts *alert.Engine
+	if rc.Alerts.Config != "" {
+		cfg, err := alert.LoadConfig(rc.Alerts.Config)
+		if err != nil {
+			return err
+		}
+		if alerts, err = alert.NewEngine(state, cfg); err != nil {
+			return err
+		}
+	}
+
+	var auth *server.Auth
+	if rc.HTTP.Admin && rc.HTTP.AuthFile == "" {
+		return errors.New("the admin API requires RUMOUR_HTTP_AUTH_FILE")
+	}
+	if rc.HTTP.AuthFile != "" {
+		if auth, err = server.NewAuth(rc.HTTP.AuthFile); err != nil {
+			return err
+		}
+	}
+
+	var tlsConfig *tls.Config
+	if rc.HTTP.TLS.Cert != "" || rc.HTTP.TLS.Key != "" {
+		if tlsConfig, err = server.NewTLSConfig(rc.HTTP.TLS.Cert, rc.HTTP.TLS.Key, rc.HTTP.TLS.ClientCA); err != nil {
+			return err
+		}
+	}
+
+	var admin server.Admin
+	var audit *server.AuditLog
+	if rc.HTTP.Admin {
+		admin = rumour.NewAdmin(fetcher)
+	}
+	if rc.HTTP.AuditLog != "" {
+		f, err := os.OpenFile(rc.HTTP.AuditLog, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		audit = server.NewAuditLog(f)
+	}
+
+	srv := server.NewHTTP(rc.HTTP.Addr, state, alerts, server.Options{
+		Log: httplog.Options{
+			LogLevel: rc.Log.Level,
+			JSON:     rc.Log.JSON,
+			Tags:     rc.Log.Tags,
+		},
+		Burrow:           rc.HTTP.Burrow,
+		Auth:             auth,
+		AuthHealthChecks: rc.HTTP.AuthHealthChecks,
+		Admin:            admin,
+		AuditLog:         audit,
 	})
 
+	var grpcSrv *grpc.Server
+	if rc.GRPC.Addr != "" {
+		lis, err := net.Listen("tcp", rc.GRPC.Addr)
+		if err != nil {
+			return err
+		}
+		defer lis.Close()
+
+		var opts []grpc.ServerOption
+		if tlsConfig != nil {
+			opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+		}
+
+		grpcSrv = server.NewGRPC(state, auth, opts...)
+		go func() {
+			if err := grpcSrv.Serve(lis); err != nil {
+				log.Println(err)
+			}
+		}()
+	}
+
 	go fetcher.RunLoop(ctx, state)
+	if rc.ClustersFile != "" {
+		go fetcher.ReloadLoop(ctx, rc.ClustersFile, 10*time.Second)
+	}
+	if auth != nil {
+		go auth.ReloadLoop(ctx, 10*time.Second)
+	}
+	if alerts != nil {
+		go alerts.RunLoop(ctx)
+	}
 	go func() {
 		sigs := make(chan os.Signal, 1)
 		signal.Notify(sigs, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
 		<-sigs
 
 		cancel()
+		if grpcSrv != nil {
+			grpcSrv.Stop()
+		}
 		_ = srv.Shutdown(context.Background())
 	}()
 
+	if tlsConfig != nil {
+		srv.TLSConfig = tlsConfig
+		return srv.ListenAndServeTLS("", "")
+	}
 	return srv.ListenAndServe()
 }
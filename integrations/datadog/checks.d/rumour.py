@@ -40,12 +40,11 @@ class RumourCheck(AgentCheck):
 
     def check_consumer_offsets(self, instance, clusters):
         for cluster in clusters:
-            endpoint = "/v1/clusters/%s/consumers" % (cluster)
+            endpoint = "/v1/clusters/%s/consumers?expand=true" % (cluster)
             consumers = self.rest(instance, endpoint).get("consumers", [])
-            for consumer in consumers:
-                consumer_endpoint = "%s/%s" % (endpoint, consumer)
-                topics = self.rest(instance, consumer_endpoint).get("topics", [])
-                for topic_data in topics:
+            for consumer_data in consumers:
+                consumer = consumer_data["consumer"]
+                for topic_data in consumer_data.get("topics", []):
                     topic = topic_data["topic"]
                     for partition, offset_data in enumerate(topic_data.get("offsets", [])):
                         tags = ["topic:%s" % topic, "partition:%s" % partition,
@@ -0,0 +1,56 @@
+package alert
+
+import "sort"
+
+// Severity indicates the severity of an alert.
+type Severity string
+
+// Severity levels.
+const (
+	SeverityWarn     Severity = "warn"
+	SeverityCritical Severity = "critical"
+)
+
+// Status indicates the status of an alert.
+type Status string
+
+// Alert statuses.
+const (
+	StatusFiring   Status = "firing"
+	StatusResolved Status = "resolved"
+)
+
+// Alert contains alert information.
+type Alert struct {
+	Cluster  string   `json:"cluster"`
+	Group    string   `json:"group"`
+	Topic    string   `json:"topic"`
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Status   Status   `json:"status"`
+	TotalLag int64    `json:"total_lag"`
+	MaxLag   int64    `json:"max_lag"`
+	TimeLag  int64    `json:"time_lag"`
+	StartsAt int64    `json:"starts_at"`
+	EndsAt   int64    `json:"ends_at,omitempty"`
+	Silenced bool     `json:"silenced"`
+}
+
+type alerts []Alert
+
+func (p alerts) Len() int      { return len(p) }
+func (p alerts) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+func (p alerts) Less(i, j int) bool {
+	if p[i].Cluster != p[j].Cluster {
+		return p[i].Cluster < p[j].Cluster
+	}
+	if p[i].Group != p[j].Group {
+		return p[i].Group < p[j].Group
+	}
+	if p[i].Topic != p[j].Topic {
+		return p[i].Topic < p[j].Topic
+	}
+	return p[i].Rule < p[j].Rule
+}
+
+func sortAlerts(p []Alert) { sort.Sort(alerts(p)) }
@@ -0,0 +1,218 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+var defaultAlertmanagerAnnotations = map[string]string{
+	"summary":     `Consumer group {{ .Group }} is lagging behind on {{ .Topic }}`,
+	"description": `Consumer group {{ .Group }} on cluster {{ .Cluster }} is {{ .TotalLag }} messages behind on {{ .Topic }} (max partition lag: {{ .MaxLag }}, time lag: {{ .TimeLag }}s).`,
+}
+
+// AlertmanagerConfig configures a Prometheus Alertmanager notifier.
+type AlertmanagerConfig struct {
+	DeliveryConfig
+
+	// Name of the notifier. Default: the URL host.
+	Name string `json:"name"`
+	// URL of the Alertmanager. The /api/v2/alerts path is appended if the
+	// URL has no path.
+	URL string `json:"url"`
+	// Headers contains custom HTTP request headers.
+	Headers map[string]string `json:"headers"`
+	// Labels contains additional static labels.
+	Labels map[string]string `json:"labels"`
+	// Annotations contains annotation templates, rendered for each alert.
+	// Default: summary and description.
+	Annotations map[string]string `json:"annotations"`
+	// GeneratorURL is an optional link back to Rumour.
+	GeneratorURL string `json:"generator_url"`
+	// ResendInterval is the interval at which active alerts are resent.
+	// Default: 1m.
+	ResendInterval Duration `json:"resend_interval"`
+}
+
+// Alertmanager pushes alerts to Prometheus Alertmanager.
+type Alertmanager struct {
+	name        string
+	url         string
+	headers     map[string]string
+	labels      map[string]string
+	annotations map[string]*template.Template
+	generator   string
+	resend      time.Duration
+	client      *http.Client
+
+	severities map[alertKey]Severity
+	mu         sync.Mutex
+}
+
+// NewAlertmanager inits an Alertmanager notifier.
+func NewAlertmanager(cfg *AlertmanagerConfig) (*Alertmanager, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, err
+	} else if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.New("alertmanager URL must be absolute")
+	}
+	if strings.Trim(u.Path, "/") == "" {
+		u.Path = "/api/v2/alerts"
+	}
+
+	name := cfg.Name
+	if name == "" {
+		name = u.Host
+	}
+
+	resend := time.Duration(cfg.ResendInterval)
+	if resend <= 0 {
+		resend = time.Minute
+	}
+
+	texts := cfg.Annotations
+	if len(texts) == 0 {
+		texts = defaultAlertmanagerAnnotations
+	}
+	annotations := make(map[string]*template.Template, len(texts))
+	for key, text := range texts {
+		tmpl, err := template.New(key).Funcs(templateFuncs).Parse(text)
+		if err != nil {
+			return nil, err
+		}
+		annotations[key] = tmpl
+	}
+
+	return &Alertmanager{
+		name:        name,
+		url:         u.String(),
+		headers:     cfg.Headers,
+		labels:      cfg.Labels,
+		annotations: annotations,
+		generator:   cfg.GeneratorURL,
+		resend:      resend,
+		client:      new(http.Client),
+		severities:  make(map[alertKey]Severity),
+	}, nil
+}
+
+// Name implements Notifier.
+func (m *Alertmanager) Name() string { return "alertmanager " + m.name }
+
+// ResendInterval returns the interval at which active alerts are resent.
+func (m *Alertmanager) ResendInterval() time.Duration { return m.resend }
+
+// Notify implements Notifier.
+func (m *Alertmanager) Notify(ctx context.Context, alerts []Alert) error {
+	now := time.Now()
+	payload := make([]alertmanagerAlert, 0, len(alerts))
+
+	// changes are committed after delivery, an empty severity means resolved
+	changes := make(map[alertKey]Severity, len(alerts))
+
+	m.mu.Lock()
+	for _, a := range alerts {
+		key := keyOf(a)
+		prev, ok := changes[key]
+		if !ok {
+			prev = m.severities[key]
+		}
+
+		// severity is part of the alert identity, resolve the previous one
+		if prev != "" && prev != a.Severity {
+			old := a
+			old.Severity = prev
+			old.Status = StatusResolved
+			old.EndsAt = now.Unix()
+			payload = append(payload, m.convert(old, now))
+		}
+
+		if a.Status == StatusFiring {
+			changes[key] = a.Severity
+		} else {
+			changes[key] = ""
+		}
+		payload = append(payload, m.convert(a, now))
+	}
+	m.mu.Unlock()
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range m.headers {
+		req.Header.Set(k, v)
+	}
+
+	if err := doRequest(m.client, req); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, severity := range changes {
+		if severity == "" {
+			delete(m.severities, key)
+		} else {
+			m.severities[key] = severity
+		}
+	}
+	return nil
+}
+
+func (m *Alertmanager) convert(a Alert, now time.Time) alertmanagerAlert {
+	labels := make(map[string]string, len(m.labels)+5)
+	for k, v := range m.labels {
+		labels[k] = v
+	}
+	labels["alertname"] = a.Rule
+	labels["cluster"] = a.Cluster
+	labels["group"] = a.Group
+	labels["topic"] = a.Topic
+	labels["severity"] = string(a.Severity)
+
+	annotations := make(map[string]string, len(m.annotations))
+	for key, tmpl := range m.annotations {
+		var buf strings.Builder
+		if err := tmpl.Execute(&buf, a); err == nil {
+			annotations[key] = buf.String()
+		}
+	}
+
+	// firing alerts expire unless resent, like Prometheus does
+	endsAt := now.Add(4 * m.resend)
+	if a.Status == StatusResolved {
+		endsAt = time.Unix(a.EndsAt, 0)
+	}
+
+	return alertmanagerAlert{
+		Labels:       labels,
+		Annotations:  annotations,
+		StartsAt:     time.Unix(a.StartsAt, 0).UTC(),
+		EndsAt:       endsAt.UTC(),
+		GeneratorURL: m.generator,
+	}
+}
+
+type alertmanagerAlert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL,omitempty"`
+}
@@ -0,0 +1,155 @@
+package alert_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/bsm/rumour/internal/alert"
+	"github.com/bsm/rumour/internal/rumour"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Alertmanager", func() {
+	var server *httptest.Server
+	var received [][]map[string]interface{}
+	var paths []string
+	var failures int
+	var mu sync.Mutex
+
+	firing := alert.Alert{
+		Cluster:  "default",
+		Group:    "orders-worker",
+		Topic:    "orders",
+		Rule:     "lagging",
+		Severity: alert.SeverityWarn,
+		Status:   alert.StatusFiring,
+		TotalLag: 70,
+		MaxLag:   60,
+		StartsAt: 1515151515,
+	}
+
+	numReceived := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received)
+	}
+
+	BeforeEach(func() {
+		received, paths, failures = nil, nil, 0
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if failures > 0 {
+				failures--
+				http.Error(w, "try again", http.StatusServiceUnavailable)
+				return
+			}
+
+			var batch []map[string]interface{}
+			_ = json.NewDecoder(r.Body).Decode(&batch)
+			received = append(received, batch)
+			paths = append(paths, r.URL.Path)
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should push alerts", func() {
+		subject, err := alert.NewAlertmanager(&alert.AlertmanagerConfig{
+			URL:    server.URL,
+			Labels: map[string]string{"team": "data"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subject.Notify(context.Background(), []alert.Alert{firing})).To(Succeed())
+		Expect(paths).To(Equal([]string{"/api/v2/alerts"}))
+		Expect(received).To(HaveLen(1))
+		Expect(received[0]).To(HaveLen(1))
+
+		pushed := received[0][0]
+		Expect(pushed).To(HaveKeyWithValue("labels", map[string]interface{}{
+			"alertname": "lagging",
+			"cluster":   "default",
+			"group":     "orders-worker",
+			"topic":     "orders",
+			"severity":  "warn",
+			"team":      "data",
+		}))
+		Expect(pushed).To(HaveKeyWithValue("annotations", HaveKeyWithValue("summary", "Consumer group orders-worker is lagging behind on orders")))
+		Expect(pushed).To(HaveKeyWithValue("startsAt", "2018-01-05T11:25:15Z"))
+		Expect(pushed).To(HaveKey("endsAt"))
+	})
+
+	It("should resolve previous severities", func() {
+		subject, err := alert.NewAlertmanager(&alert.AlertmanagerConfig{URL: server.URL + "/custom"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subject.Notify(context.Background(), []alert.Alert{firing})).To(Succeed())
+
+		critical := firing
+		critical.Severity = alert.SeverityCritical
+		Expect(subject.Notify(context.Background(), []alert.Alert{critical})).To(Succeed())
+
+		resolved := critical
+		resolved.Status = alert.StatusResolved
+		resolved.EndsAt = 1515151615
+		Expect(subject.Notify(context.Background(), []alert.Alert{resolved})).To(Succeed())
+
+		Expect(paths).To(Equal([]string{"/custom", "/custom", "/custom"}))
+		Expect(received).To(HaveLen(3))
+		Expect(received[1]).To(HaveLen(2))
+		Expect(received[1][0]).To(HaveKeyWithValue("labels", HaveKeyWithValue("severity", "warn")))
+		Expect(received[1][1]).To(HaveKeyWithValue("labels", HaveKeyWithValue("severity", "critical")))
+		Expect(received[2]).To(HaveLen(1))
+		Expect(received[2][0]).To(HaveKeyWithValue("labels", HaveKeyWithValue("severity", "critical")))
+		Expect(received[2][0]).To(HaveKeyWithValue("endsAt", "2018-01-05T11:26:55Z"))
+	})
+
+	It("should resolve previous severities on retry", func() {
+		subject, err := alert.NewAlertmanager(&alert.AlertmanagerConfig{URL: server.URL})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subject.Notify(context.Background(), []alert.Alert{firing})).To(Succeed())
+
+		critical := firing
+		critical.Severity = alert.SeverityCritical
+		failures = 1
+		Expect(subject.Notify(context.Background(), []alert.Alert{critical})).To(HaveOccurred())
+		Expect(subject.Notify(context.Background(), []alert.Alert{critical})).To(Succeed())
+
+		Expect(received).To(HaveLen(2))
+		Expect(received[1]).To(HaveLen(2))
+		Expect(received[1][0]).To(HaveKeyWithValue("labels", HaveKeyWithValue("severity", "warn")))
+		Expect(received[1][1]).To(HaveKeyWithValue("labels", HaveKeyWithValue("severity", "critical")))
+	})
+
+	It("should resend active alerts", func() {
+		state := rumour.NewState(nil)
+		state.Cluster("default").UpdateTopic("orders", []int64{100})
+		state.Cluster("default").UpdateConsumerOffsets("orders-worker", "orders", time.Now().Unix(), []int64{10})
+
+		engine, err := alert.NewEngine(state, &alert.Config{
+			Interval: alert.Duration(10 * time.Millisecond),
+			Clusters: map[string]alert.ClusterConfig{"default": {Rules: []alert.Rule{
+				{Name: "lagging", Warn: alert.Threshold{TotalLag: 50}},
+			}}},
+			Alertmanagers: []alert.AlertmanagerConfig{{
+				URL:            server.URL,
+				ResendInterval: alert.Duration(20 * time.Millisecond),
+			}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go engine.RunLoop(ctx)
+
+		Eventually(numReceived).Should(BeNumerically(">=", 3))
+	})
+})
@@ -0,0 +1,173 @@
+package alert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"os"
+	"time"
+
+	"github.com/bsm/rumour/internal/pattern"
+)
+
+// Config contains the alerting configuration.
+type Config struct {
+	// Interval is the rule evaluation interval. Default: 30s.
+	Interval Duration `json:"interval"`
+	// Clusters maps cluster names to cluster-specific configs.
+	Clusters map[string]ClusterConfig `json:"clusters"`
+	// Webhooks configures webhook notifiers.
+	Webhooks []WebhookConfig `json:"webhooks"`
+	// Alertmanagers configures Prometheus Alertmanager notifiers.
+	Alertmanagers []AlertmanagerConfig `json:"alertmanagers"`
+	// Email configures SMTP email notifiers.
+	Email []EmailConfig `json:"email"`
+	// Maintenance configures recurring maintenance windows.
+	Maintenance []MaintenanceWindow `json:"maintenance"`
+}
+
+// LoadConfig loads a JSON config from a file.
+func LoadConfig(name string) (*Config, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := new(Config)
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("alert: unable to parse %s: %w", name, err)
+	}
+	return cfg, nil
+}
+
+func (c *Config) norm() {
+	if c.Interval <= 0 {
+		c.Interval = Duration(30 * time.Second)
+	}
+}
+
+// ClusterConfig contains the alerting config of a single cluster.
+type ClusterConfig struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Rule defines alerting thresholds for matching consumer groups and topics.
+type Rule struct {
+	// Name identifies the rule, must be unique per cluster.
+	Name string `json:"name"`
+	// Groups contains consumer group patterns. Patterns are globs unless
+	// wrapped in slashes, e.g. "/^orders-.+$/", which are parsed as regular
+	// expressions. Matches all groups when empty.
+	Groups []string `json:"groups"`
+	// Topics contains topic patterns. Matches all topics when empty.
+	Topics []string `json:"topics"`
+	// Warn contains the warning threshold.
+	Warn Threshold `json:"warn"`
+	// Critical contains the critical threshold.
+	Critical Threshold `json:"critical"`
+	// Recipients contains email recipients for alerts of this rule,
+	// overriding the defaults of the email notifiers.
+	Recipients []string `json:"recipients"`
+}
+
+// Threshold defines the limits of a severity level. A threshold is breached
+// when any of the non-zero limits is exceeded. Alerts fire once the breach
+// has held for at least For.
+type Threshold struct {
+	TotalLag int64    `json:"total_lag"`
+	MaxLag   int64    `json:"max_lag"`
+	TimeLag  Duration `json:"time_lag"`
+	For      Duration `json:"for"`
+}
+
+// IsZero returns true if no limits are set.
+func (t Threshold) IsZero() bool {
+	return t.TotalLag <= 0 && t.MaxLag <= 0 && t.TimeLag <= 0
+}
+
+func (t Threshold) breached(m metrics) bool {
+	return (t.TotalLag > 0 && m.TotalLag > t.TotalLag) ||
+		(t.MaxLag > 0 && m.MaxLag > t.MaxLag) ||
+		(t.TimeLag > 0 && m.TimeLag > time.Duration(t.TimeLag))
+}
+
+// --------------------------------------------------------------------
+
+// Duration is a JSON-friendly time.Duration. It is encoded as a string,
+// e.g. "90s" and also accepts plain numbers, interpreted as seconds.
+type Duration time.Duration
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	switch vv := v.(type) {
+	case float64:
+		*d = Duration(vv * float64(time.Second))
+	case string:
+		dur, err := time.ParseDuration(vv)
+		if err != nil {
+			return err
+		}
+		*d = Duration(dur)
+	default:
+		return fmt.Errorf("alert: invalid duration %s", data)
+	}
+	return nil
+}
+
+// --------------------------------------------------------------------
+
+type compiledRule struct {
+	Rule
+	groups pattern.List
+	topics pattern.List
+}
+
+func compileRules(rules []Rule) ([]compiledRule, error) {
+	seen := make(map[string]struct{}, len(rules))
+	res := make([]compiledRule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.Name == "" {
+			return nil, errors.New("rule name is required")
+		}
+		if _, ok := seen[rule.Name]; ok {
+			return nil, fmt.Errorf("duplicate rule %q", rule.Name)
+		}
+		seen[rule.Name] = struct{}{}
+
+		if rule.Warn.IsZero() && rule.Critical.IsZero() {
+			return nil, fmt.Errorf("rule %q has no thresholds", rule.Name)
+		}
+
+		for _, addr := range rule.Recipients {
+			if _, err := mail.ParseAddress(addr); err != nil {
+				return nil, fmt.Errorf("rule %q: invalid recipient: %w", rule.Name, err)
+			}
+		}
+
+		groups, err := pattern.Compile(rule.Groups)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", rule.Name, err)
+		}
+		topics, err := pattern.Compile(rule.Topics)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", rule.Name, err)
+		}
+		res = append(res, compiledRule{Rule: rule, groups: groups, topics: topics})
+	}
+	return res, nil
+}
+
+func (r *compiledRule) match(group, topic string) bool {
+	return r.groups.Match(group) && r.topics.Match(topic)
+}
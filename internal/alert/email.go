@@ -0,0 +1,365 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/bsm/rumour/internal/rumour"
+)
+
+const defaultEmailSubject = `[Rumour] {{ .Firing }} firing, {{ .Resolved }} resolved consumer lag alert(s)`
+
+const defaultEmailText = `{{ range .Alerts -}}
+[{{ upper .Status }}] {{ .Severity }}: {{ .Group }} on {{ .Cluster }}/{{ .Topic }} (rule: {{ .Rule }})
+Total lag: {{ .TotalLag }}, max partition lag: {{ .MaxLag }}, time lag: {{ .TimeLag }}s
+{{ if .Partitions }}
+  Partition       Offset          Lag
+{{- range .Partitions }}
+  {{ printf "%-15d %-15d %d" .Partition .Offset .Lag }}{{ if .Lag }} *{{ end }}
+{{- end }}
+{{ end }}
+{{ end }}`
+
+const defaultEmailHTML = `<html>
+<body style="font-family: sans-serif">
+{{- range .Alerts }}
+<h3>[{{ upper .Status }}] {{ .Severity }}: {{ .Group }} on {{ .Cluster }}/{{ .Topic }}</h3>
+<p>Rule: {{ .Rule }}<br>Total lag: {{ .TotalLag }}<br>Max partition lag: {{ .MaxLag }}<br>Time lag: {{ .TimeLag }}s</p>
+{{- if .Partitions }}
+<table border="1" cellpadding="4" cellspacing="0">
+<tr><th>Partition</th><th>Offset</th><th>Lag</th></tr>
+{{- range .Partitions }}
+<tr{{ if .Lag }} style="background: #fdd"{{ end }}><td>{{ .Partition }}</td><td>{{ .Offset }}</td><td>{{ .Lag }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+{{- end }}
+</body>
+</html>`
+
+// EmailConfig configures an SMTP email notifier.
+type EmailConfig struct {
+	DeliveryConfig
+
+	// Name of the notifier. Default: the server address.
+	Name string `json:"name"`
+	// Addr is the SMTP server address, e.g. "smtp.example.com:587".
+	Addr string `json:"addr"`
+	// StartTLS controls the use of STARTTLS, one of "auto", "require" or
+	// "disable". Default: "auto", uses STARTTLS if supported by the server.
+	StartTLS string `json:"starttls"`
+	// InsecureSkipVerify disables certificate verification.
+	InsecureSkipVerify bool `json:"insecure_skip_verify"`
+	// Username and Password enable PLAIN authentication.
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// From is the sender address.
+	From string `json:"from"`
+	// To contains the default recipients, for rules without recipients.
+	To []string `json:"to"`
+	// Subject, Text and HTML are templates for the email subject, plain-text
+	// and HTML bodies.
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html"`
+}
+
+// Email delivers alert digests via SMTP.
+type Email struct {
+	name     string
+	addr     string
+	host     string
+	startTLS string
+	insecure bool
+	auth     smtp.Auth
+	from     string
+	to       []string
+
+	subject *template.Template
+	text    *template.Template
+	html    *htmltemplate.Template
+
+	state      *rumour.State
+	recipients func(Alert) []string
+}
+
+// NewEmail inits an email notifier. The state is optional and used to render
+// per-partition lag tables.
+func NewEmail(cfg *EmailConfig, state *rumour.State) (*Email, error) {
+	host, _, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := mail.ParseAddress(cfg.From); err != nil {
+		return nil, fmt.Errorf("invalid sender: %w", err)
+	}
+	for _, addr := range cfg.To {
+		if _, err := mail.ParseAddress(addr); err != nil {
+			return nil, fmt.Errorf("invalid recipient: %w", err)
+		}
+	}
+
+	startTLS := cfg.StartTLS
+	switch startTLS {
+	case "":
+		startTLS = "auto"
+	case "auto", "require", "disable":
+	default:
+		return nil, fmt.Errorf("invalid starttls option %q", startTLS)
+	}
+
+	name := cfg.Name
+	if name == "" {
+		name = cfg.Addr
+	}
+
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, host)
+	}
+
+	n := &Email{
+		name:     name,
+		addr:     cfg.Addr,
+		host:     host,
+		startTLS: startTLS,
+		insecure: cfg.InsecureSkipVerify,
+		auth:     auth,
+		from:     cfg.From,
+		to:       cfg.To,
+		state:    state,
+	}
+
+	if n.subject, err = template.New("subject").Funcs(templateFuncs).Parse(orDefault(cfg.Subject, defaultEmailSubject)); err != nil {
+		return nil, err
+	}
+	if n.text, err = template.New("text").Funcs(templateFuncs).Parse(orDefault(cfg.Text, defaultEmailText)); err != nil {
+		return nil, err
+	}
+	if n.html, err = htmltemplate.New("html").Funcs(htmltemplate.FuncMap(templateFuncs)).Parse(orDefault(cfg.HTML, defaultEmailHTML)); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// Name implements Notifier.
+func (n *Email) Name() string { return "email " + n.name }
+
+// Notify implements Notifier. Alerts are batched into a single digest per
+// set of recipients. When only some digests fail, the remaining ones are
+// still sent and the alerts of the failed digests are reported as failed.
+func (n *Email) Notify(ctx context.Context, alerts []Alert) error {
+	batches := make(map[string][]Alert)
+	for _, a := range alerts {
+		to := n.to
+		if n.recipients != nil {
+			if rcpt := n.recipients(a); len(rcpt) != 0 {
+				to = rcpt
+			}
+		}
+		if len(to) == 0 {
+			continue
+		}
+
+		key := strings.Join(to, ",")
+		batches[key] = append(batches[key], a)
+	}
+
+	keys := make([]string, 0, len(batches))
+	for key := range batches {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var failed []Alert
+	var first error
+	for _, key := range keys {
+		if err := n.deliver(ctx, strings.Split(key, ","), batches[key]); err != nil {
+			if first == nil {
+				first = err
+			}
+			failed = append(failed, batches[key]...)
+		}
+	}
+
+	switch len(failed) {
+	case 0:
+		return nil
+	case len(alerts):
+		return first
+	}
+	return &partialError{Failed: failed, Err: first}
+}
+
+func (n *Email) deliver(ctx context.Context, to []string, alerts []Alert) error {
+	msg, err := n.render(to, alerts)
+	if err != nil {
+		return err
+	}
+	return n.send(ctx, to, msg)
+}
+
+func (n *Email) render(to []string, alerts []Alert) ([]byte, error) {
+	data := emailData{Alerts: make([]emailAlert, 0, len(alerts))}
+	for _, a := range alerts {
+		if a.Status == StatusFiring {
+			data.Firing++
+		} else {
+			data.Resolved++
+		}
+		data.Alerts = append(data.Alerts, emailAlert{Alert: a, Partitions: n.partitions(a)})
+	}
+
+	var subject strings.Builder
+	if err := n.subject.Execute(&subject, data); err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	mw := multipart.NewWriter(buf)
+
+	fmt.Fprintf(buf, "From: %s\r\n", n.from)
+	fmt.Fprintf(buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", strings.TrimSpace(subject.String())))
+	fmt.Fprintf(buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", mw.Boundary())
+
+	text, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=utf-8"}})
+	if err != nil {
+		return nil, err
+	}
+	if err := n.text.Execute(text, data); err != nil {
+		return nil, err
+	}
+
+	html, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/html; charset=utf-8"}})
+	if err != nil {
+		return nil, err
+	}
+	if err := n.html.Execute(html, data); err != nil {
+		return nil, err
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (n *Email) partitions(a Alert) []emailPartition {
+	if n.state == nil {
+		return nil
+	}
+	cs := n.state.Cluster(a.Cluster)
+	if cs == nil {
+		return nil
+	}
+	topics, _ := cs.ConsumerTopics(a.Group)
+	for _, ct := range topics {
+		if ct.Topic != a.Topic {
+			continue
+		}
+
+		res := make([]emailPartition, 0, len(ct.Offsets))
+		for part, o := range ct.Offsets {
+			res = append(res, emailPartition{Partition: part, Offset: o.Offset, Lag: o.Lag})
+		}
+		return res
+	}
+	return nil
+}
+
+func (n *Email) send(ctx context.Context, to []string, msg []byte) error {
+	conn, err := new(net.Dialer).DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, n.host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if n.startTLS != "disable" {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: n.host, InsecureSkipVerify: n.insecure}); err != nil { //nolint:gosec
+				return err
+			}
+		} else if n.startTLS == "require" {
+			return errors.New("server does not support STARTTLS")
+		}
+	}
+
+	if n.auth != nil {
+		if err := client.Auth(n.auth); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(n.from); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := client.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+type emailData struct {
+	Alerts   []emailAlert
+	Firing   int
+	Resolved int
+}
+
+type emailAlert struct {
+	Alert
+	Partitions []emailPartition
+}
+
+type emailPartition struct {
+	Partition int
+	Offset    int64
+	Lag       int64
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
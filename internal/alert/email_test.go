@@ -0,0 +1,287 @@
+package alert_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bsm/rumour/internal/alert"
+	"github.com/bsm/rumour/internal/rumour"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Email", func() {
+	var sink *smtpSink
+	var state *rumour.State
+
+	firing := alert.Alert{
+		Cluster:  "default",
+		Group:    "orders-worker",
+		Topic:    "orders",
+		Rule:     "lagging",
+		Severity: alert.SeverityWarn,
+		Status:   alert.StatusFiring,
+		TotalLag: 70,
+		MaxLag:   60,
+		StartsAt: 1515151515,
+	}
+
+	BeforeEach(func() {
+		var err error
+		sink, err = newSMTPSink()
+		Expect(err).NotTo(HaveOccurred())
+
+		state = rumour.NewState(nil)
+		state.Cluster("default").UpdateTopic("orders", []int64{100, 100, 100})
+		state.Cluster("default").UpdateConsumerOffsets("orders-worker", "orders", 1515151515, []int64{100, 40, 90})
+	})
+
+	AfterEach(func() {
+		_ = sink.Close()
+	})
+
+	It("should validate config", func() {
+		_, err := alert.NewEmail(&alert.EmailConfig{Addr: "localhost"}, nil)
+		Expect(err).To(MatchError(ContainSubstring("missing port")))
+
+		_, err = alert.NewEmail(&alert.EmailConfig{Addr: sink.Addr(), From: "bad"}, nil)
+		Expect(err).To(MatchError(ContainSubstring("invalid sender")))
+
+		_, err = alert.NewEmail(&alert.EmailConfig{Addr: sink.Addr(), From: "rumour@example.com", StartTLS: "maybe"}, nil)
+		Expect(err).To(MatchError(`invalid starttls option "maybe"`))
+	})
+
+	It("should send digests", func() {
+		subject, err := alert.NewEmail(&alert.EmailConfig{
+			Addr:     sink.Addr(),
+			Username: "user",
+			Password: "pass",
+			From:     "rumour@example.com",
+			To:       []string{"data@example.com", "ops@example.com"},
+		}, state)
+		Expect(err).NotTo(HaveOccurred())
+
+		resolved := firing
+		resolved.Topic = "events"
+		resolved.Status = alert.StatusResolved
+		Expect(subject.Notify(context.Background(), []alert.Alert{firing, resolved})).To(Succeed())
+
+		Expect(sink.Messages()).To(HaveLen(1))
+		msg := sink.Messages()[0]
+		Expect(msg.Auth).To(Equal("\x00user\x00pass"))
+		Expect(msg.From).To(Equal("rumour@example.com"))
+		Expect(msg.To).To(Equal([]string{"data@example.com", "ops@example.com"}))
+
+		parsed, err := mail.ReadMessage(strings.NewReader(msg.Data))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parsed.Header.Get("Subject")).To(Equal("[Rumour] 1 firing, 1 resolved consumer lag alert(s)"))
+		Expect(parsed.Header.Get("Content-Type")).To(HavePrefix("multipart/alternative"))
+
+		body, err := io.ReadAll(parsed.Body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(body)).To(ContainSubstring("[FIRING] warn: orders-worker on default/orders (rule: lagging)"))
+		Expect(string(body)).To(ContainSubstring("[RESOLVED] warn: orders-worker on default/events (rule: lagging)"))
+		Expect(string(body)).To(ContainSubstring("  1               40              60 *\r\n"))
+		Expect(string(body)).To(ContainSubstring(`<tr style="background: #fdd"><td>1</td><td>40</td><td>60</td></tr>`))
+	})
+
+	It("should support per-rule recipients", func() {
+		engine, err := alert.NewEngine(state, &alert.Config{
+			Interval: alert.Duration(10 * time.Millisecond),
+			Clusters: map[string]alert.ClusterConfig{"default": {Rules: []alert.Rule{
+				{Name: "lagging", Warn: alert.Threshold{TotalLag: 50}, Recipients: []string{"orders@example.com"}},
+			}}},
+			Email: []alert.EmailConfig{{
+				Addr: sink.Addr(),
+				From: "rumour@example.com",
+				To:   []string{"data@example.com"},
+			}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go engine.RunLoop(ctx)
+
+		Eventually(sink.Messages).Should(HaveLen(1))
+		Expect(sink.Messages()[0].To).To(Equal([]string{"orders@example.com"}))
+	})
+
+	It("should only retry failed digests", func() {
+		sink.Reject("bounce@example.com")
+
+		engine, err := alert.NewEngine(state, &alert.Config{
+			Interval: alert.Duration(10 * time.Millisecond),
+			Clusters: map[string]alert.ClusterConfig{"default": {Rules: []alert.Rule{
+				{Name: "lagging", Warn: alert.Threshold{TotalLag: 50}, Recipients: []string{"orders@example.com"}},
+				{Name: "bouncing", Warn: alert.Threshold{TotalLag: 50}, Recipients: []string{"bounce@example.com"}},
+			}}},
+			Email: []alert.EmailConfig{{
+				DeliveryConfig: alert.DeliveryConfig{Backoff: alert.Duration(time.Millisecond)},
+				Addr:           sink.Addr(),
+				From:           "rumour@example.com",
+			}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go engine.RunLoop(ctx)
+
+		Eventually(sink.Attempts).Should(Equal(4))
+		Consistently(sink.Messages, 100*time.Millisecond).Should(HaveLen(1))
+		Expect(sink.Messages()[0].To).To(Equal([]string{"orders@example.com"}))
+	})
+})
+
+// --------------------------------------------------------------------
+
+type smtpMessage struct {
+	Auth string
+	From string
+	To   []string
+	Data string
+}
+
+// smtpSink is a minimal local SMTP server.
+type smtpSink struct {
+	net.Listener
+
+	mu       sync.Mutex
+	messages []smtpMessage
+	rejected []string // rejected recipients
+	attempts int      // deliveries to rejected recipients
+}
+
+// Reject makes the sink reject a recipient.
+func (s *smtpSink) Reject(rcpt string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rejected = append(s.rejected, rcpt)
+}
+
+func (s *smtpSink) Attempts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attempts
+}
+
+func (s *smtpSink) isRejected(rcpt string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range s.rejected {
+		if r == rcpt {
+			s.attempts++
+			return true
+		}
+	}
+	return false
+}
+
+func newSMTPSink() (*smtpSink, error) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &smtpSink{Listener: lis}
+	go s.serve()
+	return s, nil
+}
+
+func (s *smtpSink) Addr() string { return s.Listener.Addr().String() }
+
+func (s *smtpSink) Messages() []smtpMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]smtpMessage(nil), s.messages...)
+}
+
+func (s *smtpSink) serve() {
+	for {
+		conn, err := s.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpSink) handle(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = io.WriteString(conn, line+"\r\n") }
+	reply("220 localhost ESMTP sink")
+
+	var msg smtpMessage
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch cmd {
+		case "EHLO", "HELO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			fields := strings.Fields(line)
+			if len(fields) == 3 {
+				msg.Auth = decodeBase64(fields[2])
+			}
+			reply("235 2.7.0 Authentication successful")
+		case "MAIL":
+			msg.From = strings.Trim(strings.TrimPrefix(line[5:], "FROM:"), "<>")
+			reply("250 OK")
+		case "RCPT":
+			rcpt := strings.Trim(strings.TrimPrefix(line[5:], "TO:"), "<>")
+			if s.isRejected(rcpt) {
+				reply("550 5.1.1 No such user")
+				continue
+			}
+			msg.To = append(msg.To, rcpt)
+			reply("250 OK")
+		case "DATA":
+			reply("354 Go ahead")
+			var data strings.Builder
+			for {
+				dl, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dl == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(dl, "."))
+			}
+			msg.Data = data.String()
+
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			msg = smtpMessage{}
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func decodeBase64(s string) string {
+	b, _ := base64.StdEncoding.DecodeString(s)
+	return string(b)
+}
@@ -0,0 +1,406 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bsm/rumour/internal/rumour"
+)
+
+// Engine evaluates alerting rules against the state.
+type Engine struct {
+	state    *rumour.State
+	rules    map[string][]compiledRule
+	interval time.Duration
+	logger   *log.Logger
+	notify   []*dispatcher
+
+	maintenance []MaintenanceWindow
+
+	mu       sync.RWMutex
+	alerts   map[alertKey]*tracker
+	silences map[string]*Silence
+}
+
+// NewEngine inits an alerting engine.
+func NewEngine(state *rumour.State, cfg *Config) (*Engine, error) {
+	cfg.norm()
+
+	rules := make(map[string][]compiledRule, len(cfg.Clusters))
+	for name, cc := range cfg.Clusters {
+		if state.Cluster(name) == nil {
+			return nil, fmt.Errorf("alert: unknown cluster %q", name)
+		}
+
+		compiled, err := compileRules(cc.Rules)
+		if err != nil {
+			return nil, fmt.Errorf("alert: cluster %q: %w", name, err)
+		}
+		rules[name] = compiled
+	}
+
+	maintenance := make([]MaintenanceWindow, len(cfg.Maintenance))
+	for i, mw := range cfg.Maintenance {
+		if err := mw.compile(); err != nil {
+			return nil, fmt.Errorf("alert: maintenance window %q: %w", mw.Name, err)
+		}
+		maintenance[i] = mw
+	}
+
+	e := &Engine{
+		state:       state,
+		rules:       rules,
+		interval:    time.Duration(cfg.Interval),
+		logger:      log.New(os.Stdout, "[alert] ", log.LstdFlags),
+		maintenance: maintenance,
+		alerts:      make(map[alertKey]*tracker),
+		silences:    make(map[string]*Silence),
+	}
+
+	for i := range cfg.Webhooks {
+		wc := &cfg.Webhooks[i]
+		n, err := NewWebhook(wc)
+		if err != nil {
+			return nil, fmt.Errorf("alert: webhook %q: %w", wc.URL, err)
+		}
+		e.addNotifier(n, wc.DeliveryConfig)
+	}
+	for i := range cfg.Alertmanagers {
+		ac := &cfg.Alertmanagers[i]
+		n, err := NewAlertmanager(ac)
+		if err != nil {
+			return nil, fmt.Errorf("alert: alertmanager %q: %w", ac.URL, err)
+		}
+		e.addNotifier(n, ac.DeliveryConfig)
+	}
+	for i := range cfg.Email {
+		ec := &cfg.Email[i]
+		n, err := NewEmail(ec, state)
+		if err != nil {
+			return nil, fmt.Errorf("alert: email %q: %w", ec.Addr, err)
+		}
+		n.recipients = e.recipients
+		e.addNotifier(n, ec.DeliveryConfig)
+	}
+	return e, nil
+}
+
+func (e *Engine) recipients(a Alert) []string {
+	for _, rule := range e.rules[a.Cluster] {
+		if rule.Name == a.Rule {
+			return rule.Recipients
+		}
+	}
+	return nil
+}
+
+func (e *Engine) addNotifier(n Notifier, cfg DeliveryConfig) {
+	e.notify = append(e.notify, newDispatcher(n, cfg, e.logger, e.notifiable))
+}
+
+// Active returns the currently firing alerts, including silenced ones.
+func (e *Engine) Active() []Alert {
+	e.mu.RLock()
+	res := make([]Alert, 0, len(e.alerts))
+	for _, t := range e.alerts {
+		if t.alert.Status == StatusFiring {
+			res = append(res, t.alert)
+		}
+	}
+	e.mu.RUnlock()
+
+	sortAlerts(res)
+	return res
+}
+
+// notifiable returns the currently firing alerts that have been notified.
+func (e *Engine) notifiable() []Alert {
+	e.mu.RLock()
+	res := make([]Alert, 0, len(e.alerts))
+	for _, t := range e.alerts {
+		if t.alert.Status == StatusFiring && t.notified != "" {
+			res = append(res, t.alert)
+		}
+	}
+	e.mu.RUnlock()
+
+	sortAlerts(res)
+	return res
+}
+
+// Silences returns the current and pending silences.
+func (e *Engine) Silences() []*Silence {
+	e.mu.Lock()
+	e.pruneSilences(time.Now())
+	res := make([]*Silence, 0, len(e.silences))
+	for _, s := range e.silences {
+		res = append(res, s)
+	}
+	e.mu.Unlock()
+
+	sortSilences(res)
+	return res
+}
+
+// Silence returns a current or pending silence by ID.
+func (e *Engine) Silence(id string) (*Silence, bool) {
+	e.mu.RLock()
+	s, ok := e.silences[id]
+	e.mu.RUnlock()
+
+	if !ok || s.isExpired(time.Now()) {
+		return nil, false
+	}
+	return s, true
+}
+
+// AddSilence validates and adds a silence. It assigns and returns a new ID.
+func (e *Engine) AddSilence(s *Silence) (string, error) {
+	if err := s.validate(); err != nil {
+		return "", err
+	}
+	s.ID = newSilenceID()
+
+	e.mu.Lock()
+	e.silences[s.ID] = s
+	e.mu.Unlock()
+
+	return s.ID, nil
+}
+
+// DeleteSilence removes a silence. Returns false if not found.
+func (e *Engine) DeleteSilence(id string) bool {
+	e.mu.Lock()
+	_, ok := e.silences[id]
+	delete(e.silences, id)
+	e.mu.Unlock()
+
+	return ok
+}
+
+// pruneSilences removes expired silences, must be called while holding the
+// lock.
+func (e *Engine) pruneSilences(now time.Time) {
+	for id, s := range e.silences {
+		if s.isExpired(now) {
+			delete(e.silences, id)
+		}
+	}
+}
+
+func (e *Engine) isSilenced(key alertKey, now time.Time) bool {
+	for _, s := range e.silences {
+		if s.IsActive(now) && s.m.match(key) {
+			return true
+		}
+	}
+	for i := range e.maintenance {
+		if mw := &e.maintenance[i]; mw.m.match(key) && mw.IsActive(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// RunLoop starts the blocking evaluation loop.
+func (e *Engine) RunLoop(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	wg := new(sync.WaitGroup)
+	defer wg.Wait()
+
+	for _, d := range e.notify {
+		wg.Add(1)
+		go func(d *dispatcher) {
+			defer wg.Done()
+			d.RunLoop(ctx)
+		}(d)
+	}
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			changed := e.Evaluate(now)
+			for _, a := range changed {
+				e.logger.Printf("%s %s alert %q for %s/%s on %q", a.Status, a.Severity, a.Rule, a.Group, a.Topic, a.Cluster)
+			}
+			if len(changed) != 0 {
+				for _, d := range e.notify {
+					d.Enqueue(changed)
+				}
+			}
+		}
+	}
+}
+
+// Evaluate evaluates all rules and returns the alerts that need to be
+// notified, i.e. alerts that changed status or severity and are not silenced.
+func (e *Engine) Evaluate(now time.Time) []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.pruneSilences(now)
+
+	var changed []Alert
+	seen := make(map[alertKey]struct{}, len(e.alerts))
+	for cluster, rules := range e.rules {
+		state := e.state.Cluster(cluster)
+		if state == nil || len(rules) == 0 {
+			continue
+		}
+
+		for _, group := range state.ConsumerGroups() {
+			topics, _ := state.ConsumerTopics(group)
+			for _, ct := range topics {
+				m := calcMetrics(state, group, ct)
+
+				for i := range rules {
+					rule := &rules[i]
+					if !rule.match(group, ct.Topic) {
+						continue
+					}
+
+					key := alertKey{Cluster: cluster, Group: group, Topic: ct.Topic, Rule: rule.Name}
+					seen[key] = struct{}{}
+
+					t, ok := e.alerts[key]
+					if !ok {
+						t = new(tracker)
+						e.alerts[key] = t
+					}
+					if a, ok := t.update(key, &rule.Rule, m, now, e.isSilenced(key, now)); ok {
+						changed = append(changed, a)
+					}
+					if t.idle() {
+						delete(e.alerts, key)
+					}
+				}
+			}
+		}
+	}
+
+	// resolve alerts of groups/topics that have disappeared
+	for key, t := range e.alerts {
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		if a, ok := t.resolve(nil, now); ok {
+			changed = append(changed, a)
+		}
+		delete(e.alerts, key)
+	}
+
+	sortAlerts(changed)
+	return changed
+}
+
+// --------------------------------------------------------------------
+
+type alertKey struct {
+	Cluster, Group, Topic, Rule string
+}
+
+type metrics struct {
+	TotalLag int64
+	MaxLag   int64
+	TimeLag  time.Duration
+}
+
+func calcMetrics(state *rumour.ClusterState, group string, ct rumour.ConsumerTopic) metrics {
+	m := metrics{TotalLag: ct.Summary.TotalLag, MaxLag: ct.Summary.MaxLag}
+	m.TimeLag, _ = state.ConsumerTimeLag(group, ct.Topic)
+	return m
+}
+
+func (a *Alert) setMetrics(m metrics) {
+	a.TotalLag = m.TotalLag
+	a.MaxLag = m.MaxLag
+	a.TimeLag = int64(m.TimeLag / time.Second)
+}
+
+type tracker struct {
+	warnSince time.Time
+	critSince time.Time
+	alert     Alert
+	notified  Severity // the last notified severity
+}
+
+func (t *tracker) idle() bool {
+	return t.alert.Status != StatusFiring && t.warnSince.IsZero() && t.critSince.IsZero()
+}
+
+func (t *tracker) update(key alertKey, rule *Rule, m metrics, now time.Time, silenced bool) (Alert, bool) {
+	t.warnSince = breachedSince(t.warnSince, rule.Warn, m, now)
+	t.critSince = breachedSince(t.critSince, rule.Critical, m, now)
+
+	var severity Severity
+	if !t.critSince.IsZero() && now.Sub(t.critSince) >= time.Duration(rule.Critical.For) {
+		severity = SeverityCritical
+	} else if !t.warnSince.IsZero() && now.Sub(t.warnSince) >= time.Duration(rule.Warn.For) {
+		severity = SeverityWarn
+	}
+
+	if severity == "" {
+		return t.resolve(&m, now)
+	}
+
+	if t.alert.Status != StatusFiring {
+		t.alert = Alert{
+			Cluster:  key.Cluster,
+			Group:    key.Group,
+			Topic:    key.Topic,
+			Rule:     key.Rule,
+			Status:   StatusFiring,
+			StartsAt: now.Unix(),
+		}
+	}
+	t.alert.Severity = severity
+	t.alert.setMetrics(m)
+	t.alert.Silenced = silenced
+
+	if silenced || t.notified == severity {
+		return t.alert, false
+	}
+	t.notified = severity
+	return t.alert, true
+}
+
+// resolve resolves a firing alert with the current metrics. The last known
+// metrics are retained if m is nil, i.e. when the group or topic has gone.
+func (t *tracker) resolve(m *metrics, now time.Time) (Alert, bool) {
+	if t.alert.Status != StatusFiring {
+		return Alert{}, false
+	}
+
+	a := t.alert
+	if m != nil {
+		a.setMetrics(*m)
+	}
+	a.Status = StatusResolved
+	a.EndsAt = now.Unix()
+	notify := t.notified != ""
+
+	t.alert = Alert{}
+	t.notified = ""
+	return a, notify
+}
+
+func breachedSince(since time.Time, th Threshold, m metrics, now time.Time) time.Time {
+	if th.IsZero() || !th.breached(m) {
+		return time.Time{}
+	}
+	if since.IsZero() {
+		return now
+	}
+	return since
+}
@@ -0,0 +1,130 @@
+package alert_test
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/bsm/rumour/internal/alert"
+	"github.com/bsm/rumour/internal/rumour"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Engine", func() {
+	var subject *alert.Engine
+	var state *rumour.State
+	var cfg *alert.Config
+
+	t0 := time.Unix(1515151515, 0)
+
+	BeforeEach(func() {
+		state = rumour.NewState([]string{"default"})
+		cs := state.Cluster("default")
+		cs.UpdateTopic("orders", []int64{100, 100, 100})
+		cs.UpdateTopic("events", []int64{100, 100})
+		cs.UpdateConsumerOffsets("orders-worker", "orders", 1515151515, []int64{100, 40, 90})
+		cs.UpdateConsumerOffsets("events-worker", "events", 1515151515, []int64{100, 100})
+
+		cfg = new(alert.Config)
+		Expect(json.Unmarshal([]byte(`{
+			"clusters": {
+				"default": {
+					"rules": [
+						{
+							"name": "lagging",
+							"groups": ["orders-*", "/^events-.+$/"],
+							"warn": {"total_lag": 50},
+							"critical": {"max_lag": 50, "for": "60s"}
+						}
+					]
+				}
+			}
+		}`), cfg)).To(Succeed())
+
+		var err error
+		subject, err = alert.NewEngine(state, cfg)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should validate config", func() {
+		_, err := alert.NewEngine(state, &alert.Config{
+			Clusters: map[string]alert.ClusterConfig{"missing": {}},
+		})
+		Expect(err).To(MatchError(`alert: unknown cluster "missing"`))
+
+		_, err = alert.NewEngine(state, &alert.Config{
+			Clusters: map[string]alert.ClusterConfig{"default": {Rules: []alert.Rule{{Name: "x"}}}},
+		})
+		Expect(err).To(MatchError(`alert: cluster "default": rule "x" has no thresholds`))
+
+		_, err = alert.NewEngine(state, &alert.Config{
+			Clusters: map[string]alert.ClusterConfig{"default": {Rules: []alert.Rule{
+				{Name: "x", Groups: []string{"[x"}, Warn: alert.Threshold{TotalLag: 1}},
+			}}},
+		})
+		Expect(err).To(MatchError(`alert: cluster "default": rule "x": invalid pattern "[x": syntax error in pattern`))
+	})
+
+	It("should fire and resolve alerts", func() {
+		Expect(subject.Active()).To(BeEmpty())
+
+		Expect(subject.Evaluate(t0)).To(Equal([]alert.Alert{
+			{Cluster: "default", Group: "orders-worker", Topic: "orders", Rule: "lagging", Severity: alert.SeverityWarn, Status: alert.StatusFiring, TotalLag: 70, MaxLag: 60, StartsAt: 1515151515},
+		}))
+		Expect(subject.Active()).To(HaveLen(1))
+
+		// not changed
+		Expect(subject.Evaluate(t0.Add(30 * time.Second))).To(BeEmpty())
+
+		// escalate to critical
+		Expect(subject.Evaluate(t0.Add(60 * time.Second))).To(Equal([]alert.Alert{
+			{Cluster: "default", Group: "orders-worker", Topic: "orders", Rule: "lagging", Severity: alert.SeverityCritical, Status: alert.StatusFiring, TotalLag: 70, MaxLag: 60, StartsAt: 1515151515},
+		}))
+		Expect(subject.Active()).To(ConsistOf(
+			HaveField("Severity", alert.SeverityCritical),
+		))
+
+		// resolve
+		state.Cluster("default").UpdateConsumerOffsets("orders-worker", "orders", 1515151600, []int64{100, 100, 100})
+		Expect(subject.Evaluate(t0.Add(90 * time.Second))).To(Equal([]alert.Alert{
+			{Cluster: "default", Group: "orders-worker", Topic: "orders", Rule: "lagging", Severity: alert.SeverityCritical, Status: alert.StatusResolved, StartsAt: 1515151515, EndsAt: 1515151605},
+		}))
+		Expect(subject.Active()).To(BeEmpty())
+	})
+
+	It("should resolve alerts of expired groups", func() {
+		Expect(subject.Evaluate(t0)).To(HaveLen(1))
+
+		state.Cluster("default").ExpireConsumerGroups(1515151600)
+		Expect(subject.Evaluate(t0.Add(time.Minute))).To(ConsistOf(
+			HaveField("Status", alert.StatusResolved),
+		))
+		Expect(subject.Active()).To(BeEmpty())
+	})
+
+	It("should reset pending conditions", func() {
+		cs := state.Cluster("default")
+		cs.UpdateConsumerOffsets("orders-worker", "orders", 1515151600, []int64{100, 60, 100})
+		Expect(subject.Evaluate(t0)).To(BeEmpty())
+
+		cs.UpdateConsumerOffsets("orders-worker", "orders", 1515151601, []int64{100, 40, 100})
+		Expect(subject.Evaluate(t0.Add(30 * time.Second))).To(ConsistOf(
+			HaveField("Severity", alert.SeverityWarn),
+		))
+
+		cs.UpdateConsumerOffsets("orders-worker", "orders", 1515151602, []int64{100, 100, 100})
+		Expect(subject.Evaluate(t0.Add(60 * time.Second))).To(ConsistOf(
+			HaveField("Status", alert.StatusResolved),
+		))
+
+		cs.UpdateConsumerOffsets("orders-worker", "orders", 1515151603, []int64{100, 40, 100})
+		Expect(subject.Evaluate(t0.Add(90 * time.Second))).To(ConsistOf(
+			HaveField("Severity", alert.SeverityWarn),
+		))
+		Expect(subject.Evaluate(t0.Add(120 * time.Second))).To(BeEmpty())
+		Expect(subject.Evaluate(t0.Add(150 * time.Second))).To(ConsistOf(
+			HaveField("Severity", alert.SeverityCritical),
+		))
+	})
+})
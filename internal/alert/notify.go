@@ -0,0 +1,301 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Notifier delivers alert notifications.
+type Notifier interface {
+	// Name returns the notifier name, used for logging.
+	Name() string
+	// Notify sends a batch of alerts that changed status together.
+	Notify(ctx context.Context, alerts []Alert) error
+}
+
+// DeliveryConfig contains delivery options, shared by all notifiers.
+type DeliveryConfig struct {
+	// Retries is the number of retries on failure, 0 disables retries.
+	// Default: 3.
+	Retries *int `json:"retries"`
+	// Backoff is the delay before the first retry, it doubles with every
+	// subsequent attempt. Default: 1s.
+	Backoff Duration `json:"backoff"`
+	// Timeout limits each delivery attempt. Default: 10s.
+	Timeout Duration `json:"timeout"`
+	// DedupeInterval limits the notifications for a single alert to one
+	// per interval. The latest status is delivered once the interval has
+	// passed. Default: 0 (disabled).
+	DedupeInterval Duration `json:"dedupe_interval"`
+}
+
+func (c *DeliveryConfig) norm() {
+	if c.Retries == nil {
+		retries := 3
+		c.Retries = &retries
+	}
+	if c.Backoff <= 0 {
+		c.Backoff = Duration(time.Second)
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = Duration(10 * time.Second)
+	}
+}
+
+// --------------------------------------------------------------------
+
+type dispatcher struct {
+	Notifier
+	DeliveryConfig
+
+	logger  *log.Logger
+	resend  time.Duration
+	active  func() []Alert
+	queue   chan []Alert
+	sent    map[alertKey]sentAlert
+	pending map[alertKey]Alert
+	failed  map[alertKey]failedAlert
+}
+
+type sentAlert struct {
+	Status   Status
+	Severity Severity
+	SentAt   time.Time
+}
+
+// failedAlert is an alert that awaits a retry.
+type failedAlert struct {
+	Alert
+	Attempts int
+	RetryAt  time.Time
+}
+
+// resender is implemented by notifiers which expect active alerts to be
+// resent periodically.
+type resender interface {
+	ResendInterval() time.Duration
+}
+
+func newDispatcher(n Notifier, cfg DeliveryConfig, logger *log.Logger, active func() []Alert) *dispatcher {
+	cfg.norm()
+
+	var resend time.Duration
+	if r, ok := n.(resender); ok {
+		resend = r.ResendInterval()
+	}
+
+	return &dispatcher{
+		Notifier:       n,
+		DeliveryConfig: cfg,
+		logger:         logger,
+		resend:         resend,
+		active:         active,
+		queue:          make(chan []Alert, 100),
+		sent:           make(map[alertKey]sentAlert),
+		pending:        make(map[alertKey]Alert),
+		failed:         make(map[alertKey]failedAlert),
+	}
+}
+
+// Enqueue schedules alerts for delivery without blocking.
+func (d *dispatcher) Enqueue(alerts []Alert) {
+	select {
+	case d.queue <- alerts:
+	default:
+		d.logger.Printf("dropped %d notification(s) for %s: queue full", len(alerts), d.Name())
+	}
+}
+
+// RunLoop starts the blocking delivery loop.
+func (d *dispatcher) RunLoop(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	var resend <-chan time.Time
+	if d.resend > 0 {
+		rt := time.NewTicker(d.resend)
+		defer rt.Stop()
+		resend = rt.C
+	}
+
+	for {
+		var retry <-chan time.Time
+		if at, ok := d.nextRetry(); ok {
+			retry = time.After(time.Until(at))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case alerts := <-d.queue:
+			d.deliver(ctx, d.dedupe(alerts, time.Now()))
+		case now := <-ticker.C:
+			d.deliver(ctx, d.flush(now))
+		case <-resend:
+			d.deliver(ctx, d.active())
+		case now := <-retry:
+			d.deliver(ctx, d.due(now))
+		}
+	}
+}
+
+// dedupe filters alerts that were notified within the dedupe interval
+// and marks them as pending.
+func (d *dispatcher) dedupe(alerts []Alert, now time.Time) []Alert {
+	if d.DedupeInterval <= 0 {
+		return alerts
+	}
+
+	res := alerts[:0:0]
+	for _, a := range alerts {
+		key := keyOf(a)
+		if last, ok := d.sent[key]; ok && now.Sub(last.SentAt) < time.Duration(d.DedupeInterval) {
+			d.pending[key] = a
+			continue
+		}
+
+		delete(d.pending, key)
+		res = append(res, a)
+	}
+	return res
+}
+
+// flush returns pending alerts that are due for delivery.
+func (d *dispatcher) flush(now time.Time) []Alert {
+	var res []Alert
+	for key, a := range d.pending {
+		last := d.sent[key]
+		if now.Sub(last.SentAt) < time.Duration(d.DedupeInterval) {
+			continue
+		}
+
+		delete(d.pending, key)
+		if last.Status == a.Status && last.Severity == a.Severity {
+			continue
+		}
+		res = append(res, a)
+	}
+
+	for key, last := range d.sent {
+		if _, ok := d.pending[key]; !ok && now.Sub(last.SentAt) >= time.Duration(d.DedupeInterval) {
+			delete(d.sent, key)
+		}
+	}
+
+	sortAlerts(res)
+	return res
+}
+
+// deliver attempts to deliver alerts once. Alerts which fail are scheduled
+// for a retry with exponential backoff, so that retries do not block the
+// delivery of subsequent notifications. Delivered alerts are recorded for
+// deduplication.
+func (d *dispatcher) deliver(ctx context.Context, alerts []Alert) {
+	if len(alerts) == 0 {
+		return
+	}
+
+	err := d.attempt(ctx, alerts)
+	now := time.Now()
+
+	failed := alerts
+	var perr *partialError
+	if err == nil {
+		failed = nil
+	} else if errors.As(err, &perr) {
+		failed = perr.Failed
+	}
+
+	retry := make(map[alertKey]struct{}, len(failed))
+	for _, a := range failed {
+		retry[keyOf(a)] = struct{}{}
+	}
+
+	var dropped int
+	for _, a := range alerts {
+		key := keyOf(a)
+		attempts := 1
+		if f, ok := d.failed[key]; ok && f.Alert == a {
+			attempts += f.Attempts
+		}
+		delete(d.failed, key)
+
+		if _, ok := retry[key]; !ok {
+			if d.DedupeInterval > 0 {
+				d.sent[key] = sentAlert{Status: a.Status, Severity: a.Severity, SentAt: now}
+			}
+			continue
+		}
+
+		if attempts > *d.Retries {
+			dropped++
+			continue
+		}
+		backoff := time.Duration(d.Backoff) << (attempts - 1)
+		d.failed[key] = failedAlert{Alert: a, Attempts: attempts, RetryAt: now.Add(backoff)}
+	}
+
+	if dropped != 0 {
+		d.logger.Printf("error notifying %s, dropped %d alert(s): %v", d.Name(), dropped, err)
+	}
+}
+
+// nextRetry returns the time of the next retry.
+func (d *dispatcher) nextRetry() (time.Time, bool) {
+	var next time.Time
+	for _, f := range d.failed {
+		if next.IsZero() || f.RetryAt.Before(next) {
+			next = f.RetryAt
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// due returns failed alerts that are due for a retry.
+func (d *dispatcher) due(now time.Time) []Alert {
+	var res []Alert
+	for _, f := range d.failed {
+		if !f.RetryAt.After(now) {
+			res = append(res, f.Alert)
+		}
+	}
+	sortAlerts(res)
+	return res
+}
+
+func (d *dispatcher) attempt(ctx context.Context, alerts []Alert) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(d.Timeout))
+	defer cancel()
+
+	return d.Notify(ctx, alerts)
+}
+
+func keyOf(a Alert) alertKey {
+	return alertKey{Cluster: a.Cluster, Group: a.Group, Topic: a.Topic, Rule: a.Rule}
+}
+
+// partialError is returned by notifiers which deliver alerts individually,
+// when some of the alerts could not be delivered.
+type partialError struct {
+	Failed []Alert
+	Err    error
+}
+
+func (e *partialError) Error() string {
+	return fmt.Sprintf("%d alert(s) failed: %v", len(e.Failed), e.Err)
+}
+
+func (e *partialError) Unwrap() error { return e.Err }
+
+// notifyError is returned by notifiers on unexpected responses.
+type notifyError struct {
+	Status int
+	Body   string
+}
+
+func (e *notifyError) Error() string {
+	return fmt.Sprintf("unexpected response status %d: %s", e.Status, e.Body)
+}
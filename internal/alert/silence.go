@@ -0,0 +1,189 @@
+package alert
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bsm/rumour/internal/pattern"
+)
+
+// Silence mutes alerts of matching clusters, groups and topics for a
+// period of time. Silenced alerts are still active, but not notified.
+type Silence struct {
+	ID        string `json:"id"`
+	Cluster   string `json:"cluster,omitempty"`
+	Group     string `json:"group,omitempty"`
+	Topic     string `json:"topic,omitempty"`
+	StartsAt  int64  `json:"starts_at"`
+	EndsAt    int64  `json:"ends_at"`
+	Comment   string `json:"comment,omitempty"`
+	CreatedBy string `json:"created_by,omitempty"`
+
+	m matcher
+}
+
+// IsActive returns true if the silence is active at the given time.
+func (s *Silence) IsActive(now time.Time) bool {
+	ts := now.Unix()
+	return s.StartsAt <= ts && ts < s.EndsAt
+}
+
+func (s *Silence) isExpired(now time.Time) bool {
+	return s.EndsAt <= now.Unix()
+}
+
+func (s *Silence) validate() (err error) {
+	if s.EndsAt <= s.StartsAt {
+		return errors.New("silence must end after it starts")
+	}
+	s.m, err = newMatcher(s.Cluster, s.Group, s.Topic)
+	return
+}
+
+type silences []*Silence
+
+func (p silences) Len() int      { return len(p) }
+func (p silences) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+func (p silences) Less(i, j int) bool {
+	if p[i].StartsAt != p[j].StartsAt {
+		return p[i].StartsAt < p[j].StartsAt
+	}
+	return p[i].ID < p[j].ID
+}
+
+// --------------------------------------------------------------------
+
+// MaintenanceWindow is a recurring silence.
+type MaintenanceWindow struct {
+	// Name of the window.
+	Name string `json:"name"`
+	// Cluster, Group and Topic patterns. Match everything when empty.
+	Cluster string `json:"cluster"`
+	Group   string `json:"group"`
+	Topic   string `json:"topic"`
+	// Days contains the weekdays, e.g. ["sat", "sun"]. Default: every day.
+	Days []string `json:"days"`
+	// Start is the local start time of the window, e.g. "02:30".
+	Start string `json:"start"`
+	// Duration is the length of the window.
+	Duration Duration `json:"duration"`
+	// Timezone is the IANA timezone, e.g. "Europe/London". Default: UTC.
+	Timezone string `json:"timezone"`
+
+	m      matcher
+	days   [7]bool
+	offset time.Duration
+	loc    *time.Location
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+func (w *MaintenanceWindow) compile() (err error) {
+	if w.m, err = newMatcher(w.Cluster, w.Group, w.Topic); err != nil {
+		return err
+	}
+
+	if len(w.Days) == 0 {
+		for i := range w.days {
+			w.days[i] = true
+		}
+	}
+	for _, day := range w.Days {
+		wd, ok := weekdays[strings.ToLower(day)]
+		if !ok {
+			return fmt.Errorf("invalid day %q", day)
+		}
+		w.days[wd] = true
+	}
+
+	parts := strings.SplitN(w.Start, ":", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid start %q", w.Start)
+	}
+	h, herr := strconv.Atoi(parts[0])
+	m, merr := strconv.Atoi(parts[1])
+	if herr != nil || merr != nil || h < 0 || h > 23 || m < 0 || m > 59 {
+		return fmt.Errorf("invalid start %q", w.Start)
+	}
+	w.offset = time.Duration(h)*time.Hour + time.Duration(m)*time.Minute
+
+	if w.Duration <= 0 {
+		return errors.New("duration is required")
+	}
+
+	w.loc = time.UTC
+	if w.Timezone != "" {
+		if w.loc, err = time.LoadLocation(w.Timezone); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsActive returns true if the window is active at the given time.
+func (w *MaintenanceWindow) IsActive(now time.Time) bool {
+	local := now.In(w.loc)
+	days := int(time.Duration(w.Duration)/(24*time.Hour)) + 1
+	for back := 0; back <= days; back++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()-back, 0, 0, 0, 0, w.loc)
+		if !w.days[day.Weekday()] {
+			continue
+		}
+
+		start := day.Add(w.offset)
+		if !now.Before(start) && now.Before(start.Add(time.Duration(w.Duration))) {
+			return true
+		}
+	}
+	return false
+}
+
+// --------------------------------------------------------------------
+
+type matcher struct {
+	cluster, group, topic pattern.List
+}
+
+func newMatcher(cluster, group, topic string) (m matcher, err error) {
+	if m.cluster, err = compileOptionalPattern(cluster); err != nil {
+		return
+	}
+	if m.group, err = compileOptionalPattern(group); err != nil {
+		return
+	}
+	m.topic, err = compileOptionalPattern(topic)
+	return
+}
+
+func (m matcher) match(key alertKey) bool {
+	return m.cluster.Match(key.Cluster) && m.group.Match(key.Group) && m.topic.Match(key.Topic)
+}
+
+func compileOptionalPattern(s string) (pattern.List, error) {
+	if s == "" {
+		return nil, nil
+	}
+	return pattern.Compile([]string{s})
+}
+
+func newSilenceID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func sortSilences(p []*Silence) { sort.Sort(silences(p)) }
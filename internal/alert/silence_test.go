@@ -0,0 +1,148 @@
+package alert_test
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/bsm/rumour/internal/alert"
+	"github.com/bsm/rumour/internal/rumour"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Silence", func() {
+	var subject *alert.Engine
+	var state *rumour.State
+
+	t0 := time.Unix(1515151515, 0)
+
+	BeforeEach(func() {
+		state = rumour.NewState([]string{"default"})
+		state.Cluster("default").UpdateTopic("orders", []int64{100, 100, 100})
+		state.Cluster("default").UpdateConsumerOffsets("orders-worker", "orders", 1515151515, []int64{100, 40, 90})
+
+		var err error
+		subject, err = alert.NewEngine(state, &alert.Config{
+			Clusters: map[string]alert.ClusterConfig{"default": {Rules: []alert.Rule{
+				{Name: "lagging", Warn: alert.Threshold{TotalLag: 50}},
+			}}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should validate", func() {
+		_, err := subject.AddSilence(&alert.Silence{StartsAt: 1515151515, EndsAt: 1515151515})
+		Expect(err).To(MatchError("silence must end after it starts"))
+
+		_, err = subject.AddSilence(&alert.Silence{Group: "[x", StartsAt: 1515151515, EndsAt: 1515151516})
+		Expect(err).To(MatchError(`invalid pattern "[x": syntax error in pattern`))
+	})
+
+	It("should manage silences", func() {
+		now := time.Now().Unix()
+		id, err := subject.AddSilence(&alert.Silence{Group: "orders-*", StartsAt: now, EndsAt: now + 3600})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(id).To(HaveLen(16))
+
+		expired, err := subject.AddSilence(&alert.Silence{Group: "expired", StartsAt: now - 3600, EndsAt: now - 1})
+		Expect(err).NotTo(HaveOccurred())
+		_, ok := subject.Silence(expired)
+		Expect(ok).To(BeFalse())
+
+		Expect(subject.Silences()).To(HaveLen(1))
+		Expect(subject.Silences()[0].ID).To(Equal(id))
+		s, ok := subject.Silence(id)
+		Expect(ok).To(BeTrue())
+		Expect(s.Group).To(Equal("orders-*"))
+
+		Expect(subject.DeleteSilence(id)).To(BeTrue())
+		Expect(subject.DeleteSilence(id)).To(BeFalse())
+		Expect(subject.Silences()).To(BeEmpty())
+	})
+
+	It("should silence alerts", func() {
+		id, err := subject.AddSilence(&alert.Silence{Cluster: "default", Topic: "/^ord/", StartsAt: t0.Unix(), EndsAt: t0.Unix() + 60})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(subject.Evaluate(t0)).To(BeEmpty())
+		Expect(subject.Active()).To(ConsistOf(
+			HaveField("Silenced", true),
+		))
+
+		// silence expired
+		Expect(subject.Evaluate(t0.Add(time.Minute))).To(ConsistOf(
+			HaveField("Silenced", false),
+		))
+		Expect(subject.Active()).To(ConsistOf(
+			HaveField("Silenced", false),
+		))
+		Expect(subject.DeleteSilence(id)).To(BeFalse()) // pruned on evaluation
+
+		// silenced while firing, resolved
+		_, err = subject.AddSilence(&alert.Silence{StartsAt: t0.Unix(), EndsAt: t0.Unix() + 600})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subject.Evaluate(t0.Add(2 * time.Minute))).To(BeEmpty())
+
+		state.Cluster("default").UpdateConsumerOffsets("orders-worker", "orders", 1515151600, []int64{100, 100, 100})
+		Expect(subject.Evaluate(t0.Add(3 * time.Minute))).To(ConsistOf(
+			HaveField("Status", alert.StatusResolved),
+		))
+	})
+
+	It("should prune expired silences on evaluation", func() {
+		id, err := subject.AddSilence(&alert.Silence{StartsAt: t0.Unix(), EndsAt: t0.Unix() + 60})
+		Expect(err).NotTo(HaveOccurred())
+		pending, err := subject.AddSilence(&alert.Silence{StartsAt: t0.Unix() + 60, EndsAt: t0.Unix() + 120})
+		Expect(err).NotTo(HaveOccurred())
+
+		subject.Evaluate(t0.Add(time.Minute))
+		Expect(subject.DeleteSilence(id)).To(BeFalse())
+		Expect(subject.DeleteSilence(pending)).To(BeTrue())
+	})
+
+	It("should not notify resolved alerts that were never notified", func() {
+		_, err := subject.AddSilence(&alert.Silence{StartsAt: t0.Unix(), EndsAt: t0.Unix() + 600})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subject.Evaluate(t0)).To(BeEmpty())
+
+		state.Cluster("default").UpdateConsumerOffsets("orders-worker", "orders", 1515151600, []int64{100, 100, 100})
+		Expect(subject.Evaluate(t0.Add(time.Minute))).To(BeEmpty())
+		Expect(subject.Active()).To(BeEmpty())
+	})
+})
+
+var _ = Describe("MaintenanceWindow", func() {
+	parse := func(s string) *alert.Config {
+		cfg := new(alert.Config)
+		Expect(json.Unmarshal([]byte(s), cfg)).To(Succeed())
+		return cfg
+	}
+
+	It("should validate", func() {
+		state := rumour.NewState(nil)
+		_, err := alert.NewEngine(state, parse(`{"maintenance": [{"name": "x", "start": "25:00", "duration": "1h"}]}`))
+		Expect(err).To(MatchError(`alert: maintenance window "x": invalid start "25:00"`))
+		_, err = alert.NewEngine(state, parse(`{"maintenance": [{"name": "x", "days": ["xyz"], "start": "01:00", "duration": "1h"}]}`))
+		Expect(err).To(MatchError(`alert: maintenance window "x": invalid day "xyz"`))
+		_, err = alert.NewEngine(state, parse(`{"maintenance": [{"name": "x", "start": "01:00"}]}`))
+		Expect(err).To(MatchError(`alert: maintenance window "x": duration is required`))
+	})
+
+	It("should silence alerts within windows", func() {
+		state := rumour.NewState(nil)
+		state.Cluster("default").UpdateTopic("orders", []int64{100})
+		state.Cluster("default").UpdateConsumerOffsets("orders-worker", "orders", 1515151515, []int64{10})
+
+		// 2018-01-05 is a Friday
+		subject, err := alert.NewEngine(state, parse(`{
+			"clusters": {"default": {"rules": [{"name": "lagging", "warn": {"total_lag": 50}}]}},
+			"maintenance": [{"name": "nightly", "group": "orders-*", "days": ["fri"], "start": "23:00", "duration": "2h", "timezone": "Europe/Berlin"}]
+		}`))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(subject.Evaluate(time.Date(2018, 1, 5, 22, 30, 0, 0, time.UTC))).To(BeEmpty()) // Sat 00:30 CET
+		Expect(subject.Active()).To(ConsistOf(HaveField("Silenced", true)))
+		Expect(subject.Evaluate(time.Date(2018, 1, 6, 0, 0, 0, 0, time.UTC))).To(ConsistOf(HaveField("Silenced", false)))
+	})
+})
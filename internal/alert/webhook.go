@@ -0,0 +1,155 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const defaultWebhookTemplate = `{{ json . }}`
+
+// WebhookConfig configures a generic HTTP webhook notifier.
+type WebhookConfig struct {
+	DeliveryConfig
+
+	// Name of the webhook. Default: the URL host.
+	Name string `json:"name"`
+	// URL is the target URL.
+	URL string `json:"url"`
+	// Method is the HTTP method. Default: POST.
+	Method string `json:"method"`
+	// Headers contains custom HTTP request headers.
+	Headers map[string]string `json:"headers"`
+	// Template is a text/template for the request body, rendered once for each
+	// alert. Default: the alert encoded as JSON.
+	Template string `json:"template"`
+}
+
+// Webhook delivers alerts to HTTP endpoints.
+type Webhook struct {
+	name    string
+	url     string
+	method  string
+	headers map[string]string
+	tmpl    *template.Template
+	client  *http.Client
+}
+
+// NewWebhook inits a webhook notifier.
+func NewWebhook(cfg *WebhookConfig) (*Webhook, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, err
+	} else if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.New("webhook URL must be absolute")
+	}
+
+	name := cfg.Name
+	if name == "" {
+		name = u.Host
+	}
+
+	method := strings.ToUpper(cfg.Method)
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	text := cfg.Template
+	if text == "" {
+		text = defaultWebhookTemplate
+	}
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Webhook{
+		name:    name,
+		url:     u.String(),
+		method:  method,
+		headers: cfg.Headers,
+		tmpl:    tmpl,
+		client:  new(http.Client),
+	}, nil
+}
+
+// Name implements Notifier.
+func (w *Webhook) Name() string { return "webhook " + w.name }
+
+// Notify implements Notifier. All alerts are attempted, even if some of them
+// fail.
+func (w *Webhook) Notify(ctx context.Context, alerts []Alert) error {
+	var failed []Alert
+	var first error
+	for _, a := range alerts {
+		if err := w.send(ctx, a); err != nil {
+			if first == nil {
+				first = err
+			}
+			failed = append(failed, a)
+		}
+	}
+
+	switch len(failed) {
+	case 0:
+		return nil
+	case len(alerts):
+		return first
+	}
+	return &partialError{Failed: failed, Err: first}
+}
+
+func (w *Webhook) send(ctx context.Context, a Alert) error {
+	body := new(bytes.Buffer)
+	if err := w.tmpl.Execute(body, a); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, w.method, w.url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.headers {
+		req.Header.Set(k, v)
+	}
+
+	return doRequest(w.client, req)
+}
+
+func doRequest(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &notifyError{Status: resp.StatusCode, Body: strings.TrimSpace(string(msg))}
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// --------------------------------------------------------------------
+
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"upper": func(v interface{}) string { return strings.ToUpper(fmt.Sprint(v)) },
+	"lower": func(v interface{}) string { return strings.ToLower(fmt.Sprint(v)) },
+	"time": func(unix int64) string {
+		return time.Unix(unix, 0).UTC().Format(time.RFC3339)
+	},
+}
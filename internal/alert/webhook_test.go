@@ -0,0 +1,244 @@
+package alert_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/bsm/rumour/internal/alert"
+	"github.com/bsm/rumour/internal/rumour"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Webhook", func() {
+	var server *httptest.Server
+	var received []string
+	var headers []http.Header
+	var failures int
+	var failOn string
+	var mu sync.Mutex
+
+	firing := alert.Alert{
+		Cluster:  "default",
+		Group:    "orders-worker",
+		Topic:    "orders",
+		Rule:     "lagging",
+		Severity: alert.SeverityWarn,
+		Status:   alert.StatusFiring,
+		TotalLag: 70,
+		MaxLag:   60,
+		StartsAt: 1515151515,
+	}
+
+	receivedBodies := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), received...)
+	}
+
+	BeforeEach(func() {
+		received, headers, failures, failOn = nil, nil, 0, ""
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			body, _ := io.ReadAll(r.Body)
+			if failures > 0 && (failOn == "" || failOn == string(body)) {
+				failures--
+				http.Error(w, "try again", http.StatusServiceUnavailable)
+				return
+			}
+
+			received = append(received, string(body))
+			headers = append(headers, r.Header)
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should validate config", func() {
+		_, err := alert.NewWebhook(&alert.WebhookConfig{URL: "/relative"})
+		Expect(err).To(MatchError("webhook URL must be absolute"))
+
+		_, err = alert.NewWebhook(&alert.WebhookConfig{URL: server.URL, Template: "{{ .Bad"})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should notify", func() {
+		subject, err := alert.NewWebhook(&alert.WebhookConfig{URL: server.URL})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subject.Notify(context.Background(), []alert.Alert{firing})).To(Succeed())
+		Expect(received).To(ConsistOf(MatchJSON(`{
+			"cluster": "default",
+			"group": "orders-worker",
+			"topic": "orders",
+			"rule": "lagging",
+			"severity": "warn",
+			"status": "firing",
+			"total_lag": 70,
+			"max_lag": 60,
+			"time_lag": 0,
+			"starts_at": 1515151515,
+			"silenced": false
+		}`)))
+	})
+
+	It("should support templates and headers", func() {
+		subject, err := alert.NewWebhook(&alert.WebhookConfig{
+			URL:      server.URL,
+			Headers:  map[string]string{"Authorization": "Bearer secret"},
+			Template: `{"text": {{ printf "[%s] %s is behind on %s by %d" (upper .Severity) .Group .Topic .TotalLag | json }}, "since": {{ json (time .StartsAt) }}}`,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subject.Notify(context.Background(), []alert.Alert{firing})).To(Succeed())
+		Expect(received).To(ConsistOf(MatchJSON(`{"text": "[WARN] orders-worker is behind on orders by 70", "since": "2018-01-05T11:25:15Z"}`)))
+		Expect(headers[0].Get("Authorization")).To(Equal("Bearer secret"))
+	})
+
+	It("should fail on bad responses", func() {
+		failures = 1
+
+		subject, err := alert.NewWebhook(&alert.WebhookConfig{URL: server.URL})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subject.Notify(context.Background(), []alert.Alert{firing})).To(MatchError("unexpected response status 503: try again"))
+	})
+
+	It("should be notified by the engine and retry", func() {
+		failures = 2
+
+		state := rumour.NewState(nil)
+		state.Cluster("default").UpdateTopic("orders", []int64{100})
+		state.Cluster("default").UpdateConsumerOffsets("orders-worker", "orders", time.Now().Unix(), []int64{10})
+
+		engine, err := alert.NewEngine(state, &alert.Config{
+			Interval: alert.Duration(10 * time.Millisecond),
+			Clusters: map[string]alert.ClusterConfig{"default": {Rules: []alert.Rule{
+				{Name: "lagging", Warn: alert.Threshold{TotalLag: 50}},
+			}}},
+			Webhooks: []alert.WebhookConfig{{
+				URL:            server.URL,
+				Template:       `{{ .Status }}`,
+				DeliveryConfig: alert.DeliveryConfig{Backoff: alert.Duration(time.Millisecond)},
+			}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go engine.RunLoop(ctx)
+
+		Eventually(receivedBodies).Should(Equal([]string{"firing"}))
+		Consistently(receivedBodies, 50*time.Millisecond).Should(HaveLen(1))
+	})
+
+	Describe("delivery", func() {
+		var state *rumour.State
+		var engine *alert.Engine
+		var cancel context.CancelFunc
+
+		setLag := func(group string, lag int64) {
+			state.Cluster("default").UpdateConsumerOffsets(group, "orders", time.Now().Unix(), []int64{100 - lag})
+		}
+
+		run := func(delivery alert.DeliveryConfig) {
+			if delivery.Backoff == 0 {
+				delivery.Backoff = alert.Duration(time.Millisecond)
+			}
+
+			var err error
+			engine, err = alert.NewEngine(state, &alert.Config{
+				Interval: alert.Duration(10 * time.Millisecond),
+				Clusters: map[string]alert.ClusterConfig{"default": {Rules: []alert.Rule{
+					{Name: "lagging", Warn: alert.Threshold{TotalLag: 50}},
+				}}},
+				Webhooks: []alert.WebhookConfig{{
+					URL:            server.URL,
+					Template:       `{{ .Group }} {{ .Status }}`,
+					DeliveryConfig: delivery,
+				}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			go engine.RunLoop(ctx)
+		}
+
+		BeforeEach(func() {
+			state = rumour.NewState(nil)
+			state.Cluster("default").UpdateTopic("orders", []int64{100})
+		})
+
+		AfterEach(func() {
+			cancel()
+		})
+
+		It("should only retry failed alerts", func() {
+			failures, failOn = 1, "b-worker firing"
+			setLag("a-worker", 90)
+			setLag("b-worker", 90)
+			run(alert.DeliveryConfig{})
+
+			Eventually(receivedBodies).Should(Equal([]string{"a-worker firing", "b-worker firing"}))
+			Consistently(receivedBodies, 50*time.Millisecond).Should(HaveLen(2))
+		})
+
+		It("should allow to disable retries", func() {
+			failures = 1
+			setLag("a-worker", 90)
+			run(alert.DeliveryConfig{Retries: new(int)})
+
+			Consistently(receivedBodies, 100*time.Millisecond).Should(BeEmpty())
+		})
+
+		It("should not block deliveries while retrying", func() {
+			failures, failOn = 1, "a-worker firing"
+			setLag("a-worker", 90)
+			run(alert.DeliveryConfig{Backoff: alert.Duration(200 * time.Millisecond)})
+			Consistently(receivedBodies, 50*time.Millisecond).Should(BeEmpty())
+
+			setLag("b-worker", 90)
+			Eventually(receivedBodies).Should(Equal([]string{"b-worker firing", "a-worker firing"}))
+		})
+
+		It("should not dedupe undelivered alerts", func() {
+			failures = 1
+			setLag("a-worker", 90)
+			run(alert.DeliveryConfig{Retries: new(int), DedupeInterval: alert.Duration(time.Second)})
+			Consistently(receivedBodies, 50*time.Millisecond).Should(BeEmpty())
+
+			setLag("a-worker", 10)
+			Eventually(receivedBodies, 500*time.Millisecond).Should(Equal([]string{"a-worker resolved"}))
+		})
+
+		It("should deliver the latest status after the dedupe interval", func() {
+			setLag("a-worker", 90)
+			run(alert.DeliveryConfig{DedupeInterval: alert.Duration(300 * time.Millisecond)})
+			Eventually(receivedBodies).Should(Equal([]string{"a-worker firing"}))
+
+			setLag("a-worker", 10)
+			Eventually(engine.Active).Should(BeEmpty())
+			Consistently(receivedBodies, 100*time.Millisecond).Should(HaveLen(1))
+			Eventually(receivedBodies, 3*time.Second).Should(Equal([]string{"a-worker firing", "a-worker resolved"}))
+		})
+
+		It("should drop changes which revert within the dedupe interval", func() {
+			setLag("a-worker", 90)
+			run(alert.DeliveryConfig{DedupeInterval: alert.Duration(300 * time.Millisecond)})
+			Eventually(receivedBodies).Should(Equal([]string{"a-worker firing"}))
+
+			setLag("a-worker", 10)
+			Eventually(engine.Active).Should(BeEmpty())
+			setLag("a-worker", 90)
+			Eventually(engine.Active).Should(HaveLen(1))
+			Consistently(receivedBodies, 1500*time.Millisecond).Should(HaveLen(1))
+		})
+	})
+})
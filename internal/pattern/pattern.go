@@ -0,0 +1,64 @@
+// Package pattern matches names against glob and regular expression
+// patterns.
+package pattern
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Pattern is a glob, unless wrapped in slashes, in which case it is a
+// regular expression, e.g. "/^orders-\d+$/".
+type Pattern struct {
+	glob string
+	rx   *regexp.Regexp
+}
+
+// Match returns true if the pattern matches s.
+func (p Pattern) Match(s string) bool {
+	if p.rx != nil {
+		return p.rx.MatchString(s)
+	}
+	ok, _ := path.Match(p.glob, s)
+	return ok
+}
+
+// List matches names against any of its patterns.
+type List []Pattern
+
+// Compile compiles a list of patterns.
+func Compile(ss []string) (List, error) {
+	res := make(List, 0, len(ss))
+	for _, s := range ss {
+		if n := len(s); n > 1 && strings.HasPrefix(s, "/") && strings.HasSuffix(s, "/") {
+			rx, err := regexp.Compile(s[1 : n-1])
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, Pattern{rx: rx})
+			continue
+		}
+
+		if _, err := path.Match(s, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", s, err)
+		}
+		res = append(res, Pattern{glob: s})
+	}
+	return res, nil
+}
+
+// Match returns true if any of the patterns matches s. Empty lists match
+// everything.
+func (pp List) Match(s string) bool {
+	if len(pp) == 0 {
+		return true
+	}
+	for _, p := range pp {
+		if p.Match(s) {
+			return true
+		}
+	}
+	return false
+}
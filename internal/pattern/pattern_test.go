@@ -0,0 +1,40 @@
+package pattern_test
+
+import (
+	"testing"
+
+	"github.com/bsm/rumour/internal/pattern"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("List", func() {
+	DescribeTable("should match",
+		func(patterns []string, name string, exp bool) {
+			pp, err := pattern.Compile(patterns)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pp.Match(name)).To(Equal(exp))
+		},
+		Entry("empty", nil, "orders", true),
+		Entry("glob", []string{"orders-*"}, "orders-worker", true),
+		Entry("glob mismatch", []string{"orders-*"}, "payments-worker", false),
+		Entry("any", []string{"payments-*", "orders-*"}, "orders-worker", true),
+		Entry("regexp", []string{`/^orders-\d+$/`}, "orders-1", true),
+		Entry("regexp mismatch", []string{`/^orders-\d+$/`}, "orders-worker", false),
+		Entry("slash", []string{"/"}, "/", true),
+	)
+
+	It("should reject invalid patterns", func() {
+		_, err := pattern.Compile([]string{"[x"})
+		Expect(err).To(MatchError(`invalid pattern "[x": syntax error in pattern`))
+
+		_, err = pattern.Compile([]string{"/(/"})
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "internal/pattern")
+}
@@ -0,0 +1,344 @@
+package rumour
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Shopify/sarama"
+)
+
+var (
+	// ErrUnknownCluster is returned for clusters that are not configured.
+	ErrUnknownCluster = errors.New("rumour: unknown cluster")
+	// ErrGroupActive is returned when a consumer group has active members.
+	ErrGroupActive = errors.New("rumour: consumer group has active members")
+	// ErrGroupNotFound is returned when a consumer group does not exist.
+	ErrGroupNotFound = errors.New("rumour: consumer group not found")
+	// ErrInvalidOffsetReset is returned for invalid offset reset requests.
+	ErrInvalidOffsetReset = errors.New("rumour: invalid offset reset")
+)
+
+// ResetMode determines how offsets are reset.
+type ResetMode string
+
+// Supported reset modes.
+const (
+	ResetToEarliest  ResetMode = "earliest"
+	ResetToLatest    ResetMode = "latest"
+	ResetToTimestamp ResetMode = "timestamp"
+	ResetToOffset    ResetMode = "offset"
+	ResetByShift     ResetMode = "shift"
+)
+
+// OffsetReset describes a consumer group offset reset.
+type OffsetReset struct {
+	// Topics to reset.
+	Topics []string `json:"topics"`
+	// Partitions to reset, all partitions if empty.
+	Partitions []int32 `json:"partitions,omitempty"`
+	// Mode determines how new offsets are computed.
+	Mode ResetMode `json:"mode"`
+	// Timestamp is used by ResetToTimestamp, in unix seconds. Partitions
+	// are reset to the first offset at or after the timestamp.
+	Timestamp int64 `json:"timestamp,omitempty"`
+	// Offset is used by ResetToOffset.
+	Offset int64 `json:"offset,omitempty"`
+	// Shift is used by ResetByShift, negative values move backwards.
+	Shift int64 `json:"shift,omitempty"`
+	// DryRun computes the new offsets without committing them.
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// Validate validates the request.
+func (r *OffsetReset) Validate() error {
+	if len(r.Topics) == 0 {
+		return fmt.Errorf("%w: topics are required", ErrInvalidOffsetReset)
+	}
+	switch r.Mode {
+	case ResetToEarliest, ResetToLatest, ResetByShift:
+	case ResetToTimestamp:
+		if r.Timestamp <= 0 {
+			return fmt.Errorf("%w: timestamp is required", ErrInvalidOffsetReset)
+		}
+	case ResetToOffset:
+		if r.Offset < 0 {
+			return fmt.Errorf("%w: offset must not be negative", ErrInvalidOffsetReset)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported mode %q", ErrInvalidOffsetReset, r.Mode)
+	}
+	return nil
+}
+
+// OffsetChange describes the change of a consumer partition offset.
+// OldOffset is OffsetUnknown if the partition had no committed offset.
+type OffsetChange struct {
+	Topic     string `json:"topic"`
+	Partition int32  `json:"partition"`
+	OldOffset int64  `json:"old_offset"`
+	NewOffset int64  `json:"new_offset"`
+}
+
+// --------------------------------------------------------------------
+
+// Admin performs administrative actions on the clusters of a fetcher.
+type Admin struct {
+	fetcher *Fetcher
+}
+
+// NewAdmin inits an admin.
+func NewAdmin(fetcher *Fetcher) *Admin {
+	return &Admin{fetcher: fetcher}
+}
+
+// Refresh triggers an immediate refresh of a cluster, see Fetcher.Refresh.
+func (a *Admin) Refresh(ctx context.Context, cluster string, kind RefreshKind, wait bool) error {
+	return a.fetcher.Refresh(ctx, cluster, kind, wait)
+}
+
+// SetCluster adds or updates a cluster, see Fetcher.SetCluster.
+func (a *Admin) SetCluster(cc *ClusterConfig) (bool, error) {
+	return a.fetcher.SetCluster(cc)
+}
+
+// RemoveCluster removes a cluster, see Fetcher.RemoveCluster.
+func (a *Admin) RemoveCluster(cluster string) error {
+	return a.fetcher.RemoveCluster(cluster)
+}
+
+// Pause pauses monitoring of a cluster, see Fetcher.Pause.
+func (a *Admin) Pause(cluster string) error {
+	return a.fetcher.Pause(cluster)
+}
+
+// Resume resumes monitoring of a cluster, see Fetcher.Resume.
+func (a *Admin) Resume(cluster string) error {
+	return a.fetcher.Resume(cluster)
+}
+
+// ResetOffsets resets the offsets of an inactive consumer group. New offsets
+// are limited to the available range of each partition. It returns the
+// changes, sorted by topic and partition.
+func (a *Admin) ResetOffsets(ctx context.Context, cluster, group string, req *OffsetReset) ([]OffsetChange, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	// offset lookups by timestamp require v0.10.1
+	client, err := a.connect(cluster, sarama.V0_10_1_0)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	coordinator, err := groupCoordinator(client, group)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkGroupInactive(coordinator, group); err != nil {
+		return nil, err
+	}
+
+	changes, err := planOffsetReset(client, coordinator, group, req)
+	if err != nil || req.DryRun {
+		return changes, err
+	}
+	if isDone(ctx) {
+		return nil, ctx.Err()
+	}
+
+	creq := &sarama.OffsetCommitRequest{
+		Version:                 2,
+		ConsumerGroup:           group,
+		ConsumerGroupGeneration: sarama.GroupGenerationUndefined,
+		RetentionTime:           -1,
+	}
+	for _, c := range changes {
+		creq.AddBlock(c.Topic, c.Partition, c.NewOffset, 0, "")
+	}
+
+	cres, err := coordinator.CommitOffset(creq)
+	if err != nil {
+		return nil, err
+	}
+	for _, blocks := range cres.Errors {
+		for _, kerr := range blocks {
+			if kerr != sarama.ErrNoError {
+				return nil, kerr
+			}
+		}
+	}
+	return changes, nil
+}
+
+// DeleteConsumerGroup deletes an inactive consumer group, including its
+// committed offsets. It requires Kafka 1.1 or later.
+func (a *Admin) DeleteConsumerGroup(ctx context.Context, cluster, group string) error {
+	client, err := a.connect(cluster, sarama.V1_1_0_0)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	coordinator, err := groupCoordinator(client, group)
+	if err != nil {
+		return err
+	}
+	if err := checkGroupInactive(coordinator, group); err != nil {
+		return err
+	}
+	if isDone(ctx) {
+		return ctx.Err()
+	}
+
+	res, err := coordinator.DeleteGroups(&sarama.DeleteGroupsRequest{Groups: []string{group}})
+	if err != nil {
+		return err
+	}
+
+	switch kerr := res.GroupErrorCodes[group]; kerr {
+	case sarama.ErrNoError:
+		a.fetcher.forgetGroup(ctx, cluster, group)
+		return nil
+	case sarama.ErrGroupIDNotFound:
+		return ErrGroupNotFound
+	case sarama.ErrNonEmptyGroup:
+		return ErrGroupActive
+	default:
+		return kerr
+	}
+}
+
+func (a *Admin) connect(cluster string, version sarama.KafkaVersion) (sarama.Client, error) {
+	cc, ok := a.fetcher.config(cluster)
+	if !ok {
+		return nil, ErrUnknownCluster
+	}
+
+	config := newSaramaConfig()
+	config.Version = version
+	return sarama.NewClient(cc.Brokers, config)
+}
+
+func groupCoordinator(client sarama.Client, group string) (*sarama.Broker, error) {
+	if err := client.RefreshCoordinator(group); err != nil {
+		return nil, err
+	}
+	return client.Coordinator(group)
+}
+
+// checkGroupInactive returns ErrGroupActive if the group has members.
+func checkGroupInactive(coordinator *sarama.Broker, group string) error {
+	res, err := coordinator.DescribeGroups(&sarama.DescribeGroupsRequest{Groups: []string{group}})
+	if err != nil {
+		return err
+	}
+	for _, desc := range res.Groups {
+		if desc.Err != sarama.ErrNoError {
+			return desc.Err
+		}
+		if len(desc.Members) != 0 {
+			return ErrGroupActive
+		}
+	}
+	return nil
+}
+
+func planOffsetReset(client sarama.Client, coordinator *sarama.Broker, group string, req *OffsetReset) ([]OffsetChange, error) {
+	freq := &sarama.OffsetFetchRequest{Version: 1, ConsumerGroup: group}
+	changes := make([]OffsetChange, 0)
+	for _, topic := range req.Topics {
+		partitions, err := client.Partitions(topic)
+		if errors.Is(err, sarama.ErrUnknownTopicOrPartition) {
+			return nil, fmt.Errorf("%w: unknown topic %q", ErrInvalidOffsetReset, topic)
+		} else if err != nil {
+			return nil, err
+		}
+
+		if len(req.Partitions) != 0 {
+			known := make(map[int32]bool, len(partitions))
+			for _, part := range partitions {
+				known[part] = true
+			}
+			for _, part := range req.Partitions {
+				if !known[part] {
+					return nil, fmt.Errorf("%w: unknown partition %s/%d", ErrInvalidOffsetReset, topic, part)
+				}
+			}
+			partitions = req.Partitions
+		}
+
+		for _, part := range partitions {
+			freq.AddPartition(topic, part)
+			changes = append(changes, OffsetChange{Topic: topic, Partition: part, OldOffset: OffsetUnknown})
+		}
+	}
+
+	fres, err := coordinator.FetchOffset(freq)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range changes {
+		c := &changes[i]
+		if block := fres.GetBlock(c.Topic, c.Partition); block != nil {
+			if block.Err != sarama.ErrNoError {
+				return nil, block.Err
+			}
+			c.OldOffset = block.Offset
+		}
+
+		earliest, err := client.GetOffset(c.Topic, c.Partition, sarama.OffsetOldest)
+		if err != nil {
+			return nil, err
+		}
+		latest, err := client.GetOffset(c.Topic, c.Partition, sarama.OffsetNewest)
+		if err != nil {
+			return nil, err
+		}
+
+		switch req.Mode {
+		case ResetToEarliest:
+			c.NewOffset = earliest
+		case ResetToLatest:
+			c.NewOffset = latest
+		case ResetToTimestamp:
+			off, err := client.GetOffset(c.Topic, c.Partition, req.Timestamp*1000)
+			if err != nil {
+				return nil, err
+			}
+			if off < 0 {
+				off = latest // no messages at or after the timestamp
+			}
+			c.NewOffset = off
+		case ResetToOffset:
+			c.NewOffset = req.Offset
+		case ResetByShift:
+			if c.OldOffset < 0 {
+				return nil, fmt.Errorf("%w: partition %s/%d has no committed offset to shift", ErrInvalidOffsetReset, c.Topic, c.Partition)
+			}
+			c.NewOffset = c.OldOffset + req.Shift
+		}
+		c.NewOffset = clampOffset(c.NewOffset, earliest, latest)
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Topic != changes[j].Topic {
+			return changes[i].Topic < changes[j].Topic
+		}
+		return changes[i].Partition < changes[j].Partition
+	})
+	return changes, nil
+}
+
+func clampOffset(offset, earliest, latest int64) int64 {
+	if offset < earliest {
+		return earliest
+	}
+	if offset > latest {
+		return latest
+	}
+	return offset
+}
@@ -0,0 +1,242 @@
+package rumour_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/bsm/rumour/internal/rumour"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Admin", func() {
+	var subject *rumour.Admin
+	var fetcher *rumour.Fetcher
+	var broker *sarama.MockBroker
+	var handlers map[string]sarama.MockResponse
+	var groups *sarama.MockDescribeGroupsResponse
+	var ctx = context.Background()
+
+	committed := func() map[int32]int64 {
+		offsets := make(map[int32]int64)
+		for _, rr := range broker.History() {
+			if req, ok := rr.Request.(*sarama.OffsetCommitRequest); ok {
+				for _, part := range []int32{0, 1} {
+					if off, _, err := req.Offset("orders", part); err == nil {
+						offsets[part] = off
+					}
+				}
+			}
+		}
+		return offsets
+	}
+
+	BeforeEach(func() {
+		broker = sarama.NewMockBroker(GinkgoT(), 1)
+		groups = sarama.NewMockDescribeGroupsResponse(GinkgoT())
+		handlers = map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockMetadataResponse(GinkgoT()).
+				SetBroker(broker.Addr(), broker.BrokerID()).
+				SetLeader("orders", 0, broker.BrokerID()).
+				SetLeader("orders", 1, broker.BrokerID()),
+			"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(GinkgoT()).
+				SetCoordinator(sarama.CoordinatorGroup, "orders-worker", broker),
+			"DescribeGroupsRequest": groups,
+			"OffsetFetchRequest": sarama.NewMockOffsetFetchResponse(GinkgoT()).
+				SetOffset("orders-worker", "orders", 0, 40, "", sarama.ErrNoError).
+				SetOffset("orders-worker", "orders", 1, -1, "", sarama.ErrNoError),
+			"OffsetRequest": sarama.NewMockOffsetResponse(GinkgoT()).
+				SetVersion(1).
+				SetOffset("orders", 0, sarama.OffsetOldest, 10).
+				SetOffset("orders", 0, sarama.OffsetNewest, 100).
+				SetOffset("orders", 0, 1515151515000, 70).
+				SetOffset("orders", 1, sarama.OffsetOldest, 0).
+				SetOffset("orders", 1, sarama.OffsetNewest, 50).
+				SetOffset("orders", 1, 1515151515000, -1),
+			"OffsetCommitRequest": sarama.NewMockOffsetCommitResponse(GinkgoT()),
+			"DeleteGroupsRequest": sarama.NewMockDeleteGroupsRequest(GinkgoT()).
+				SetDeletedGroups([]string{"orders-worker"}),
+		}
+		broker.SetHandlerByMap(handlers)
+
+		var err error
+		fetcher, err = rumour.NewFetcher(rumour.ClusterConfig{
+			Name:          "main",
+			Brokers:       []string{broker.Addr()},
+			MetaRefresh:   time.Hour,
+			OffsetRefresh: time.Hour,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		subject = rumour.NewAdmin(fetcher)
+	})
+
+	AfterEach(func() {
+		broker.Close()
+	})
+
+	DescribeTable("should reset offsets",
+		func(req rumour.OffsetReset, exp0, exp1 int64) {
+			changes, err := subject.ResetOffsets(ctx, "main", "orders-worker", &req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changes).To(Equal([]rumour.OffsetChange{
+				{Topic: "orders", Partition: 0, OldOffset: 40, NewOffset: exp0},
+				{Topic: "orders", Partition: 1, OldOffset: rumour.OffsetUnknown, NewOffset: exp1},
+			}))
+			Expect(committed()).To(Equal(map[int32]int64{0: exp0, 1: exp1}))
+		},
+		Entry("earliest", rumour.OffsetReset{Topics: []string{"orders"}, Mode: rumour.ResetToEarliest}, int64(10), int64(0)),
+		Entry("latest", rumour.OffsetReset{Topics: []string{"orders"}, Mode: rumour.ResetToLatest}, int64(100), int64(50)),
+		Entry("timestamp", rumour.OffsetReset{Topics: []string{"orders"}, Mode: rumour.ResetToTimestamp, Timestamp: 1515151515}, int64(70), int64(50)),
+		Entry("offset", rumour.OffsetReset{Topics: []string{"orders"}, Mode: rumour.ResetToOffset, Offset: 60}, int64(60), int64(50)),
+	)
+
+	It("should shift offsets", func() {
+		changes, err := subject.ResetOffsets(ctx, "main", "orders-worker", &rumour.OffsetReset{
+			Topics:     []string{"orders"},
+			Partitions: []int32{0},
+			Mode:       rumour.ResetByShift,
+			Shift:      -50,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(Equal([]rumour.OffsetChange{
+			{Topic: "orders", Partition: 0, OldOffset: 40, NewOffset: 10},
+		}))
+		Expect(committed()).To(Equal(map[int32]int64{0: 10}))
+
+		_, err = subject.ResetOffsets(ctx, "main", "orders-worker", &rumour.OffsetReset{
+			Topics: []string{"orders"},
+			Mode:   rumour.ResetByShift,
+			Shift:  5,
+		})
+		Expect(err).To(MatchError(rumour.ErrInvalidOffsetReset))
+	})
+
+	It("should support dry-runs", func() {
+		changes, err := subject.ResetOffsets(ctx, "main", "orders-worker", &rumour.OffsetReset{
+			Topics: []string{"orders"},
+			Mode:   rumour.ResetToLatest,
+			DryRun: true,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(HaveLen(2))
+		Expect(committed()).To(BeEmpty())
+	})
+
+	It("should refuse active groups", func() {
+		groups.AddGroupDescription("orders-worker", &sarama.GroupDescription{
+			GroupId: "orders-worker",
+			State:   "Stable",
+			Members: map[string]*sarama.GroupMemberDescription{"member-1": {ClientId: "worker"}},
+		})
+
+		_, err := subject.ResetOffsets(ctx, "main", "orders-worker", &rumour.OffsetReset{
+			Topics: []string{"orders"},
+			Mode:   rumour.ResetToLatest,
+		})
+		Expect(err).To(MatchError(rumour.ErrGroupActive))
+		Expect(committed()).To(BeEmpty())
+	})
+
+	It("should delete consumer groups", func() {
+		Expect(subject.DeleteConsumerGroup(ctx, "main", "orders-worker")).To(Succeed())
+		Expect(subject.DeleteConsumerGroup(ctx, "other", "orders-worker")).To(MatchError(rumour.ErrUnknownCluster))
+
+		groups.AddGroupDescription("orders-worker", &sarama.GroupDescription{
+			GroupId: "orders-worker",
+			State:   "Stable",
+			Members: map[string]*sarama.GroupMemberDescription{"member-1": {ClientId: "worker"}},
+		})
+		Expect(subject.DeleteConsumerGroup(ctx, "main", "orders-worker")).To(MatchError(rumour.ErrGroupActive))
+
+		var deleted int
+		for _, rr := range broker.History() {
+			if _, ok := rr.Request.(*sarama.DeleteGroupsRequest); ok {
+				deleted++
+			}
+		}
+		Expect(deleted).To(Equal(1))
+	})
+
+	It("should stop fetching deleted consumer groups", func() {
+		// the group is active at the time of the metadata refresh and empty
+		// once deleted
+		handlers["ListGroupsRequest"] = sarama.NewMockListGroupsResponse(GinkgoT()).
+			AddGroup("orders-worker", "consumer")
+		handlers["DescribeGroupsRequest"] = sarama.NewMockSequence(
+			&sarama.DescribeGroupsResponse{Groups: []*sarama.GroupDescription{{
+				GroupId: "orders-worker",
+				State:   "Stable",
+				Members: map[string]*sarama.GroupMemberDescription{
+					"member-1": {ClientId: "worker", MemberAssignment: memberAssignment("orders", 0, 1)},
+				},
+			}}},
+			&sarama.DescribeGroupsResponse{Groups: []*sarama.GroupDescription{{
+				GroupId: "orders-worker",
+				State:   "Empty",
+			}}},
+		)
+		handlers["OffsetRequest"] = sarama.NewMockOffsetResponse(GinkgoT()).
+			SetOffset("orders", 0, sarama.OffsetNewest, 100).
+			SetOffset("orders", 1, sarama.OffsetNewest, 50)
+		broker.SetHandlerByMap(handlers)
+
+		state := rumour.NewState(nil)
+		loopCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			fetcher.RunLoop(loopCtx, state)
+		}()
+		defer func() {
+			cancel()
+			Eventually(done).Should(BeClosed())
+		}()
+
+		Eventually(func() int {
+			var described int
+			for _, rr := range broker.History() {
+				if _, ok := rr.Request.(*sarama.DescribeGroupsRequest); ok {
+					described++
+				}
+			}
+			return described
+		}).Should(Equal(1))
+
+		Expect(subject.DeleteConsumerGroup(ctx, "main", "orders-worker")).To(Succeed())
+		state.Cluster("main").DeleteConsumerGroup("orders-worker")
+
+		Expect(fetcher.Refresh(ctx, "main", rumour.RefreshOffsets, true)).To(Succeed())
+		Expect(state.Cluster("main").ConsumerGroups()).To(BeEmpty())
+	})
+
+	It("should validate requests", func() {
+		_, err := subject.ResetOffsets(ctx, "main", "orders-worker", &rumour.OffsetReset{Mode: rumour.ResetToLatest})
+		Expect(err).To(MatchError(rumour.ErrInvalidOffsetReset))
+
+		_, err = subject.ResetOffsets(ctx, "main", "orders-worker", &rumour.OffsetReset{Topics: []string{"orders"}, Mode: "bad"})
+		Expect(err).To(MatchError(rumour.ErrInvalidOffsetReset))
+
+		_, err = subject.ResetOffsets(ctx, "main", "orders-worker", &rumour.OffsetReset{Topics: []string{"orders"}, Partitions: []int32{7}, Mode: rumour.ResetToLatest})
+		Expect(err).To(MatchError(rumour.ErrInvalidOffsetReset))
+
+		_, err = subject.ResetOffsets(ctx, "other", "orders-worker", &rumour.OffsetReset{Topics: []string{"orders"}, Mode: rumour.ResetToLatest})
+		Expect(err).To(MatchError(rumour.ErrUnknownCluster))
+	})
+})
+
+// memberAssignment encodes the assignment of a consumer group member.
+func memberAssignment(topic string, partitions ...int32) []byte {
+	buf := new(bytes.Buffer)
+	_ = binary.Write(buf, binary.BigEndian, int16(0)) // version
+	_ = binary.Write(buf, binary.BigEndian, int32(1)) // number of topics
+	_ = binary.Write(buf, binary.BigEndian, int16(len(topic)))
+	buf.WriteString(topic)
+	_ = binary.Write(buf, binary.BigEndian, int32(len(partitions)))
+	_ = binary.Write(buf, binary.BigEndian, partitions)
+	_ = binary.Write(buf, binary.BigEndian, int32(-1)) // no user data
+	return buf.Bytes()
+}
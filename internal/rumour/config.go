@@ -0,0 +1,170 @@
+package rumour
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// ErrInvalidClusterConfig is returned for invalid cluster configs.
+var ErrInvalidClusterConfig = errors.New("rumour: invalid cluster config")
+
+// Default refresh intervals.
+const (
+	DefaultMetaRefresh   = 180 * time.Second
+	DefaultOffsetRefresh = 30 * time.Second
+)
+
+type clusterConfigJSON struct {
+	Name          string   `json:"name,omitempty"`
+	Brokers       []string `json:"brokers"`
+	MetaRefresh   string   `json:"meta_refresh,omitempty"`
+	OffsetRefresh string   `json:"offset_refresh,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler, intervals are encoded as strings.
+func (c ClusterConfig) MarshalJSON() ([]byte, error) {
+	v := clusterConfigJSON{Name: c.Name, Brokers: c.Brokers}
+	if c.MetaRefresh > 0 {
+		v.MetaRefresh = c.MetaRefresh.String()
+	}
+	if c.OffsetRefresh > 0 {
+		v.OffsetRefresh = c.OffsetRefresh.String()
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *ClusterConfig) UnmarshalJSON(data []byte) error {
+	var v clusterConfigJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*c = ClusterConfig{Name: v.Name, Brokers: v.Brokers}
+	if v.MetaRefresh != "" {
+		d, err := time.ParseDuration(v.MetaRefresh)
+		if err != nil {
+			return fmt.Errorf("invalid meta_refresh: %w", err)
+		}
+		c.MetaRefresh = d
+	}
+	if v.OffsetRefresh != "" {
+		d, err := time.ParseDuration(v.OffsetRefresh)
+		if err != nil {
+			return fmt.Errorf("invalid offset_refresh: %w", err)
+		}
+		c.OffsetRefresh = d
+	}
+	return nil
+}
+
+// norm validates the config and applies defaults.
+func (c *ClusterConfig) norm() error {
+	if c.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidClusterConfig)
+	}
+	if len(c.Brokers) == 0 {
+		return fmt.Errorf("%w: brokers are required", ErrInvalidClusterConfig)
+	}
+	if c.MetaRefresh <= 0 {
+		c.MetaRefresh = DefaultMetaRefresh
+	}
+	if c.OffsetRefresh <= 0 {
+		c.OffsetRefresh = DefaultOffsetRefresh
+	}
+	return nil
+}
+
+func (c *ClusterConfig) equal(o *ClusterConfig) bool {
+	if c.Name != o.Name || c.MetaRefresh != o.MetaRefresh || c.OffsetRefresh != o.OffsetRefresh || len(c.Brokers) != len(o.Brokers) {
+		return false
+	}
+	for i := range c.Brokers {
+		if c.Brokers[i] != o.Brokers[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// --------------------------------------------------------------------
+
+// LoadClusterConfigs loads cluster configs from a JSON file, e.g.:
+//
+//	{"clusters": {"main": {"brokers": ["10.0.0.1:9092"], "meta_refresh": "120s"}}}
+//
+// Configs are returned sorted by name.
+func LoadClusterConfigs(name string) ([]ClusterConfig, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Clusters map[string]ClusterConfig `json:"clusters"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("rumour: invalid cluster configs in %s: %w", name, err)
+	}
+	if len(file.Clusters) == 0 {
+		return nil, fmt.Errorf("rumour: no clusters configured in %s", name)
+	}
+
+	configs := make([]ClusterConfig, 0, len(file.Clusters))
+	for name, cc := range file.Clusters {
+		cc.Name = name
+		if err := cc.norm(); err != nil {
+			return nil, fmt.Errorf("rumour: cluster %q: %w", name, err)
+		}
+		configs = append(configs, cc)
+	}
+	sort.Slice(configs, func(i, j int) bool { return configs[i].Name < configs[j].Name })
+	return configs, nil
+}
+
+// ReloadLoop checks a cluster configs file for changes every interval and
+// reconfigures the fetcher accordingly. Invalid changes are logged and
+// ignored. It blocks until the context is cancelled.
+func (f *Fetcher) ReloadLoop(ctx context.Context, name string, interval time.Duration) {
+	var modTime time.Time
+	var size int64
+	if fi, err := os.Stat(name); err == nil {
+		modTime, size = fi.ModTime(), fi.Size()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		fi, err := os.Stat(name)
+		if err != nil {
+			f.logger.Printf("unable to check %s: %v", name, err)
+			continue
+		}
+		if fi.ModTime().Equal(modTime) && fi.Size() == size {
+			continue
+		}
+		modTime, size = fi.ModTime(), fi.Size()
+
+		configs, err := LoadClusterConfigs(name)
+		if err == nil {
+			err = f.Reconfigure(configs...)
+		}
+		if err != nil {
+			f.logger.Printf("unable to reload clusters: %v", err)
+			continue
+		}
+		f.logger.Printf("reloaded clusters from %s", name)
+	}
+}
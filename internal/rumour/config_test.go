@@ -0,0 +1,63 @@
+package rumour_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bsm/rumour/internal/rumour"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("ClusterConfig", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "rumour-config")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("should encode/decode JSON", func() {
+		cc := rumour.ClusterConfig{Name: "main", Brokers: []string{"10.0.0.1:9092"}, MetaRefresh: 2 * time.Minute}
+		data, err := json.Marshal(cc)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(MatchJSON(`{"name":"main","brokers":["10.0.0.1:9092"],"meta_refresh":"2m0s"}`))
+
+		var decoded rumour.ClusterConfig
+		Expect(json.Unmarshal(data, &decoded)).To(Succeed())
+		Expect(decoded).To(Equal(cc))
+
+		Expect(json.Unmarshal([]byte(`{"offset_refresh":"soon"}`), &decoded)).To(MatchError(ContainSubstring("invalid offset_refresh")))
+	})
+
+	It("should load from files", func() {
+		name := filepath.Join(dir, "clusters.json")
+		Expect(os.WriteFile(name, []byte(`{
+			"clusters": {
+				"prio": {"brokers": ["10.0.0.1:9192"], "offset_refresh": "10s"},
+				"main": {"brokers": ["10.0.0.1:9092"]}
+			}
+		}`), 0o600)).To(Succeed())
+
+		Expect(rumour.LoadClusterConfigs(name)).To(Equal([]rumour.ClusterConfig{
+			{Name: "main", Brokers: []string{"10.0.0.1:9092"}, MetaRefresh: rumour.DefaultMetaRefresh, OffsetRefresh: rumour.DefaultOffsetRefresh},
+			{Name: "prio", Brokers: []string{"10.0.0.1:9192"}, MetaRefresh: rumour.DefaultMetaRefresh, OffsetRefresh: 10 * time.Second},
+		}))
+
+		Expect(os.WriteFile(name, []byte(`{"clusters": {"main": {}}}`), 0o600)).To(Succeed())
+		_, err := rumour.LoadClusterConfigs(name)
+		Expect(err).To(MatchError(rumour.ErrInvalidClusterConfig))
+
+		Expect(os.WriteFile(name, []byte(`{}`), 0o600)).To(Succeed())
+		_, err = rumour.LoadClusterConfigs(name)
+		Expect(err).To(MatchError(ContainSubstring("no clusters configured")))
+	})
+})
@@ -0,0 +1,134 @@
+package rumour
+
+import "sync"
+
+// EventType identifies the kind of a state change event.
+type EventType string
+
+// Supported event types.
+const (
+	EventTopicOffsets    EventType = "topic_offsets"
+	EventConsumerOffsets EventType = "consumer_offsets"
+	EventGroupAdded      EventType = "group_added"
+	EventGroupExpired    EventType = "group_expired"
+	EventGroupDeleted    EventType = "group_deleted"
+	EventFetchError      EventType = "fetch_error"
+
+	// EventLagged is not published by the state, it is sent to stream
+	// clients after events were dropped and their view must be refreshed.
+	EventLagged EventType = "lagged"
+)
+
+// Event describes a change of the cluster state.
+type Event struct {
+	Type      EventType   `json:"type"`
+	Cluster   string      `json:"cluster"`
+	Group     string      `json:"consumer,omitempty"`
+	Topic     string      `json:"topic,omitempty"`
+	Timestamp int64       `json:"timestamp"`
+	Offsets   []int64     `json:"offsets,omitempty"`
+	Summary   *LagSummary `json:"summary,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
+
+// EventFilter restricts subscriptions to matching events. Empty fields
+// match everything.
+type EventFilter struct {
+	Cluster string
+	Group   string
+	Topic   string
+}
+
+// Match returns true if the event matches the filter. Fetch errors are not
+// associated with groups or topics and are matched by cluster only.
+func (f EventFilter) Match(ev Event) bool {
+	if f.Cluster != "" && f.Cluster != ev.Cluster {
+		return false
+	}
+	if ev.Type == EventFetchError {
+		return true
+	}
+	if f.Group != "" && f.Group != ev.Group {
+		return false
+	}
+	if f.Topic != "" && f.Topic != ev.Topic {
+		return false
+	}
+	return true
+}
+
+// --------------------------------------------------------------------
+
+// Subscription receives published events.
+type Subscription struct {
+	C <-chan Event
+
+	// Lagged receives a value when events were dropped because the
+	// subscriber could not keep up.
+	Lagged <-chan struct{}
+
+	c      chan Event
+	lagged chan struct{}
+	filter EventFilter
+	hub    *eventHub
+	once   sync.Once
+}
+
+// Close unsubscribes and closes the event channel.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.hub.unsubscribe(s)
+	})
+}
+
+type eventHub struct {
+	subs map[*Subscription]struct{}
+	mu   sync.RWMutex
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{subs: make(map[*Subscription]struct{})}
+}
+
+func (h *eventHub) subscribe(filter EventFilter, size int) *Subscription {
+	c := make(chan Event, size)
+	lagged := make(chan struct{}, 1)
+	sub := &Subscription{C: c, Lagged: lagged, c: c, lagged: lagged, filter: filter, hub: h}
+
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+
+	return sub
+}
+
+func (h *eventHub) unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	delete(h.subs, sub)
+	close(sub.c)
+	h.mu.Unlock()
+}
+
+// publish delivers events to all matching subscribers. It never blocks,
+// events are dropped for subscribers that cannot keep up and their Lagged
+// channel is signalled.
+func (h *eventHub) publish(events ...Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for sub := range h.subs {
+		for _, ev := range events {
+			if !sub.filter.Match(ev) {
+				continue
+			}
+			select {
+			case sub.c <- ev:
+			default:
+				select {
+				case sub.lagged <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package rumour_test
+
+import (
+	"errors"
+
+	"github.com/bsm/rumour/internal/rumour"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Events", func() {
+	var subject *rumour.State
+
+	BeforeEach(func() {
+		subject = rumour.NewState([]string{"default", "other"})
+	})
+
+	It("should publish state changes", func() {
+		sub := subject.Subscribe(rumour.EventFilter{}, 10)
+		defer sub.Close()
+
+		cluster := subject.Cluster("default")
+		cluster.UpdateTopic("orders", []int64{100, 100})
+		cluster.UpdateConsumerOffsets("orders-worker", "orders", 1515151515, []int64{90, 80})
+		cluster.UpdateConsumerOffsets("orders-worker", "orders", 1515151514, []int64{80, 70})
+		cluster.ExpireConsumerGroups(1515151516)
+		cluster.ReportError(errors.New("broker unavailable"))
+
+		ev := <-sub.C
+		Expect(ev.Type).To(Equal(rumour.EventTopicOffsets))
+		Expect(ev.Cluster).To(Equal("default"))
+		Expect(ev.Topic).To(Equal("orders"))
+		Expect(ev.Offsets).To(Equal([]int64{100, 100}))
+
+		Expect(<-sub.C).To(Equal(rumour.Event{
+			Type:      rumour.EventGroupAdded,
+			Cluster:   "default",
+			Group:     "orders-worker",
+			Timestamp: 1515151515,
+		}))
+		Expect(<-sub.C).To(Equal(rumour.Event{
+			Type:      rumour.EventConsumerOffsets,
+			Cluster:   "default",
+			Group:     "orders-worker",
+			Topic:     "orders",
+			Timestamp: 1515151515,
+			Offsets:   []int64{90, 80},
+			Summary:   &rumour.LagSummary{TotalLag: 30, MaxLag: 20, MaxLagPartition: 1, Partitions: 2},
+		}))
+
+		ev = <-sub.C
+		Expect(ev.Type).To(Equal(rumour.EventGroupExpired))
+		Expect(ev.Group).To(Equal("orders-worker"))
+
+		ev = <-sub.C
+		Expect(ev.Type).To(Equal(rumour.EventFetchError))
+		Expect(ev.Error).To(Equal("broker unavailable"))
+		Expect(sub.C).NotTo(Receive())
+	})
+
+	It("should expire groups on reset", func() {
+		cluster := subject.Cluster("default")
+		cluster.UpdateConsumerOffsets("orders-worker", "orders", 1515151515, []int64{90})
+
+		sub := subject.Subscribe(rumour.EventFilter{}, 10)
+		defer sub.Close()
+
+		cluster.Reset()
+		Expect(sub.C).To(Receive(And(
+			HaveField("Type", rumour.EventGroupExpired),
+			HaveField("Group", "orders-worker"),
+		)))
+		Expect(sub.C).NotTo(Receive())
+	})
+
+	It("should filter events", func() {
+		sub := subject.Subscribe(rumour.EventFilter{Cluster: "default", Topic: "orders"}, 10)
+		defer sub.Close()
+
+		subject.Cluster("other").UpdateTopic("orders", []int64{100})
+		subject.Cluster("default").UpdateTopic("payments", []int64{100})
+		subject.Cluster("default").UpdateTopic("orders", []int64{100})
+		subject.Cluster("default").ReportError(errors.New("broker unavailable"))
+		subject.Cluster("other").ReportError(errors.New("broker unavailable"))
+
+		Expect(sub.C).To(Receive(HaveField("Type", rumour.EventTopicOffsets)))
+		Expect(sub.C).To(Receive(HaveField("Type", rumour.EventFetchError)))
+		Expect(sub.C).NotTo(Receive())
+	})
+
+	It("should drop events for slow subscribers", func() {
+		sub := subject.Subscribe(rumour.EventFilter{}, 1)
+		subject.Cluster("default").UpdateTopic("orders", []int64{100})
+		subject.Cluster("default").UpdateTopic("payments", []int64{100})
+
+		Expect(sub.C).To(Receive(HaveField("Topic", "orders")))
+		Expect(sub.C).NotTo(Receive())
+		Expect(sub.Lagged).To(Receive())
+		Expect(sub.Lagged).NotTo(Receive())
+
+		sub.Close()
+		sub.Close()
+		Expect(sub.C).To(BeClosed())
+	})
+})
@@ -3,8 +3,10 @@ package rumour
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
 	"os"
+	"sort"
 	"sync"
 	"time"
 
@@ -19,12 +21,92 @@ type ClusterConfig struct {
 	OffsetRefresh time.Duration `default:"30s"`
 }
 
+// MinRefreshInterval is the minimum interval between on-demand refreshes of
+// a cluster.
+const MinRefreshInterval = 10 * time.Second
+
+// ErrRefreshLimited is returned when on-demand refreshes are requested more
+// frequently than MinRefreshInterval.
+var ErrRefreshLimited = errors.New("rumour: refresh rate limited")
+
+// ErrRefreshTimeout is returned when a refresh does not complete within the
+// offset refresh interval of a cluster.
+var ErrRefreshTimeout = errors.New("rumour: refresh timed out")
+
+// RefreshKind determines what is refreshed on demand.
+type RefreshKind string
+
+// Supported refresh kinds.
+const (
+	RefreshMeta    RefreshKind = "meta"
+	RefreshOffsets RefreshKind = "offsets"
+)
+
+// ParseRefreshKind parses a refresh kind. Empty strings default to
+// RefreshMeta.
+func ParseRefreshKind(s string) (RefreshKind, error) {
+	switch kind := RefreshKind(s); kind {
+	case "":
+		return RefreshMeta, nil
+	case RefreshMeta, RefreshOffsets:
+		return kind, nil
+	}
+	return "", fmt.Errorf("invalid refresh kind %q", s)
+}
+
+// ErrClusterPaused is returned when a paused cluster is refreshed.
+var ErrClusterPaused = errors.New("rumour: cluster is paused")
+
+type refreshRequest struct {
+	meta bool
+	done chan error
+}
+
+// clusterMonitor controls the monitoring of a single cluster.
+type clusterMonitor struct {
+	config  ClusterConfig
+	refresh chan *refreshRequest
+	forget  chan string // consumer groups deleted through the admin
+	resume  chan struct{}
+	removed chan struct{} // closed once the cluster is removed
+
+	configured bool // managed by Reconfigure, rather than SetCluster
+	paused     bool
+	cancel     context.CancelFunc // stops the running monitor
+	remove     context.CancelFunc // stops monitoring for good
+	done       chan struct{}      // closed once monitoring has stopped
+}
+
+func newClusterMonitor(cc ClusterConfig) *clusterMonitor {
+	return &clusterMonitor{
+		config:  cc,
+		refresh: make(chan *refreshRequest, 1),
+		forget:  make(chan string),
+		resume:  make(chan struct{}, 1),
+		removed: make(chan struct{}),
+	}
+}
+
+// fetchLoop is the context of a running loop.
+type fetchLoop struct {
+	ctx     context.Context
+	state   *State
+	wg      *sync.WaitGroup
+	removed map[string]chan struct{} // done channels of removed monitors
+}
+
 // Fetcher updates state.
 type Fetcher struct {
-	clusters []ClusterConfig
-	logger   *log.Logger
+	logger *log.Logger
+
+	running sync.Mutex
+
+	mu       sync.Mutex
+	monitors map[string]*clusterMonitor
+	loop     *fetchLoop
 
-	mu sync.Mutex
+	lmu       sync.Mutex
+	refreshed map[string]time.Time
 }
 
 // NewFetcher inits a fetcher.
@@ -33,45 +115,421 @@ func NewFetcher(clusters ...ClusterConfig) (*Fetcher, error) {
 		return nil, errors.New("rumour: list of monitored clusters cannot be empty")
 	}
 
+	monitors := make(map[string]*clusterMonitor, len(clusters))
+	for _, cc := range clusters {
+		if err := cc.norm(); err != nil {
+			return nil, err
+		}
+		m := newClusterMonitor(cc)
+		m.configured = true
+		monitors[cc.Name] = m
+	}
+
 	return &Fetcher{
-		logger:   log.New(os.Stdout, "[fetch] ", log.LstdFlags),
-		clusters: clusters,
+		logger:    log.New(os.Stdout, "[fetch] ", log.LstdFlags),
+		monitors:  monitors,
+		refreshed: make(map[string]time.Time),
 	}, nil
 }
 
-// RunLoop starts the blocking loop.
+// RunLoop starts the blocking loop. Clusters are added to the state as they
+// are monitored.
 func (f *Fetcher) RunLoop(ctx context.Context, state *State) {
-	f.mu.Lock()
-	defer f.mu.Unlock()
+	f.running.Lock()
+	defer f.running.Unlock()
 
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	wg := new(sync.WaitGroup)
-	for _, cc := range f.clusters {
-		wg.Add(1)
-		go func(c *ClusterConfig) {
-			defer wg.Done()
 
+	f.mu.Lock()
+	f.loop = &fetchLoop{ctx: ctx, state: state, wg: wg, removed: make(map[string]chan struct{})}
+	for _, m := range f.monitors {
+		f.spawn(m)
+	}
+	f.mu.Unlock()
+
+	<-ctx.Done()
+
+	f.mu.Lock()
+	f.loop = nil
+	f.mu.Unlock()
+
+	wg.Wait()
+}
+
+// Clusters returns the configs of all monitored clusters, sorted by name.
+func (f *Fetcher) Clusters() []ClusterConfig {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	configs := make([]ClusterConfig, 0, len(f.monitors))
+	for _, m := range f.monitors {
+		configs = append(configs, m.config)
+	}
+	sort.Slice(configs, func(i, j int) bool { return configs[i].Name < configs[j].Name })
+	return configs
+}
+
+// SetCluster adds a cluster or updates its config, defaults are applied to
+// cc. Updates restart the monitoring of the cluster, unless the config is
+// unchanged. It returns true if the cluster was added.
+func (f *Fetcher) SetCluster(cc *ClusterConfig) (bool, error) {
+	if err := cc.norm(); err != nil {
+		return false, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.set(*cc), nil
+}
+
+// RemoveCluster stops monitoring a cluster and removes it from the state.
+func (f *Fetcher) RemoveCluster(name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.monitors[name]; !ok {
+		return ErrUnknownCluster
+	}
+	f.remove(name)
+	return nil
+}
+
+// Reconfigure replaces the configs of all configured clusters, i.e. those
+// passed to NewFetcher or a previous Reconfigure. Clusters are added, updated
+// or removed as needed, unchanged clusters continue uninterrupted. Clusters
+// added by SetCluster are retained, unless listed.
+func (f *Fetcher) Reconfigure(clusters ...ClusterConfig) error {
+	if len(clusters) == 0 {
+		return errors.New("rumour: list of monitored clusters cannot be empty")
+	}
+
+	keep := make(map[string]struct{}, len(clusters))
+	for i := range clusters {
+		if err := clusters[i].norm(); err != nil {
+			return err
+		}
+		keep[clusters[i].Name] = struct{}{}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for name, m := range f.monitors {
+		if _, ok := keep[name]; !ok && m.configured {
+			f.remove(name)
+		}
+	}
+	for _, cc := range clusters {
+		f.set(cc)
+		f.monitors[cc.Name].configured = true
+	}
+	return nil
+}
+
+// set adds or updates a cluster, must be called while holding the lock.
+func (f *Fetcher) set(cc ClusterConfig) bool {
+	m, ok := f.monitors[cc.Name]
+	if !ok {
+		m = newClusterMonitor(cc)
+		f.monitors[cc.Name] = m
+		f.logger.Printf("added cluster %q", cc.Name)
+		if f.loop != nil {
+			f.spawn(m)
+		}
+		return true
+	}
+
+	if !m.config.equal(&cc) {
+		m.config = cc
+		f.logger.Printf("updated cluster %q", cc.Name)
+		if m.cancel != nil {
+			m.cancel() // restart with the new config
+		}
+	}
+	return false
+}
+
+// remove removes a cluster, must be called while holding the lock.
+func (f *Fetcher) remove(name string) {
+	m := f.monitors[name]
+	delete(f.monitors, name)
+	f.logger.Printf("removed cluster %q", name)
+
+	close(m.removed)
+	if m.remove != nil {
+		m.remove()
+	}
+	if f.loop != nil {
+		f.loop.state.RemoveCluster(name)
+		if m.done != nil {
+			f.loop.removed[name] = m.done
+		}
+	}
+
+	f.lmu.Lock()
+	delete(f.refreshed, name)
+	f.lmu.Unlock()
+}
+
+// spawn starts monitoring a cluster, must be called while holding the lock.
+func (f *Fetcher) spawn(m *clusterMonitor) {
+	state := f.loop.state.AddCluster(m.config.Name)
+
+	var ctx context.Context
+	ctx, m.remove = context.WithCancel(f.loop.ctx)
+
+	// a removed monitor of the same name may still be finishing a fetch
+	prev := f.loop.removed[m.config.Name]
+	delete(f.loop.removed, m.config.Name)
+
+	done := make(chan struct{})
+	m.done = done
+
+	wg := f.loop.wg
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(done)
+
+		if prev != nil {
+			<-prev
+		}
+		f.run(ctx, m, state)
+	}()
+}
+
+// Pause stops monitoring a cluster until it is resumed. The last known state
+// of the cluster is retained.
+func (f *Fetcher) Pause(cluster string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	m, ok := f.monitors[cluster]
+	if !ok {
+		return ErrUnknownCluster
+	}
+
+	m.paused = true
+	if m.cancel != nil {
+		m.cancel()
+	}
+	return nil
+}
+
+// Resume resumes monitoring of a paused cluster.
+func (f *Fetcher) Resume(cluster string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	m, ok := f.monitors[cluster]
+	if !ok {
+		return ErrUnknownCluster
+	}
+
+	if m.paused {
+		m.paused = false
+		select {
+		case m.resume <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Paused returns true if monitoring of a cluster is paused.
+func (f *Fetcher) Paused(cluster string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	m, ok := f.monitors[cluster]
+	return ok && m.paused
+}
+
+// Refresh triggers an immediate refresh of a cluster. RefreshMeta refreshes
+// brokers, topics and consumer groups, followed by their offsets. If wait is
+// true, it blocks until the refresh has completed and returns its error, but
+// no longer than the OffsetRefresh interval of the cluster. Refreshes are
+// limited to one per MinRefreshInterval and cluster.
+func (f *Fetcher) Refresh(ctx context.Context, cluster string, kind RefreshKind, wait bool) error {
+	f.mu.Lock()
+	m, ok := f.monitors[cluster]
+	paused := ok && m.paused
+	var timeout time.Duration
+	if ok {
+		timeout = m.config.OffsetRefresh
+	}
+	f.mu.Unlock()
+
+	if !ok {
+		return ErrUnknownCluster
+	} else if paused {
+		return ErrClusterPaused
+	}
+	if !f.allowRefresh(cluster) {
+		return ErrRefreshLimited
+	}
+
+	req := &refreshRequest{meta: kind == RefreshMeta}
+	if !wait {
+		select {
+		case m.refresh <- req:
+		default: // a refresh is already pending
+		}
+		return nil
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	req.done = make(chan error, 1)
+	select {
+	case m.refresh <- req:
+	case <-m.removed:
+		return ErrUnknownCluster
+	case <-timer.C:
+		return ErrRefreshTimeout
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-req.done:
+		return err
+	case <-m.removed:
+		return ErrUnknownCluster
+	case <-timer.C:
+		return ErrRefreshTimeout
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// forgetGroup stops fetching the offsets of a deleted consumer group until
+// it is rediscovered by a metadata refresh. Otherwise, the next offset
+// refresh would restore the group.
+func (f *Fetcher) forgetGroup(ctx context.Context, cluster, group string) {
+	f.mu.Lock()
+	m, ok := f.monitors[cluster]
+	var done chan struct{}
+	if ok {
+		done = m.done
+	}
+	f.mu.Unlock()
+
+	if done == nil { // not monitored yet
+		return
+	}
+
+	select {
+	case m.forget <- group:
+	case <-done:
+	case <-ctx.Done():
+	}
+}
+
+func (f *Fetcher) config(cluster string) (ClusterConfig, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if m, ok := f.monitors[cluster]; ok {
+		return m.config, true
+	}
+	return ClusterConfig{}, false
+}
+
+func (f *Fetcher) allowRefresh(cluster string) bool {
+	f.lmu.Lock()
+	defer f.lmu.Unlock()
+
+	now := time.Now()
+	if now.Sub(f.refreshed[cluster]) < MinRefreshInterval {
+		return false
+	}
+	f.refreshed[cluster] = now
+	return true
+}
+
+// run monitors a cluster until the context is cancelled, pausing and
+// resuming on demand.
+func (f *Fetcher) run(ctx context.Context, m *clusterMonitor, state *ClusterState) {
+	var brokers []string
+	for !isDone(ctx) {
+		mctx, cc, ok := f.start(ctx, m)
+		if !ok {
+			f.logger.Printf("paused monitoring of %q", cc.Name)
+			state.SetPaused(true)
+
+			// refuse refreshes requested before the pause
+			select {
+			case req := <-m.refresh:
+				if req.done != nil {
+					req.done <- ErrClusterPaused
+				}
+			default:
+			}
+
+		wait:
 			for {
-				if isDone(ctx) {
+				select {
+				case <-ctx.Done():
 					return
+				case <-m.forget:
+					// groups are discovered afresh on resume
+				case <-m.resume:
+					break wait
 				}
-				f.monitor(ctx, c, state.Cluster(c.Name))
 			}
-		}(&cc)
+
+			f.logger.Printf("resumed monitoring of %q", cc.Name)
+			state.SetPaused(false)
+			continue
+		}
+
+		// state of the previous brokers may not apply to the new ones
+		if brokers != nil && !sameBrokers(brokers, cc.Brokers) {
+			f.logger.Printf("brokers of %q changed, resetting state", cc.Name)
+			state.Reset()
+		}
+		brokers = cc.Brokers
+
+		f.watch(mctx, &cc, m.refresh, m.forget, state)
+		f.stop(m)
 	}
-	wg.Wait()
 }
 
-func (f *Fetcher) monitor(ctx context.Context, cc *ClusterConfig, state *ClusterState) {
-	config := sarama.NewConfig()
-	config.ClientID = "rumour"
-	config.Version = sarama.V0_10_0_0
+// start returns a context for the monitor and the current config, unless the
+// cluster is paused.
+func (f *Fetcher) start(ctx context.Context, m *clusterMonitor) (context.Context, ClusterConfig, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if m.paused {
+		return nil, m.config, false
+	}
 
-	client, err := sarama.NewClient(cc.Brokers, config)
+	ctx, m.cancel = context.WithCancel(ctx)
+	return ctx, m.config, true
+}
+
+func (f *Fetcher) stop(m *clusterMonitor) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if m.cancel != nil {
+		m.cancel()
+		m.cancel = nil
+	}
+}
+
+func (f *Fetcher) watch(ctx context.Context, cc *ClusterConfig, refresh <-chan *refreshRequest, forget <-chan string, state *ClusterState) {
+	client, err := sarama.NewClient(cc.Brokers, newSaramaConfig())
 	if err != nil {
 		f.logger.Printf("error connecting to %q: %v", cc.Name, err)
+		state.ReportError(err)
 		return
 	}
 	defer client.Close()
@@ -88,30 +546,73 @@ func (f *Fetcher) monitor(ctx context.Context, cc *ClusterConfig, state *Cluster
 	ott := time.NewTimer(0)
 	defer ott.Stop()
 
+	refreshMeta := func() error {
+		start := time.Now()
+		if err := cf.refreshMeta(ctx); err != nil {
+			f.logger.Printf("error refreshing groups for %q: %v", cc.Name, err)
+			state.ReportError(err)
+			resetTimer(mtt, 30*time.Second) // try again in 30s
+			return err
+		}
+		f.logger.Printf("refreshed metadata for %q in %.3fs", cc.Name, time.Since(start).Seconds())
+		resetTimer(mtt, cc.MetaRefresh)
+		return nil
+	}
+
+	refreshOffsets := func() error {
+		start := time.Now()
+		if err := cf.refreshOffsets(ctx, start.Add(-2*cc.OffsetRefresh)); err != nil {
+			f.logger.Printf("error refreshing offsets for %q: %v", cc.Name, err)
+			state.ReportError(err)
+			resetTimer(ott, 30*time.Second) // try again in 30s
+			return err
+		}
+		f.logger.Printf("refreshed offsets for %q in %.3fs", cc.Name, time.Since(start).Seconds())
+		resetTimer(ott, cc.OffsetRefresh)
+		return nil
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-mtt.C:
-			start := time.Now()
-			if err := cf.refreshMeta(ctx); err != nil {
-				f.logger.Printf("error refreshing groups for %q: %v", cc.Name, err)
-				mtt.Reset(30 * time.Second) // try again in 30s
-			} else {
-				f.logger.Printf("refreshed metadata for %q in %.3fs", cc.Name, time.Since(start).Seconds())
-				mtt.Reset(cc.MetaRefresh)
-			}
+			_ = refreshMeta()
 		case <-ott.C:
-			start := time.Now()
-			if err := cf.refreshOffsets(ctx, start.Add(-2*cc.OffsetRefresh)); err != nil {
-				f.logger.Printf("error refreshing offsets for %q: %v", cc.Name, err)
-				ott.Reset(30 * time.Second) // try again in 30s
-			} else {
-				f.logger.Printf("refreshed offsets for %q in %.3fs", cc.Name, time.Since(start).Seconds())
-				ott.Reset(cc.OffsetRefresh)
+			_ = refreshOffsets()
+		case req := <-refresh:
+			var err error
+			if req.meta {
+				err = refreshMeta()
+			}
+			if err == nil {
+				err = refreshOffsets()
+			}
+			if req.done != nil {
+				req.done <- err
 			}
+		case group := <-forget:
+			delete(cf.groups, group)
+		}
+	}
+}
+
+// resetTimer resets a timer which may have fired without being drained.
+func resetTimer(t *time.Timer, d time.Duration) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
 		}
 	}
+	t.Reset(d)
+}
+
+func newSaramaConfig() *sarama.Config {
+	config := sarama.NewConfig()
+	config.ClientID = "rumour"
+	config.Version = sarama.V0_10_0_0
+	return config
 }
 
 type clusterFetcher struct {
@@ -121,6 +622,11 @@ type clusterFetcher struct {
 }
 
 func (f *clusterFetcher) refreshMeta(ctx context.Context) error {
+	// pick up new brokers and topics
+	if err := f.client.RefreshMetadata(); err != nil {
+		return err
+	}
+
 	// populate brokers
 	brokers := f.client.Brokers()
 	addrs := make([]string, 0, len(brokers))
@@ -129,7 +635,8 @@ func (f *clusterFetcher) refreshMeta(ctx context.Context) error {
 	}
 	f.state.UpdateBrokers(addrs)
 
-	// query brokers for known groups
+	// query brokers for known groups, deleted groups are dropped
+	groups := make(map[string][]string, len(f.groups))
 	for _, broker := range brokers {
 		_ = broker.Open(f.client.Config())
 
@@ -157,10 +664,11 @@ func (f *clusterFetcher) refreshMeta(ctx context.Context) error {
 			return err
 		}
 
-		if err := f.extractGroupTopicAssociations(dres); err != nil {
+		if err := extractGroupTopicAssociations(groups, dres); err != nil {
 			return err
 		}
 	}
+	f.groups = groups
 	return nil
 }
 
@@ -206,7 +714,7 @@ func (f *clusterFetcher) refreshTopics(ctx context.Context) error {
 			}
 		}
 
-		offsets := make([]int64, size)
+		offsets := makeUnknownOffsets(size)
 		for _, part := range partitions {
 			off, err := f.client.GetOffset(topic, part, sarama.OffsetNewest)
 			if err != nil {
@@ -264,7 +772,7 @@ func (f *clusterFetcher) refreshGroupOffsets(ctx context.Context, group string,
 			}
 		}
 
-		offsets := make([]int64, size)
+		offsets := makeUnknownOffsets(size)
 		for part, block := range blocks {
 			offsets[int(part)] = block.Offset
 		}
@@ -273,7 +781,7 @@ func (f *clusterFetcher) refreshGroupOffsets(ctx context.Context, group string,
 	return nil
 }
 
-func (f *clusterFetcher) extractGroupTopicAssociations(res *sarama.DescribeGroupsResponse) error {
+func extractGroupTopicAssociations(groups map[string][]string, res *sarama.DescribeGroupsResponse) error {
 	for _, group := range res.Groups {
 		if group.Err != sarama.ErrNoError {
 			return group.Err
@@ -291,14 +799,22 @@ func (f *clusterFetcher) extractGroupTopicAssociations(res *sarama.DescribeGroup
 			}
 		}
 
-		f.groups[group.GroupId] = f.groups[group.GroupId][:0]
+		groups[group.GroupId] = groups[group.GroupId][:0]
 		for topic := range topics {
-			f.groups[group.GroupId] = append(f.groups[group.GroupId], topic)
+			groups[group.GroupId] = append(groups[group.GroupId], topic)
 		}
 	}
 	return nil
 }
 
+func makeUnknownOffsets(size int) []int64 {
+	offsets := make([]int64, size)
+	for i := range offsets {
+		offsets[i] = OffsetUnknown
+	}
+	return offsets
+}
+
 func isDone(ctx context.Context) bool {
 	select {
 	case <-ctx.Done():
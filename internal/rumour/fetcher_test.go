@@ -0,0 +1,235 @@
+package rumour_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/bsm/rumour/internal/rumour"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Fetcher", func() {
+	var subject *rumour.Fetcher
+	var broker *sarama.MockBroker
+	var state *rumour.State
+	var cancel context.CancelFunc
+	var done chan struct{}
+
+	ctx := context.Background()
+
+	serveLogEndOffset := func(offset int64) {
+		broker.SetHandlerByMap(map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockMetadataResponse(GinkgoT()).
+				SetBroker(broker.Addr(), broker.BrokerID()).
+				SetLeader("orders", 0, broker.BrokerID()),
+			"ListGroupsRequest": sarama.NewMockListGroupsResponse(GinkgoT()),
+			"OffsetRequest": sarama.NewMockOffsetResponse(GinkgoT()).
+				SetOffset("orders", 0, sarama.OffsetNewest, offset),
+		})
+	}
+
+	topicOffsets := func() []int64 {
+		offsets, _ := state.Cluster("main").TopicOffsets("orders")
+		return offsets
+	}
+
+	BeforeEach(func() {
+		broker = sarama.NewMockBroker(GinkgoT(), 1)
+		serveLogEndOffset(100)
+
+		var err error
+		subject, err = rumour.NewFetcher(rumour.ClusterConfig{
+			Name:          "main",
+			Brokers:       []string{broker.Addr()},
+			MetaRefresh:   time.Hour,
+			OffsetRefresh: time.Hour,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		state = rumour.NewState([]string{"main"})
+
+		var loopCtx context.Context
+		loopCtx, cancel = context.WithCancel(ctx)
+		done = make(chan struct{})
+		go func() {
+			defer close(done)
+			subject.RunLoop(loopCtx, state)
+		}()
+		Eventually(topicOffsets).Should(Equal([]int64{100}))
+	})
+
+	AfterEach(func() {
+		cancel()
+		Eventually(done).Should(BeClosed())
+		broker.Close()
+	})
+
+	It("should refresh on demand", func() {
+		serveLogEndOffset(120)
+		Expect(subject.Refresh(ctx, "main", rumour.RefreshOffsets, true)).To(Succeed())
+		Expect(topicOffsets()).To(Equal([]int64{120}))
+	})
+
+	It("should limit refreshes", func() {
+		Expect(subject.Refresh(ctx, "main", rumour.RefreshMeta, false)).To(Succeed())
+		Expect(subject.Refresh(ctx, "main", rumour.RefreshMeta, false)).To(MatchError(rumour.ErrRefreshLimited))
+		Expect(subject.Refresh(ctx, "missing", rumour.RefreshMeta, false)).To(MatchError(rumour.ErrUnknownCluster))
+	})
+
+	It("should pause and resume clusters", func() {
+		Expect(subject.Pause("main")).To(Succeed())
+		Expect(subject.Paused("main")).To(BeTrue())
+		Eventually(state.Cluster("main").Paused).Should(BeTrue())
+		Expect(subject.Refresh(ctx, "main", rumour.RefreshOffsets, true)).To(MatchError(rumour.ErrClusterPaused))
+
+		// state is retained while paused
+		serveLogEndOffset(120)
+		Expect(topicOffsets()).To(Equal([]int64{100}))
+
+		Expect(subject.Resume("main")).To(Succeed())
+		Expect(subject.Paused("main")).To(BeFalse())
+		Eventually(state.Cluster("main").Paused).Should(BeFalse())
+		Eventually(topicOffsets).Should(Equal([]int64{120}))
+
+		Expect(subject.Pause("missing")).To(MatchError(rumour.ErrUnknownCluster))
+		Expect(subject.Resume("missing")).To(MatchError(rumour.ErrUnknownCluster))
+	})
+
+	It("should add, update and remove clusters", func() {
+		added, err := subject.SetCluster(&rumour.ClusterConfig{Name: "prio", Brokers: []string{broker.Addr()}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(added).To(BeTrue())
+		Expect(state.Clusters()).To(Equal([]string{"main", "prio"}))
+		Eventually(func() []int64 {
+			offsets, _ := state.Cluster("prio").TopicOffsets("orders")
+			return offsets
+		}).Should(Equal([]int64{100}))
+
+		update := rumour.ClusterConfig{Name: "main", Brokers: []string{broker.Addr()}, MetaRefresh: time.Hour, OffsetRefresh: time.Minute}
+		Expect(subject.SetCluster(&update)).To(BeFalse())
+		Expect(subject.Clusters()).To(Equal([]rumour.ClusterConfig{
+			update,
+			{Name: "prio", Brokers: []string{broker.Addr()}, MetaRefresh: rumour.DefaultMetaRefresh, OffsetRefresh: rumour.DefaultOffsetRefresh},
+		}))
+
+		// restarts the monitor with the new config
+		serveLogEndOffset(120)
+		Eventually(topicOffsets).Should(Equal([]int64{120}))
+
+		_, err = subject.SetCluster(&rumour.ClusterConfig{Name: "other"})
+		Expect(err).To(MatchError(rumour.ErrInvalidClusterConfig))
+
+		Expect(subject.RemoveCluster("prio")).To(Succeed())
+		Expect(subject.RemoveCluster("prio")).To(MatchError(rumour.ErrUnknownCluster))
+		Expect(state.Clusters()).To(Equal([]string{"main"}))
+	})
+
+	It("should reset the state when brokers change", func() {
+		other := sarama.NewMockBroker(GinkgoT(), 2)
+		defer other.Close()
+		other.SetHandlerByMap(map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockMetadataResponse(GinkgoT()).
+				SetBroker(other.Addr(), other.BrokerID()).
+				SetLeader("payments", 0, other.BrokerID()),
+			"ListGroupsRequest": sarama.NewMockListGroupsResponse(GinkgoT()),
+			"OffsetRequest": sarama.NewMockOffsetResponse(GinkgoT()).
+				SetOffset("payments", 0, sarama.OffsetNewest, 50),
+		})
+
+		Expect(state.Cluster("main").Topics()).To(Equal([]string{"orders"}))
+		_, err := subject.SetCluster(&rumour.ClusterConfig{Name: "main", Brokers: []string{other.Addr()}})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(state.Cluster("main").Topics).Should(Equal([]string{"payments"}))
+		Expect(state.Cluster("main").Brokers()).To(Equal([]string{other.Addr()}))
+	})
+
+	It("should wait for removed clusters to stop before re-adding them", func() {
+		slow := sarama.NewMockBroker(GinkgoT(), 2)
+		defer slow.Close()
+		slow.SetLatency(200 * time.Millisecond)
+		slow.SetHandlerByMap(map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockMetadataResponse(GinkgoT()).
+				SetBroker(slow.Addr(), slow.BrokerID()).
+				SetLeader("orders", 0, slow.BrokerID()),
+			"ListGroupsRequest": sarama.NewMockListGroupsResponse(GinkgoT()),
+			"OffsetRequest": sarama.NewMockOffsetResponse(GinkgoT()).
+				SetOffset("orders", 0, sarama.OffsetNewest, 100),
+		})
+
+		sub := state.Subscribe(rumour.EventFilter{Cluster: "prio", Topic: "orders"}, 10)
+		defer sub.Close()
+
+		_, err := subject.SetCluster(&rumour.ClusterConfig{Name: "prio", Brokers: []string{slow.Addr()}})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(sub.C, 5*time.Second).Should(Receive(HaveField("Offsets", []int64{100})))
+
+		// leave a slow fetch in flight
+		Expect(subject.Refresh(ctx, "prio", rumour.RefreshOffsets, false)).To(Succeed())
+		time.Sleep(50 * time.Millisecond)
+
+		serveLogEndOffset(200)
+		Expect(subject.RemoveCluster("prio")).To(Succeed())
+		_, err = subject.SetCluster(&rumour.ClusterConfig{Name: "prio", Brokers: []string{broker.Addr()}})
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(sub.C, time.Second).Should(Receive(HaveField("Offsets", []int64{200})))
+		Consistently(sub.C, 400*time.Millisecond).ShouldNot(Receive())
+	})
+
+	It("should bound waiting for refreshes", func() {
+		slow := sarama.NewMockBroker(GinkgoT(), 2)
+		defer slow.Close()
+		slow.SetLatency(time.Second)
+		slow.SetHandlerByMap(map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockMetadataResponse(GinkgoT()).
+				SetBroker(slow.Addr(), slow.BrokerID()),
+		})
+
+		_, err := subject.SetCluster(&rumour.ClusterConfig{Name: "prio", Brokers: []string{slow.Addr()}, OffsetRefresh: 100 * time.Millisecond})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = subject.SetCluster(&rumour.ClusterConfig{Name: "lazy", Brokers: []string{slow.Addr()}})
+		Expect(err).NotTo(HaveOccurred())
+
+		// times out after the offset refresh interval
+		start := time.Now()
+		Expect(subject.Refresh(ctx, "prio", rumour.RefreshOffsets, true)).To(MatchError(rumour.ErrRefreshTimeout))
+		Expect(time.Since(start)).To(BeNumerically("<", 500*time.Millisecond))
+
+		// fails once the cluster is removed
+		errs := make(chan error, 1)
+		go func() { errs <- subject.Refresh(ctx, "lazy", rumour.RefreshOffsets, true) }()
+		Consistently(errs, 100*time.Millisecond).ShouldNot(Receive())
+		Expect(subject.RemoveCluster("lazy")).To(Succeed())
+		Eventually(errs, 500*time.Millisecond).Should(Receive(MatchError(rumour.ErrUnknownCluster)))
+	})
+
+	It("should reconfigure clusters", func() {
+		Expect(subject.Reconfigure(
+			rumour.ClusterConfig{Name: "prio", Brokers: []string{broker.Addr()}},
+		)).To(Succeed())
+		Expect(state.Clusters()).To(Equal([]string{"prio"}))
+		Expect(subject.Clusters()).To(HaveLen(1))
+
+		// retains clusters added at runtime
+		_, err := subject.SetCluster(&rumour.ClusterConfig{Name: "extra", Brokers: []string{broker.Addr()}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subject.Reconfigure(
+			rumour.ClusterConfig{Name: "main", Brokers: []string{broker.Addr()}},
+		)).To(Succeed())
+		Expect(state.Clusters()).To(Equal([]string{"extra", "main"}))
+
+		Expect(subject.Reconfigure()).NotTo(Succeed())
+		Expect(subject.Reconfigure(rumour.ClusterConfig{Name: "other"})).To(MatchError(rumour.ErrInvalidClusterConfig))
+		Expect(state.Clusters()).To(Equal([]string{"extra", "main"}))
+	})
+
+	It("should parse refresh kinds", func() {
+		Expect(rumour.ParseRefreshKind("")).To(Equal(rumour.RefreshMeta))
+		Expect(rumour.ParseRefreshKind("offsets")).To(Equal(rumour.RefreshOffsets))
+		_, err := rumour.ParseRefreshKind("all")
+		Expect(err).To(MatchError(`invalid refresh kind "all"`))
+	})
+})
@@ -0,0 +1,95 @@
+package rumour
+
+// maxHistory is the maximum number of samples retained per series.
+const maxHistory = 120
+
+type offsetSample struct {
+	Timestamp int64
+	Offsets   []int64
+}
+
+type offsetHistory []offsetSample
+
+func (h offsetHistory) append(timestamp int64, offsets []int64) offsetHistory {
+	if n := len(h); n != 0 && h[n-1].Timestamp > timestamp {
+		return h
+	}
+	if len(h) == maxHistory {
+		copy(h, h[1:])
+		h = h[:maxHistory-1]
+	}
+	return append(h, offsetSample{Timestamp: timestamp, Offsets: offsets})
+}
+
+// timeLag estimates for how long (in seconds) a consumer at the given
+// offsets has been behind. It finds the earliest sample at which the
+// log end offset of a partition moved past the consumer offset and returns
+// the largest delay across all partitions. When the consumer is behind the
+// oldest known sample, the age of that sample is returned as a lower bound.
+func (h offsetHistory) timeLag(now int64, offsets []int64) int64 {
+	if len(h) == 0 {
+		return 0
+	}
+
+	latest := h[len(h)-1].Offsets
+	var lag int64
+	for part, max := range latest {
+		var off int64
+		if part < len(offsets) {
+			off = offsets[part]
+		}
+		if off >= max {
+			continue
+		}
+
+		for _, sample := range h {
+			if part < len(sample.Offsets) && sample.Offsets[part] > off {
+				if d := now - sample.Timestamp; d > lag {
+					lag = d
+				}
+				break
+			}
+		}
+	}
+	return lag
+}
+
+// LagSample is a point-in-time total lag measurement.
+type LagSample struct {
+	Timestamp int64 `json:"timestamp"`
+	Lag       int64 `json:"lag"`
+}
+
+// trendSamples is the number of recent samples used to calculate trends.
+const trendSamples = 10
+
+type lagHistory []LagSample
+
+func (h lagHistory) append(timestamp, lag int64) lagHistory {
+	if n := len(h); n != 0 && h[n-1].Timestamp >= timestamp {
+		if h[n-1].Timestamp == timestamp {
+			h[n-1].Lag = lag
+		}
+		return h
+	}
+	if len(h) == maxHistory {
+		copy(h, h[1:])
+		h = h[:maxHistory-1]
+	}
+	return append(h, LagSample{Timestamp: timestamp, Lag: lag})
+}
+
+// growth returns the change in lag across the most recent samples.
+// Returns false if there is not enough history.
+func (h lagHistory) growth() (int64, bool) {
+	n := len(h)
+	if n < 2 {
+		return 0, false
+	}
+
+	first := n - trendSamples
+	if first < 0 {
+		first = 0
+	}
+	return h[n-1].Lag - h[first].Lag, true
+}
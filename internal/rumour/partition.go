@@ -0,0 +1,194 @@
+package rumour
+
+import "sort"
+
+// OffsetUnknown marks unknown offsets, i.e. partitions that could not be
+// fetched or have no committed offset.
+const OffsetUnknown int64 = -1
+
+// TopicPartition contains the log end offset of a topic partition. Unknown
+// offsets are nil.
+type TopicPartition struct {
+	Partition    int    `json:"partition"`
+	LogEndOffset *int64 `json:"log_end_offset"`
+}
+
+// PartitionOffset contains explicit consumer partition offsets. Unknown values
+// are nil.
+type PartitionOffset struct {
+	Partition    int    `json:"partition"`
+	Offset       *int64 `json:"offset"`
+	LogEndOffset *int64 `json:"log_end_offset"`
+	Lag          *int64 `json:"lag"`
+}
+
+// ConsumerTopicPartitions contains partition offsets of a consumer group
+// on a topic.
+type ConsumerTopicPartitions struct {
+	Topic      string            `json:"topic,omitempty"`
+	Group      string            `json:"consumer,omitempty"`
+	Timestamp  int64             `json:"timestamp"`
+	Partitions []PartitionOffset `json:"partitions"`
+	Summary    LagSummary        `json:"summary"`
+}
+
+// ConsumerPartitions contains the partition offsets of a consumer group.
+type ConsumerPartitions struct {
+	Group   string                    `json:"consumer"`
+	Topics  []ConsumerTopicPartitions `json:"topics"`
+	Summary LagSummary                `json:"summary"`
+}
+
+// FilterTopics returns a copy of the consumer partitions, limited to the
+// topics accepted by fn. The summary is recalculated from the remaining
+// topics.
+func (c ConsumerPartitions) FilterTopics(fn func(topic string) bool) ConsumerPartitions {
+	res := ConsumerPartitions{Group: c.Group, Topics: make([]ConsumerTopicPartitions, 0, len(c.Topics))}
+	for _, ct := range c.Topics {
+		if fn(ct.Topic) {
+			res.Topics = append(res.Topics, ct)
+			res.Summary.add(ct.Topic, ct.Summary)
+		}
+	}
+	return res
+}
+
+// TopicPartitions returns the partition offsets of a topic.
+func (s *ClusterState) TopicPartitions(topic string) ([]TopicPartition, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	maxima, ok := s.topics[topic]
+	if !ok {
+		return nil, false
+	}
+
+	res := make([]TopicPartition, len(maxima))
+	for i, max := range maxima {
+		res[i] = TopicPartition{Partition: i, LogEndOffset: knownOffset(max)}
+	}
+	return res, true
+}
+
+// ConsumerPartitions returns the partition offsets of a consumer group.
+func (s *ClusterState) ConsumerPartitions(group string) (ConsumerPartitions, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.consumerPartitions(group)
+}
+
+// AllConsumerPartitions returns the partition offsets of all consumer groups,
+// sorted by group. The result is a consistent snapshot of the cluster state.
+func (s *ClusterState) AllConsumerPartitions() []ConsumerPartitions {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := make([]ConsumerPartitions, 0, len(s.consumers))
+	for group := range s.consumers {
+		cp, _ := s.consumerPartitions(group)
+		res = append(res, cp)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Group < res[j].Group })
+	return res
+}
+
+// TopicConsumerPartitions returns the partition offsets of all consumer
+// groups reading a topic, sorted by group.
+func (s *ClusterState) TopicConsumerPartitions(topic string) ([]ConsumerTopicPartitions, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	maxima, ok := s.topics[topic]
+	if !ok {
+		return nil, false
+	}
+
+	res := make([]ConsumerTopicPartitions, 0, len(s.readers[topic]))
+	for group := range s.readers[topic] {
+		cos := s.consumers[group][topic]
+		parts, summary := calcPartitionOffsets(maxima, cos.Offsets)
+		res = append(res, ConsumerTopicPartitions{
+			Group:      group,
+			Timestamp:  cos.Timestamp,
+			Partitions: parts,
+			Summary:    summary,
+		})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Group < res[j].Group })
+	return res, true
+}
+
+func (s *ClusterState) consumerPartitions(group string) (ConsumerPartitions, bool) {
+	topics, ok := s.consumers[group]
+	if !ok {
+		return ConsumerPartitions{}, false
+	}
+
+	res := ConsumerPartitions{Group: group, Topics: make([]ConsumerTopicPartitions, 0, len(topics))}
+	for topic, cos := range topics {
+		if maxima, ok := s.topics[topic]; ok {
+			parts, summary := calcPartitionOffsets(maxima, cos.Offsets)
+			res.Topics = append(res.Topics, ConsumerTopicPartitions{
+				Topic:      topic,
+				Timestamp:  cos.Timestamp,
+				Partitions: parts,
+				Summary:    summary,
+			})
+		}
+	}
+	sort.Slice(res.Topics, func(i, j int) bool { return res.Topics[i].Topic < res.Topics[j].Topic })
+
+	for _, ct := range res.Topics {
+		res.Summary.add(ct.Topic, ct.Summary)
+	}
+	return res, true
+}
+
+// calcPartitionOffsets calculates explicit partition offsets. Unlike
+// calcConsumerOffsets, the lag of partitions with unknown offsets is not
+// included in the summary.
+func calcPartitionOffsets(maxima, offsets []int64) ([]PartitionOffset, LagSummary) {
+	size := len(maxima)
+	if len(offsets) > size {
+		size = len(offsets)
+	}
+
+	res := make([]PartitionOffset, size)
+	sum := LagSummary{Partitions: size}
+	for i := range res {
+		po := PartitionOffset{Partition: i}
+		if i < len(maxima) {
+			po.LogEndOffset = knownOffset(maxima[i])
+		}
+		if i < len(offsets) {
+			po.Offset = knownOffset(offsets[i])
+		}
+		if po.Offset == nil {
+			sum.Uncommitted++
+		}
+
+		if po.Offset != nil && po.LogEndOffset != nil {
+			var lag int64
+			if *po.Offset < *po.LogEndOffset {
+				lag = *po.LogEndOffset - *po.Offset
+			}
+			po.Lag = &lag
+
+			sum.TotalLag += lag
+			if lag > sum.MaxLag {
+				sum.MaxLag = lag
+				sum.MaxLagPartition = i
+			}
+		}
+		res[i] = po
+	}
+	return res, sum
+}
+
+func knownOffset(off int64) *int64 {
+	if off < 0 {
+		return nil
+	}
+	return &off
+}
@@ -0,0 +1,85 @@
+package rumour_test
+
+import (
+	"github.com/bsm/rumour/internal/rumour"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("ClusterState partitions", func() {
+	var subject *rumour.ClusterState
+
+	offset := func(n int64) *int64 { return &n }
+
+	BeforeEach(func() {
+		subject = rumour.NewClusterState()
+		subject.UpdateTopic("one-topic", []int64{125, rumour.OffsetUnknown, 117})
+		subject.UpdateConsumerOffsets("csmx", "one-topic", 1515151515, []int64{120, 100, rumour.OffsetUnknown})
+		subject.UpdateConsumerOffsets("csmy", "one-topic", 1515151516, []int64{125, 101})
+	})
+
+	It("should read topic partitions", func() {
+		partitions, ok := subject.TopicPartitions("one-topic")
+		Expect(ok).To(BeTrue())
+		Expect(partitions).To(Equal([]rumour.TopicPartition{
+			{Partition: 0, LogEndOffset: offset(125)},
+			{Partition: 1},
+			{Partition: 2, LogEndOffset: offset(117)},
+		}))
+
+		_, ok = subject.TopicPartitions("missing")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should read consumer partitions", func() {
+		cp, ok := subject.ConsumerPartitions("csmx")
+		Expect(ok).To(BeTrue())
+		Expect(cp).To(Equal(rumour.ConsumerPartitions{
+			Group: "csmx",
+			Topics: []rumour.ConsumerTopicPartitions{{
+				Topic:     "one-topic",
+				Timestamp: 1515151515,
+				Partitions: []rumour.PartitionOffset{
+					{Partition: 0, Offset: offset(120), LogEndOffset: offset(125), Lag: offset(5)},
+					{Partition: 1, Offset: offset(100)},
+					{Partition: 2, LogEndOffset: offset(117)},
+				},
+				Summary: rumour.LagSummary{TotalLag: 5, MaxLag: 5, Partitions: 3, Uncommitted: 1},
+			}},
+			Summary: rumour.LagSummary{TotalLag: 5, MaxLag: 5, MaxLagTopic: "one-topic", Partitions: 3, Uncommitted: 1},
+		}))
+
+		_, ok = subject.ConsumerPartitions("missing")
+		Expect(ok).To(BeFalse())
+
+		Expect(cp.FilterTopics(func(string) bool { return false })).To(Equal(rumour.ConsumerPartitions{
+			Group:  "csmx",
+			Topics: []rumour.ConsumerTopicPartitions{},
+		}))
+
+		all := subject.AllConsumerPartitions()
+		Expect(all).To(HaveLen(2))
+		Expect(all[0]).To(Equal(cp))
+		Expect(all[1].Group).To(Equal("csmy"))
+	})
+
+	It("should read topic consumer partitions", func() {
+		consumers, ok := subject.TopicConsumerPartitions("one-topic")
+		Expect(ok).To(BeTrue())
+		Expect(consumers).To(HaveLen(2))
+		Expect(consumers[1]).To(Equal(rumour.ConsumerTopicPartitions{
+			Group:     "csmy",
+			Timestamp: 1515151516,
+			Partitions: []rumour.PartitionOffset{
+				{Partition: 0, Offset: offset(125), LogEndOffset: offset(125), Lag: offset(0)},
+				{Partition: 1, Offset: offset(101)},
+				{Partition: 2, LogEndOffset: offset(117)},
+			},
+			Summary: rumour.LagSummary{Partitions: 3, Uncommitted: 1},
+		}))
+
+		_, ok = subject.TopicConsumerPartitions("missing")
+		Expect(ok).To(BeFalse())
+	})
+})
@@ -0,0 +1,171 @@
+package rumour
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// RankBy determines the order of ranked consumer lags.
+type RankBy string
+
+// Supported rankings.
+const (
+	RankByLag     RankBy = "lag"
+	RankByTimeLag RankBy = "time_lag"
+	RankByGrowth  RankBy = "growth"
+)
+
+// ParseRankBy parses a ranking. Empty strings default to RankByLag.
+func ParseRankBy(s string) (RankBy, error) {
+	switch by := RankBy(s); by {
+	case "":
+		return RankByLag, nil
+	case RankByLag, RankByTimeLag, RankByGrowth:
+		return by, nil
+	}
+	return "", fmt.Errorf("invalid ranking %q", s)
+}
+
+// Lag trends.
+const (
+	TrendIncreasing = "increasing"
+	TrendDecreasing = "decreasing"
+	TrendStable     = "stable"
+)
+
+// ConsumerLag contains the lag of a consumer group on a topic.
+type ConsumerLag struct {
+	Cluster   string     `json:"cluster,omitempty"`
+	Group     string     `json:"consumer"`
+	Topic     string     `json:"topic"`
+	Timestamp int64      `json:"timestamp"`
+	Summary   LagSummary `json:"summary"`
+	TimeLag   int64      `json:"time_lag"`
+	Growth    int64      `json:"growth"`
+	Trend     string     `json:"trend,omitempty"`
+}
+
+type consumerLags struct {
+	s  []ConsumerLag
+	by RankBy
+}
+
+func (p consumerLags) Len() int      { return len(p.s) }
+func (p consumerLags) Swap(i, j int) { p.s[i], p.s[j] = p.s[j], p.s[i] }
+func (p consumerLags) Less(i, j int) bool {
+	a, b := &p.s[i], &p.s[j]
+
+	var x, y int64
+	switch p.by {
+	case RankByTimeLag:
+		x, y = a.TimeLag, b.TimeLag
+	case RankByGrowth:
+		x, y = a.Growth, b.Growth
+	}
+	if x != y {
+		return x > y
+	}
+	if a.Summary.TotalLag != b.Summary.TotalLag {
+		return a.Summary.TotalLag > b.Summary.TotalLag
+	}
+	if a.Cluster != b.Cluster {
+		return a.Cluster < b.Cluster
+	}
+	if a.Group != b.Group {
+		return a.Group < b.Group
+	}
+	return a.Topic < b.Topic
+}
+
+// RankConsumerLags sorts lags (in place) and returns the top entries. A
+// limit <= 0 returns all entries.
+func RankConsumerLags(lags []ConsumerLag, by RankBy, limit int) []ConsumerLag {
+	sort.Sort(consumerLags{s: lags, by: by})
+	if limit > 0 && len(lags) > limit {
+		lags = lags[:limit]
+	}
+	return lags
+}
+
+// --------------------------------------------------------------------
+
+// ConsumerLags returns the lag of every consumer group and topic pair.
+func (s *ClusterState) ConsumerLags() []ConsumerLag {
+	now := time.Now().Unix()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := make([]ConsumerLag, 0, len(s.consumers))
+	for group, topics := range s.consumers {
+		for topic, cos := range topics {
+			maxima, ok := s.topics[topic]
+			if !ok {
+				continue
+			}
+
+			_, summary := calcConsumerOffsets(maxima, cos.Offsets)
+			lag := ConsumerLag{
+				Group:     group,
+				Topic:     topic,
+				Timestamp: cos.Timestamp,
+				Summary:   summary,
+				TimeLag:   s.history[topic].timeLag(now, cos.Offsets),
+			}
+			if growth, ok := cos.Lags.growth(); ok {
+				lag.Growth = growth
+				lag.Trend = trendOf(growth)
+			}
+			res = append(res, lag)
+		}
+	}
+	return res
+}
+
+// LagFilter selects consumer lags by cluster, consumer group and topic.
+type LagFilter func(cluster, group, topic string) bool
+
+// TopConsumers returns the top consumer lags of the cluster. A nil filter
+// selects all lags.
+func (s *ClusterState) TopConsumers(by RankBy, limit int, filter LagFilter) []ConsumerLag {
+	return RankConsumerLags(s.filterConsumerLags(nil, filter), by, limit)
+}
+
+// TopConsumers returns the top consumer lags across all clusters. A nil
+// filter selects all lags.
+func (s *State) TopConsumers(by RankBy, limit int, filter LagFilter) []ConsumerLag {
+	res := make([]ConsumerLag, 0)
+	for _, name := range s.Clusters() {
+		cs := s.Cluster(name)
+		if cs == nil { // removed in the meantime
+			continue
+		}
+		res = cs.filterConsumerLags(res, filter)
+	}
+	return RankConsumerLags(res, by, limit)
+}
+
+// filterConsumerLags appends the selected lags of the cluster to dst.
+func (s *ClusterState) filterConsumerLags(dst []ConsumerLag, filter LagFilter) []ConsumerLag {
+	if dst == nil {
+		dst = make([]ConsumerLag, 0)
+	}
+	for _, lag := range s.ConsumerLags() {
+		if filter == nil || filter(s.name, lag.Group, lag.Topic) {
+			lag.Cluster = s.name
+			dst = append(dst, lag)
+		}
+	}
+	return dst
+}
+
+func trendOf(growth int64) string {
+	switch {
+	case growth > 0:
+		return TrendIncreasing
+	case growth < 0:
+		return TrendDecreasing
+	}
+	return TrendStable
+}
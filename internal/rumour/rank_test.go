@@ -0,0 +1,105 @@
+package rumour_test
+
+import (
+	"github.com/bsm/rumour/internal/rumour"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("TopConsumers", func() {
+	var subject *rumour.State
+
+	BeforeEach(func() {
+		subject = rumour.NewState([]string{"main", "prio"})
+
+		main := subject.Cluster("main")
+		main.UpdateTopic("orders", []int64{100, 100})
+		main.UpdateTopic("events", []int64{500})
+		main.UpdateConsumerOffsets("csmx", "orders", 1515151515, []int64{90, 80})
+		main.UpdateConsumerOffsets("csmx", "orders", 1515151545, []int64{95, 95})
+		main.UpdateConsumerOffsets("csmy", "events", 1515151515, []int64{480})
+		main.UpdateConsumerOffsets("csmy", "events", 1515151545, []int64{470})
+
+		prio := subject.Cluster("prio")
+		prio.UpdateTopic("orders", []int64{100, 100})
+		prio.UpdateConsumerOffsets("csmx", "orders", 1515151515, []int64{50, 60})
+	})
+
+	It("should parse rankings", func() {
+		Expect(rumour.ParseRankBy("")).To(Equal(rumour.RankByLag))
+		Expect(rumour.ParseRankBy("growth")).To(Equal(rumour.RankByGrowth))
+		_, err := rumour.ParseRankBy("bad")
+		Expect(err).To(MatchError(`invalid ranking "bad"`))
+	})
+
+	It("should rank by lag", func() {
+		top := subject.Cluster("main").TopConsumers(rumour.RankByLag, 10, nil)
+		for i := range top {
+			Expect(top[i].TimeLag).To(BeNumerically("<=", 1))
+			top[i].TimeLag = 0
+		}
+		Expect(top).To(Equal([]rumour.ConsumerLag{
+			{
+				Cluster:   "main",
+				Group:     "csmy",
+				Topic:     "events",
+				Timestamp: 1515151545,
+				Summary:   rumour.LagSummary{TotalLag: 30, MaxLag: 30, Partitions: 1},
+				Growth:    10,
+				Trend:     rumour.TrendIncreasing,
+			},
+			{
+				Cluster:   "main",
+				Group:     "csmx",
+				Topic:     "orders",
+				Timestamp: 1515151545,
+				Summary:   rumour.LagSummary{TotalLag: 10, MaxLag: 5, Partitions: 2},
+				Growth:    -20,
+				Trend:     rumour.TrendDecreasing,
+			},
+		}))
+	})
+
+	It("should rank by growth across clusters", func() {
+		top := subject.TopConsumers(rumour.RankByGrowth, 2, nil)
+		Expect(top).To(HaveLen(2))
+		Expect(top[0].Cluster).To(Equal("main"))
+		Expect(top[0].Group).To(Equal("csmy"))
+		Expect(top[1].Cluster).To(Equal("prio"))
+		Expect(top[1].Group).To(Equal("csmx"))
+		Expect(top[1].Trend).To(BeEmpty())
+
+		top = subject.TopConsumers(rumour.RankByLag, 0, nil)
+		Expect(top).To(HaveLen(3))
+		Expect(top[0].Cluster).To(Equal("prio"))
+		Expect(top[0].Summary.TotalLag).To(Equal(int64(90)))
+	})
+
+	It("should filter", func() {
+		top := subject.TopConsumers(rumour.RankByLag, 0, func(cluster, group, topic string) bool {
+			return cluster == "main" || topic != "orders"
+		})
+		Expect(top).To(HaveLen(2))
+		Expect(top[0].Group).To(Equal("csmy"))
+		Expect(top[1].Cluster).To(Equal("main"))
+
+		top = subject.Cluster("main").TopConsumers(rumour.RankByLag, 0, func(_, group, _ string) bool {
+			return group == "csmx"
+		})
+		Expect(top).To(HaveLen(1))
+		Expect(top[0].Topic).To(Equal("orders"))
+	})
+
+	It("should track lag history", func() {
+		history, ok := subject.Cluster("main").ConsumerLagHistory("csmx", "orders")
+		Expect(ok).To(BeTrue())
+		Expect(history).To(Equal([]rumour.LagSample{
+			{Timestamp: 1515151515, Lag: 30},
+			{Timestamp: 1515151545, Lag: 10},
+		}))
+
+		_, ok = subject.Cluster("main").ConsumerLagHistory("csmx", "missing")
+		Expect(ok).To(BeFalse())
+	})
+})
@@ -3,11 +3,14 @@ package rumour
 import (
 	"sort"
 	"sync"
+	"time"
 )
 
 // State maintains all state
 type State struct {
 	clusters map[string]*ClusterState
+	events   *eventHub
+	mu       sync.RWMutex
 }
 
 // NewState inits a state.
@@ -15,15 +18,18 @@ func NewState(clusters []string) *State {
 	if len(clusters) == 0 {
 		clusters = []string{"default"}
 	}
-	sub := make(map[string]*ClusterState, len(clusters))
+	s := &State{clusters: make(map[string]*ClusterState, len(clusters)), events: newEventHub()}
 	for _, name := range clusters {
-		sub[name] = NewClusterState()
+		s.AddCluster(name)
 	}
-	return &State{clusters: sub}
+	return s
 }
 
 // Clusters returns the cluster names.
 func (s *State) Clusters() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	names := make([]string, 0, len(s.clusters))
 	for name := range s.clusters {
 		names = append(names, name)
@@ -35,9 +41,50 @@ func (s *State) Clusters() []string {
 
 // Cluster returns state by name.
 func (s *State) Cluster(name string) *ClusterState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	return s.clusters[name]
 }
 
+// AddCluster adds a cluster and returns its state. The existing state is
+// returned if the cluster is already known.
+func (s *State) AddCluster(name string) *ClusterState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cs, ok := s.clusters[name]; ok {
+		return cs
+	}
+
+	cs := NewClusterState()
+	cs.name = name
+	cs.events = s.events
+	s.clusters[name] = cs
+	return cs
+}
+
+// RemoveCluster removes a cluster and its state. It returns false if the
+// cluster is unknown.
+func (s *State) RemoveCluster(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.clusters[name]; !ok {
+		return false
+	}
+	delete(s.clusters, name)
+	return true
+}
+
+// Subscribe subscribes to state change events matching the filter. Up to
+// size events are buffered, further events are dropped until the subscriber
+// catches up and signalled via the Lagged channel. Subscriptions must be
+// closed after use.
+func (s *State) Subscribe(filter EventFilter, size int) *Subscription {
+	return s.events.subscribe(filter, size)
+}
+
 // --------------------------------------------------------------------
 
 // ConsumerTopic maintains group topic info.
@@ -45,6 +92,7 @@ type ConsumerTopic struct {
 	Topic     string           `json:"topic"`
 	Timestamp int64            `json:"timestamp"`
 	Offsets   []ConsumerOffset `json:"offsets"`
+	Summary   LagSummary       `json:"summary"`
 }
 
 type consumerTopics []ConsumerTopic
@@ -53,25 +101,94 @@ func (p consumerTopics) Len() int           { return len(p) }
 func (p consumerTopics) Less(i, j int) bool { return p[i].Topic < p[j].Topic }
 func (p consumerTopics) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
 
+// Consumer contains the topics of a consumer group.
+type Consumer struct {
+	Group   string          `json:"consumer"`
+	Topics  []ConsumerTopic `json:"topics"`
+	Summary LagSummary      `json:"summary"`
+}
+
+// FilterTopics returns a copy of the consumer, limited to the topics
+// accepted by fn. The summary is recalculated from the remaining topics.
+func (c Consumer) FilterTopics(fn func(topic string) bool) Consumer {
+	res := Consumer{Group: c.Group, Topics: make([]ConsumerTopic, 0, len(c.Topics))}
+	for _, ct := range c.Topics {
+		if fn(ct.Topic) {
+			res.Topics = append(res.Topics, ct)
+		}
+	}
+	res.Summary = summarizeConsumerTopics(res.Topics)
+	return res
+}
+
+// LagSummary contains aggregated lag numbers.
+type LagSummary struct {
+	TotalLag        int64  `json:"total_lag"`
+	MaxLag          int64  `json:"max_lag"`
+	MaxLagTopic     string `json:"max_lag_topic,omitempty"`
+	MaxLagPartition int    `json:"max_lag_partition"`
+	Partitions      int    `json:"partitions"`
+	Uncommitted     int    `json:"uncommitted_partitions"`
+}
+
+// add aggregates the summary of a topic.
+func (s *LagSummary) add(topic string, sum LagSummary) {
+	if sum.MaxLag > s.MaxLag || s.MaxLagTopic == "" {
+		s.MaxLag = sum.MaxLag
+		s.MaxLagTopic = topic
+		s.MaxLagPartition = sum.MaxLagPartition
+	}
+	s.TotalLag += sum.TotalLag
+	s.Partitions += sum.Partitions
+	s.Uncommitted += sum.Uncommitted
+}
+
+func summarizeConsumerTopics(topics []ConsumerTopic) LagSummary {
+	var res LagSummary
+	for _, ct := range topics {
+		res.add(ct.Topic, ct.Summary)
+	}
+	return res
+}
+
+// TopicConsumer contains the offsets of a consumer group on a topic.
+type TopicConsumer struct {
+	Group     string           `json:"consumer"`
+	Timestamp int64            `json:"timestamp"`
+	Offsets   []ConsumerOffset `json:"offsets"`
+	Summary   LagSummary       `json:"summary"`
+}
+
 // ConsumerOffset maintains partition offsets for a consumer.
 type ConsumerOffset struct {
 	Offset int64 `json:"offset"`
 	Lag    int64 `json:"lag"`
 }
 
-func calcConsumerOffsets(maxima, offsets []int64) []ConsumerOffset {
+func calcConsumerOffsets(maxima, offsets []int64) ([]ConsumerOffset, LagSummary) {
 	res := make([]ConsumerOffset, len(maxima))
+	sum := LagSummary{Partitions: len(maxima)}
 	for i, max := range maxima {
 		var off int64
 		if i < len(offsets) {
 			off = offsets[i]
 			res[i].Offset = off
 		}
+		if i >= len(offsets) || off < 0 {
+			sum.Uncommitted++
+			off = 0 // lag of unknown offsets is relative to zero
+		}
 		if off < max {
 			res[i].Lag = max - off
 		}
+
+		sum.TotalLag += res[i].Lag
+		if res[i].Lag > sum.MaxLag {
+			sum.MaxLag = res[i].Lag
+			sum.MaxLagPartition = i
+		}
 	}
-	return res
+	return res, sum
 }
 
 // --------------------------------------------------------------------
@@ -79,21 +196,32 @@ func calcConsumerOffsets(maxima, offsets []int64) []ConsumerOffset {
 type consumerOffsetState struct {
 	Offsets   []int64
 	Timestamp int64
+	Lags      lagHistory
 }
 
 // ClusterState maintains cluster state.
 type ClusterState struct {
 	brokers   []string
 	topics    map[string][]int64
+	history   map[string]offsetHistory
 	consumers map[string]map[string]consumerOffsetState
+	readers   map[string]map[string]struct{} // topic -> groups index
+	revision  uint64
+	modified  time.Time
+	paused    bool
 	mu        sync.RWMutex
+
+	name   string
+	events *eventHub
 }
 
 // NewClusterState inits a cluster state.
 func NewClusterState() *ClusterState {
 	return &ClusterState{
 		topics:    make(map[string][]int64),
+		history:   make(map[string]offsetHistory),
 		consumers: make(map[string]map[string]consumerOffsetState),
+		readers:   make(map[string]map[string]struct{}),
 	}
 }
 
@@ -108,9 +236,13 @@ func (s *ClusterState) Brokers() []string {
 	return addrs
 }
 
-// UpdateBrokers updates brokers addresses.
+// UpdateBrokers updates brokers addresses. The revision is only incremented
+// if the addresses have changed.
 func (s *ClusterState) UpdateBrokers(brokers []string) {
 	s.mu.Lock()
+	if !sameBrokers(s.brokers, brokers) {
+		s.touch()
+	}
 	s.brokers = brokers
 	s.mu.Unlock()
 }
@@ -137,11 +269,20 @@ func (s *ClusterState) TopicOffsets(topic string) ([]int64, bool) {
 	return offsets, ok
 }
 
-// UpdateTopic updates topic offsets.
+// UpdateTopic updates topic offsets. The revision is only incremented if
+// the offsets have changed.
 func (s *ClusterState) UpdateTopic(name string, offsets []int64) {
+	now := time.Now().Unix()
+
 	s.mu.Lock()
+	if prev, ok := s.topics[name]; !ok || !sameOffsets(prev, offsets) {
+		s.touch()
+	}
 	s.topics[name] = offsets
+	s.history[name] = s.history[name].append(now, offsets)
 	s.mu.Unlock()
+
+	s.publish(Event{Type: EventTopicOffsets, Topic: name, Timestamp: now, Offsets: offsets})
 }
 
 // ConsumerGroups returns consumer group names.
@@ -162,14 +303,80 @@ func (s *ClusterState) ConsumerTopics(group string) ([]ConsumerTopic, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	return s.consumerTopics(group)
+}
+
+// ConsumerOffsets returns the committed offsets of a consumer group by topic.
+// Unlike ConsumerTopics, it retains OffsetUnknown for partitions without a
+// committed offset.
+func (s *ClusterState) ConsumerOffsets(group string) (map[string][]int64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	topics, ok := s.consumers[group]
+	if !ok {
+		return nil, false
+	}
+
+	res := make(map[string][]int64, len(topics))
+	for topic, cos := range topics {
+		res[topic] = append([]int64(nil), cos.Offsets...)
+	}
+	return res, true
+}
+
+// Consumer returns the topics and the summary of a consumer group. The
+// result is a consistent snapshot of the cluster state.
+func (s *ClusterState) Consumer(group string) (Consumer, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	topics, ok := s.consumerTopics(group)
+	if !ok {
+		return Consumer{}, false
+	}
+	return Consumer{Group: group, Topics: topics, Summary: summarizeConsumerTopics(topics)}, true
+}
+
+// Consumers returns the topics of all consumer groups, sorted by group.
+// The result is a consistent snapshot of the cluster state.
+func (s *ClusterState) Consumers() []Consumer {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := make([]Consumer, 0, len(s.consumers))
+	for group := range s.consumers {
+		topics, _ := s.consumerTopics(group)
+		res = append(res, Consumer{Group: group, Topics: topics, Summary: summarizeConsumerTopics(topics)})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Group < res[j].Group })
+	return res
+}
+
+// ConsumerSummary returns the aggregated lag of a consumer group across
+// all its topics.
+func (s *ClusterState) ConsumerSummary(group string) (LagSummary, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	topics, ok := s.consumerTopics(group)
+	if !ok {
+		return LagSummary{}, false
+	}
+	return summarizeConsumerTopics(topics), true
+}
+
+func (s *ClusterState) consumerTopics(group string) ([]ConsumerTopic, bool) {
 	if topics, ok := s.consumers[group]; ok {
 		res := make([]ConsumerTopic, 0, len(topics))
 		for topic, cos := range topics {
 			if maxima, ok := s.topics[topic]; ok {
+				offsets, summary := calcConsumerOffsets(maxima, cos.Offsets)
 				res = append(res, ConsumerTopic{
 					Topic:     topic,
 					Timestamp: cos.Timestamp,
-					Offsets:   calcConsumerOffsets(maxima, cos.Offsets),
+					Offsets:   offsets,
+					Summary:   summary,
 				})
 			}
 		}
@@ -179,23 +386,128 @@ func (s *ClusterState) ConsumerTopics(group string) ([]ConsumerTopic, bool) {
 	return nil, false
 }
 
+// ConsumerTimeLag estimates how far (in time) a consumer group is behind on
+// a topic, based on the recent history of topic offsets.
+func (s *ClusterState) ConsumerTimeLag(group, topic string) (time.Duration, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cos, ok := s.consumers[group][topic]
+	if !ok {
+		return 0, false
+	}
+	secs := s.history[topic].timeLag(time.Now().Unix(), cos.Offsets)
+	return time.Duration(secs) * time.Second, true
+}
+
+// ConsumerLagHistory returns the recent total lag history of a consumer
+// group on a topic.
+func (s *ClusterState) ConsumerLagHistory(group, topic string) ([]LagSample, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cos, ok := s.consumers[group][topic]
+	if !ok {
+		return nil, false
+	}
+
+	res := make([]LagSample, len(cos.Lags))
+	copy(res, cos.Lags)
+	return res, true
+}
+
+// TopicConsumers returns the consumer groups reading a topic, sorted by group.
+func (s *ClusterState) TopicConsumers(topic string) ([]TopicConsumer, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	maxima, ok := s.topics[topic]
+	if !ok {
+		return nil, false
+	}
+
+	res := make([]TopicConsumer, 0, len(s.readers[topic]))
+	for group := range s.readers[topic] {
+		cos := s.consumers[group][topic]
+		offsets, summary := calcConsumerOffsets(maxima, cos.Offsets)
+		res = append(res, TopicConsumer{
+			Group:     group,
+			Timestamp: cos.Timestamp,
+			Offsets:   offsets,
+			Summary:   summary,
+		})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Group < res[j].Group })
+	return res, true
+}
+
+// TopicLags returns the highest total lag of any consumer group reading a
+// topic, by topic. Topics without consumer groups are omitted.
+func (s *ClusterState) TopicLags() map[string]int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := make(map[string]int64, len(s.readers))
+	for topic, groups := range s.readers {
+		maxima, ok := s.topics[topic]
+		if !ok {
+			continue
+		}
+
+		var lag int64
+		for group := range groups {
+			_, sum := calcConsumerOffsets(maxima, s.consumers[group][topic].Offsets)
+			if sum.TotalLag > lag {
+				lag = sum.TotalLag
+			}
+		}
+		res[topic] = lag
+	}
+	return res
+}
+
 // UpdateConsumerOffsets updates consumer offsets.
 func (s *ClusterState) UpdateConsumerOffsets(group, topic string, timestamp int64, offsets []int64) {
+	var events []Event
+	defer func() { s.publish(events...) }()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	topics, ok := s.consumers[group]
 	if !ok {
 		topics = make(map[string]consumerOffsetState)
+		events = append(events, Event{Type: EventGroupAdded, Group: group, Timestamp: timestamp})
 	}
-	if timestamp >= topics[topic].Timestamp {
-		topics[topic] = consumerOffsetState{Offsets: offsets, Timestamp: timestamp}
+	if prev := topics[topic]; timestamp >= prev.Timestamp {
+		ev := Event{Type: EventConsumerOffsets, Group: group, Topic: topic, Timestamp: timestamp, Offsets: offsets}
+		lags := prev.Lags
+		if maxima, ok := s.topics[topic]; ok {
+			_, sum := calcConsumerOffsets(maxima, offsets)
+			lags = lags.append(timestamp, sum.TotalLag)
+			ev.Summary = &sum
+		}
+		topics[topic] = consumerOffsetState{Offsets: offsets, Timestamp: timestamp, Lags: lags}
+		events = append(events, ev)
+		s.touch()
 	}
 	s.consumers[group] = topics
+
+	groups, ok := s.readers[topic]
+	if !ok {
+		groups = make(map[string]struct{})
+		s.readers[topic] = groups
+	}
+	groups[group] = struct{}{}
 }
 
 // ExpireConsumerGroups removes consumer groups that have not updated since timestamp.
 func (s *ClusterState) ExpireConsumerGroups(timestamp int64) {
+	var events []Event
+	defer func() { s.publish(events...) }()
+
+	now := time.Now().Unix()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -203,10 +515,153 @@ func (s *ClusterState) ExpireConsumerGroups(timestamp int64) {
 		for topic, state := range topics {
 			if state.Timestamp < timestamp {
 				delete(topics, topic)
+				s.removeReader(topic, group)
+				s.touch()
 			}
 		}
 		if len(topics) == 0 {
 			delete(s.consumers, group)
+			events = append(events, Event{Type: EventGroupExpired, Group: group, Timestamp: now})
+		}
+	}
+}
+
+// DeleteConsumerGroup removes a consumer group. It returns false if the group
+// is unknown.
+func (s *ClusterState) DeleteConsumerGroup(group string) bool {
+	var events []Event
+	defer func() { s.publish(events...) }()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	topics, ok := s.consumers[group]
+	if !ok {
+		return false
+	}
+
+	for topic := range topics {
+		s.removeReader(topic, group)
+	}
+	delete(s.consumers, group)
+	s.touch()
+
+	events = append(events, Event{Type: EventGroupDeleted, Group: group, Timestamp: time.Now().Unix()})
+	return true
+}
+
+// Reset drops all brokers, topics and consumer groups, e.g. once a cluster
+// has moved to different brokers. Dropped groups are reported as expired.
+func (s *ClusterState) Reset() {
+	var events []Event
+	defer func() { s.publish(events...) }()
+
+	now := time.Now().Unix()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	groups := make([]string, 0, len(s.consumers))
+	for group := range s.consumers {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	for _, group := range groups {
+		events = append(events, Event{Type: EventGroupExpired, Group: group, Timestamp: now})
+	}
+
+	s.brokers = nil
+	s.topics = make(map[string][]int64)
+	s.history = make(map[string]offsetHistory)
+	s.consumers = make(map[string]map[string]consumerOffsetState)
+	s.readers = make(map[string]map[string]struct{})
+	s.touch()
+}
+
+// Revision returns the revision of the cluster state and the time of the
+// last update. The revision is incremented on every change.
+func (s *ClusterState) Revision() (uint64, time.Time) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.revision, s.modified
+}
+
+// Paused returns true if monitoring of the cluster is paused.
+func (s *ClusterState) Paused() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.paused
+}
+
+// SetPaused marks the cluster as paused or resumed. The state of paused
+// clusters is retained but no longer updated.
+func (s *ClusterState) SetPaused(paused bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.paused != paused {
+		s.paused = paused
+		s.touch()
+	}
+}
+
+// touch increments the revision, must be called while holding the lock.
+func (s *ClusterState) touch() {
+	s.revision++
+	s.modified = time.Now()
+}
+
+// ReportError publishes a fetch error event.
+func (s *ClusterState) ReportError(err error) {
+	s.publish(Event{Type: EventFetchError, Timestamp: time.Now().Unix(), Error: err.Error()})
+}
+
+func (s *ClusterState) publish(events ...Event) {
+	if s.events == nil || len(events) == 0 {
+		return
+	}
+	for i := range events {
+		events[i].Cluster = s.name
+	}
+	s.events.publish(events...)
+}
+
+func (s *ClusterState) removeReader(topic, group string) {
+	if groups, ok := s.readers[topic]; ok {
+		delete(groups, group)
+		if len(groups) == 0 {
+			delete(s.readers, topic)
+		}
+	}
+}
+
+func sameBrokers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	x := append(make([]string, 0, len(a)), a...)
+	y := append(make([]string, 0, len(b)), b...)
+	sort.Strings(x)
+	sort.Strings(y)
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sameOffsets(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
 		}
 	}
+	return true
 }
@@ -1,6 +1,8 @@
 package rumour_test
 
 import (
+	"time"
+
 	"github.com/bsm/rumour/internal/rumour"
 
 	. "github.com/bsm/ginkgo/v2"
@@ -23,6 +25,17 @@ var _ = Describe("State", func() {
 		Expect(subject.Cluster("default")).To(BeAssignableToTypeOf(&rumour.ClusterState{}))
 		Expect(subject.Cluster("missing")).To(BeNil())
 	})
+
+	It("should add and remove clusters", func() {
+		cs := subject.AddCluster("prio")
+		Expect(subject.Clusters()).To(Equal([]string{"default", "other", "prio"}))
+		Expect(subject.AddCluster("prio")).To(BeIdenticalTo(cs))
+
+		Expect(subject.RemoveCluster("prio")).To(BeTrue())
+		Expect(subject.RemoveCluster("prio")).To(BeFalse())
+		Expect(subject.Cluster("prio")).To(BeNil())
+		Expect(subject.Clusters()).To(Equal([]string{"default", "other"}))
+	})
 })
 
 var _ = Describe("ClusterState", func() {
@@ -41,6 +54,40 @@ var _ = Describe("ClusterState", func() {
 		subject.UpdateConsumerOffsets("csmx", "two-topic", 1515151517, []int64{999, 125, 100})
 	})
 
+	It("should track revisions", func() {
+		revision := func() uint64 {
+			rev, _ := subject.Revision()
+			return rev
+		}
+
+		rev, modified := subject.Revision()
+		Expect(rev).To(Equal(uint64(6)))
+		Expect(modified).To(BeTemporally("~", time.Now(), time.Second))
+
+		subject.UpdateConsumerOffsets("csmx", "one-topic", 1515151500, []int64{1, 1, 1, 1})
+		Expect(revision()).To(Equal(rev))
+
+		subject.ExpireConsumerGroups(1515151500)
+		Expect(revision()).To(Equal(rev))
+
+		subject.ExpireConsumerGroups(1515151516)
+		Expect(revision()).To(Equal(rev + 1))
+
+		// unchanged brokers and offsets
+		subject.UpdateTopic("one-topic", []int64{125, 101, 117, 124})
+		subject.UpdateBrokers([]string{"10.0.0.1:9092", "10.0.0.2:9092"})
+		Expect(revision()).To(Equal(rev + 1))
+
+		subject.UpdateTopic("one-topic", []int64{125, 101, 117, 125})
+		Expect(revision()).To(Equal(rev + 2))
+		subject.UpdateBrokers([]string{"10.0.0.1:9092"})
+		Expect(revision()).To(Equal(rev + 3))
+
+		rev, modified = rumour.NewClusterState().Revision()
+		Expect(rev).To(BeZero())
+		Expect(modified).To(BeZero())
+	})
+
 	It("should read brokers", func() {
 		Expect(subject.Brokers()).To(Equal([]string{"10.0.0.1:9092", "10.0.0.2:9092"}))
 	})
@@ -71,6 +118,7 @@ var _ = Describe("ClusterState", func() {
 					{Offset: 117, Lag: 0},
 					{Offset: 115, Lag: 9},
 				},
+				Summary: rumour.LagSummary{TotalLag: 14, MaxLag: 9, MaxLagPartition: 3, Partitions: 4},
 			},
 			{
 				Topic:     "two-topic",
@@ -81,6 +129,7 @@ var _ = Describe("ClusterState", func() {
 					{Offset: 100, Lag: 1},
 					{Offset: 0, Lag: 124},
 				},
+				Summary: rumour.LagSummary{TotalLag: 125, MaxLag: 124, MaxLagPartition: 3, Partitions: 4, Uncommitted: 1},
 			},
 		}))
 
@@ -88,6 +137,172 @@ var _ = Describe("ClusterState", func() {
 		Expect(ok).To(BeFalse())
 	})
 
+	It("should read committed consumer offsets", func() {
+		subject.UpdateConsumerOffsets("csmy", "one-topic", 1515151518, []int64{125, rumour.OffsetUnknown})
+
+		offsets, ok := subject.ConsumerOffsets("csmy")
+		Expect(ok).To(BeTrue())
+		Expect(offsets).To(Equal(map[string][]int64{
+			"one-topic": {125, rumour.OffsetUnknown},
+			"two-topic": {125, 100, 117, 124},
+		}))
+
+		_, ok = subject.ConsumerOffsets("missing")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should reset", func() {
+		rev, _ := subject.Revision()
+		subject.Reset()
+		Expect(subject.Brokers()).To(BeEmpty())
+		Expect(subject.Topics()).To(BeEmpty())
+		Expect(subject.ConsumerGroups()).To(BeEmpty())
+		_, ok := subject.TopicConsumers("one-topic")
+		Expect(ok).To(BeFalse())
+
+		next, _ := subject.Revision()
+		Expect(next).To(Equal(rev + 1))
+	})
+
+	It("should read all consumers", func() {
+		consumers := subject.Consumers()
+		Expect(consumers).To(HaveLen(2))
+		Expect(consumers[0].Group).To(Equal("csmx"))
+		Expect(consumers[0].Topics).To(HaveLen(2))
+		Expect(consumers[1].Group).To(Equal("csmy"))
+		Expect(consumers[1].Topics).To(Equal([]rumour.ConsumerTopic{
+			{
+				Topic:     "two-topic",
+				Timestamp: 1515151515,
+				Offsets: []rumour.ConsumerOffset{
+					{Offset: 125, Lag: 0},
+					{Offset: 100, Lag: 25},
+					{Offset: 117, Lag: 0},
+					{Offset: 124, Lag: 0},
+				},
+				Summary: rumour.LagSummary{TotalLag: 25, MaxLag: 25, MaxLagPartition: 1, Partitions: 4},
+			},
+		}))
+		Expect(consumers[1].Summary).To(Equal(rumour.LagSummary{
+			TotalLag:        25,
+			MaxLag:          25,
+			MaxLagTopic:     "two-topic",
+			MaxLagPartition: 1,
+			Partitions:      4,
+		}))
+	})
+
+	It("should filter consumer topics", func() {
+		consumer := subject.Consumers()[0].FilterTopics(func(topic string) bool { return topic == "one-topic" })
+		Expect(consumer.Group).To(Equal("csmx"))
+		Expect(consumer.Topics).To(HaveLen(1))
+		Expect(consumer.Summary).To(Equal(rumour.LagSummary{
+			TotalLag:        14,
+			MaxLag:          9,
+			MaxLagTopic:     "one-topic",
+			MaxLagPartition: 3,
+			Partitions:      4,
+		}))
+	})
+
+	It("should summarize consumer lag", func() {
+		subject.UpdateConsumerOffsets("csmz", "one-topic", 1515151518, []int64{125, -1, 117, 124})
+
+		summary, ok := subject.ConsumerSummary("csmx")
+		Expect(ok).To(BeTrue())
+		Expect(summary).To(Equal(rumour.LagSummary{
+			TotalLag:        139,
+			MaxLag:          124,
+			MaxLagTopic:     "two-topic",
+			MaxLagPartition: 3,
+			Partitions:      8,
+			Uncommitted:     1,
+		}))
+
+		summary, ok = subject.ConsumerSummary("csmz")
+		Expect(ok).To(BeTrue())
+		Expect(summary).To(Equal(rumour.LagSummary{
+			TotalLag:        101,
+			MaxLag:          101,
+			MaxLagTopic:     "one-topic",
+			MaxLagPartition: 1,
+			Partitions:      4,
+			Uncommitted:     1,
+		}))
+
+		_, ok = subject.ConsumerSummary("missing")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should read consumers", func() {
+		consumer, ok := subject.Consumer("csmx")
+		Expect(ok).To(BeTrue())
+		Expect(consumer.Group).To(Equal("csmx"))
+		Expect(consumer.Topics).To(HaveLen(2))
+
+		summary, _ := subject.ConsumerSummary("csmx")
+		Expect(consumer.Summary).To(Equal(summary))
+
+		_, ok = subject.Consumer("missing")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should read topic consumers", func() {
+		consumers, ok := subject.TopicConsumers("two-topic")
+		Expect(ok).To(BeTrue())
+		Expect(consumers).To(HaveLen(2))
+		Expect(consumers[0].Group).To(Equal("csmx"))
+		Expect(consumers[0].Summary.TotalLag).To(Equal(int64(125)))
+		Expect(consumers[1]).To(Equal(rumour.TopicConsumer{
+			Group:     "csmy",
+			Timestamp: 1515151515,
+			Offsets: []rumour.ConsumerOffset{
+				{Offset: 125, Lag: 0},
+				{Offset: 100, Lag: 25},
+				{Offset: 117, Lag: 0},
+				{Offset: 124, Lag: 0},
+			},
+			Summary: rumour.LagSummary{TotalLag: 25, MaxLag: 25, MaxLagPartition: 1, Partitions: 4},
+		}))
+
+		consumers, ok = subject.TopicConsumers("one-topic")
+		Expect(ok).To(BeTrue())
+		Expect(consumers).To(HaveLen(1))
+
+		subject.ExpireConsumerGroups(1515151517)
+		consumers, ok = subject.TopicConsumers("two-topic")
+		Expect(ok).To(BeTrue())
+		Expect(consumers).To(ConsistOf(HaveField("Group", "csmx")))
+		consumers, ok = subject.TopicConsumers("one-topic")
+		Expect(ok).To(BeTrue())
+		Expect(consumers).To(BeEmpty())
+
+		_, ok = subject.TopicConsumers("missing")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should calculate topic lags", func() {
+		subject.UpdateTopic("no-consumers", []int64{100})
+		Expect(subject.TopicLags()).To(Equal(map[string]int64{
+			"one-topic": 14,
+			"two-topic": 125,
+		}))
+	})
+
+	It("should estimate time lag", func() {
+		lag, ok := subject.ConsumerTimeLag("csmx", "one-topic")
+		Expect(ok).To(BeTrue())
+		Expect(lag).To(BeNumerically("~", 0, time.Second))
+
+		subject.UpdateConsumerOffsets("csmz", "one-topic", 1515151518, []int64{125, 101, 117, 124})
+		lag, ok = subject.ConsumerTimeLag("csmz", "one-topic")
+		Expect(ok).To(BeTrue())
+		Expect(lag).To(Equal(time.Duration(0)))
+
+		_, ok = subject.ConsumerTimeLag("csmx", "missing")
+		Expect(ok).To(BeFalse())
+	})
+
 	It("should expire consumer groups", func() {
 		subject.ExpireConsumerGroups(1515151500)
 		Expect(subject.ConsumerGroups()).To(Equal([]string{"csmx", "csmy"}))
@@ -104,4 +319,19 @@ var _ = Describe("ClusterState", func() {
 		subject.ExpireConsumerGroups(1515151520)
 		Expect(subject.ConsumerGroups()).To(BeEmpty())
 	})
+
+	It("should delete consumer groups", func() {
+		rev, _ := subject.Revision()
+		Expect(subject.DeleteConsumerGroup("csmx")).To(BeTrue())
+		next, _ := subject.Revision()
+		Expect(next).To(BeNumerically(">", rev))
+		Expect(subject.ConsumerGroups()).To(Equal([]string{"csmy"}))
+
+		consumers, _ := subject.TopicConsumers("one-topic")
+		Expect(consumers).To(BeEmpty())
+		consumers, _ = subject.TopicConsumers("two-topic")
+		Expect(consumers).To(HaveLen(1))
+
+		Expect(subject.DeleteConsumerGroup("csmx")).To(BeFalse())
+	})
 })
@@ -0,0 +1,331 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bsm/rumour/internal/rumour"
+	"github.com/go-chi/chi/v5"
+)
+
+// Admin performs administrative actions on clusters and consumer groups. It
+// is implemented by rumour.Admin.
+type Admin interface {
+	// Refresh triggers an immediate refresh of a cluster.
+	Refresh(ctx context.Context, cluster string, kind rumour.RefreshKind, wait bool) error
+	// SetCluster adds a cluster or updates its config, it returns true if the
+	// cluster was added.
+	SetCluster(cc *rumour.ClusterConfig) (bool, error)
+	// RemoveCluster removes a cluster.
+	RemoveCluster(cluster string) error
+	// Pause pauses monitoring of a cluster.
+	Pause(cluster string) error
+	// Resume resumes monitoring of a paused cluster.
+	Resume(cluster string) error
+	// ResetOffsets resets the offsets of an inactive consumer group.
+	ResetOffsets(ctx context.Context, cluster, group string, req *rumour.OffsetReset) ([]rumour.OffsetChange, error)
+	// DeleteConsumerGroup deletes an inactive consumer group.
+	DeleteConsumerGroup(ctx context.Context, cluster, group string) error
+}
+
+// requireAdmin restricts access to credentials with admin permissions.
+func requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cred := credentialOf(r); cred == nil || !cred.admin {
+			writeError(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeAdminError maps admin errors to responses.
+func writeAdminError(w http.ResponseWriter, err error) {
+	msg := strings.TrimPrefix(err.Error(), "rumour: ")
+
+	switch {
+	case errors.Is(err, rumour.ErrUnknownCluster), errors.Is(err, rumour.ErrGroupNotFound):
+		writeError(w, "not found", http.StatusNotFound)
+	case errors.Is(err, rumour.ErrInvalidOffsetReset), errors.Is(err, rumour.ErrInvalidClusterConfig):
+		writeError(w, msg, http.StatusBadRequest)
+	case errors.Is(err, rumour.ErrGroupActive), errors.Is(err, rumour.ErrClusterPaused):
+		writeError(w, msg, http.StatusConflict)
+	case errors.Is(err, rumour.ErrRefreshLimited):
+		w.Header().Set("Retry-After", strconv.Itoa(int(rumour.MinRefreshInterval.Seconds())))
+		writeError(w, msg, http.StatusTooManyRequests)
+	case errors.Is(err, rumour.ErrRefreshTimeout):
+		writeError(w, msg, http.StatusGatewayTimeout)
+	default:
+		writeError(w, msg, http.StatusBadGateway)
+	}
+}
+
+func putCluster(admin Admin, audit *AuditLog) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cluster := chi.URLParam(r, "cluster")
+		if !scopeOf(r).cluster(cluster) {
+			writeError(w, "cluster is out of scope", http.StatusForbidden)
+			return
+		}
+
+		var cc rumour.ClusterConfig
+		if err := json.NewDecoder(r.Body).Decode(&cc); err != nil {
+			writeError(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		cc.Name = cluster
+
+		added, err := admin.SetCluster(&cc)
+		entry := &AuditEntry{Action: "put_cluster", Cluster: cluster, Request: &cc}
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		audit.record(r, entry)
+
+		if err != nil {
+			writeAdminError(w, err)
+			return
+		}
+
+		if added {
+			w.WriteHeader(http.StatusCreated)
+		}
+		_ = json.NewEncoder(w).Encode(&cc)
+	})
+}
+
+func deleteCluster(s *rumour.State, admin Admin, audit *AuditLog) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cluster, state := lookupCluster(s, r)
+		if state == nil {
+			writeError(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		err := admin.RemoveCluster(cluster)
+		entry := &AuditEntry{Action: "delete_cluster", Cluster: cluster}
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		audit.record(r, entry)
+
+		if err != nil {
+			writeAdminError(w, err)
+			return
+		}
+		s.RemoveCluster(cluster)
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func refreshCluster(s *rumour.State, admin Admin) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cluster, state := lookupCluster(s, r)
+		if state == nil {
+			writeError(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		query := r.URL.Query()
+		kind, err := rumour.ParseRefreshKind(query.Get("what"))
+		if err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var wait bool
+		if v := query.Get("wait"); v != "" {
+			if wait, err = strconv.ParseBool(v); err != nil {
+				writeError(w, "invalid wait parameter", http.StatusBadRequest)
+				return
+			}
+		}
+
+		if err := admin.Refresh(r.Context(), cluster, kind, wait); err != nil {
+			writeAdminError(w, err)
+			return
+		}
+
+		if wait {
+			w.WriteHeader(http.StatusNoContent)
+		} else {
+			w.WriteHeader(http.StatusAccepted)
+		}
+	})
+}
+
+func pauseCluster(s *rumour.State, admin Admin, audit *AuditLog, paused bool) http.HandlerFunc {
+	action, toggle := "resume_cluster", admin.Resume
+	if paused {
+		action, toggle = "pause_cluster", admin.Pause
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cluster, state := lookupCluster(s, r)
+		if state == nil {
+			writeError(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		err := toggle(cluster)
+		entry := &AuditEntry{Action: action, Cluster: cluster}
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		audit.record(r, entry)
+
+		if err != nil {
+			writeAdminError(w, err)
+			return
+		}
+		state.SetPaused(paused)
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func resetConsumerOffsets(s *rumour.State, admin Admin, audit *AuditLog) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cluster, state := lookupCluster(s, r)
+		if state == nil {
+			writeError(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		// inactive groups are usually no longer tracked by the state
+		consumer := chi.URLParam(r, "consumer")
+		if !scopeOf(r).group(consumer) {
+			writeError(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		var req rumour.OffsetReset
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(req.Topics) == 0 {
+			if !groupInScope(r, state, consumer) {
+				writeError(w, "group reads topics out of scope", http.StatusForbidden)
+				return
+			}
+
+			topics, _ := state.ConsumerTopics(consumer)
+			for _, ct := range topics {
+				req.Topics = append(req.Topics, ct.Topic)
+			}
+		}
+		for _, topic := range req.Topics {
+			if !scopeOf(r).topic(topic) {
+				writeError(w, "topic is out of scope", http.StatusForbidden)
+				return
+			}
+		}
+		if err := req.Validate(); err != nil {
+			writeAdminError(w, err)
+			return
+		}
+
+		changes, err := admin.ResetOffsets(r.Context(), cluster, consumer, &req)
+		if !req.DryRun {
+			entry := &AuditEntry{Action: "reset_offsets", Cluster: cluster, Consumer: consumer, Request: &req}
+			if err != nil {
+				entry.Error = err.Error()
+			} else {
+				entry.Result = changes
+			}
+			audit.record(r, entry)
+		}
+		if err != nil {
+			writeAdminError(w, err)
+			return
+		}
+		if !req.DryRun {
+			applyOffsetChanges(state, consumer, changes)
+		}
+
+		_ = json.NewEncoder(w).Encode(&OffsetResetResponse{
+			Cluster:  cluster,
+			Consumer: consumer,
+			DryRun:   req.DryRun,
+			Changes:  changes,
+		})
+	})
+}
+
+func deleteConsumerGroup(s *rumour.State, admin Admin, audit *AuditLog) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cluster, state := lookupCluster(s, r)
+		if state == nil {
+			writeError(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		consumer := chi.URLParam(r, "consumer")
+		if !scopeOf(r).group(consumer) {
+			writeError(w, "not found", http.StatusNotFound)
+			return
+		}
+		if !groupInScope(r, state, consumer) {
+			writeError(w, "group reads topics out of scope", http.StatusForbidden)
+			return
+		}
+
+		err := admin.DeleteConsumerGroup(r.Context(), cluster, consumer)
+		entry := &AuditEntry{Action: "delete_group", Cluster: cluster, Consumer: consumer}
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		audit.record(r, entry)
+
+		if err != nil {
+			writeAdminError(w, err)
+			return
+		}
+		state.DeleteConsumerGroup(consumer)
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// groupInScope returns true if all topics read by a consumer group are within
+// the scope of the request.
+func groupInScope(r *http.Request, state *rumour.ClusterState, group string) bool {
+	topics, _ := state.ConsumerOffsets(group)
+	for topic := range topics {
+		if !scopeOf(r).topic(topic) {
+			return false
+		}
+	}
+	return true
+}
+
+// applyOffsetChanges updates the state with committed offsets, inactive
+// groups are not refreshed by the fetcher.
+func applyOffsetChanges(state *rumour.ClusterState, group string, changes []rumour.OffsetChange) {
+	current, _ := state.ConsumerOffsets(group)
+
+	updated := make(map[string][]int64)
+	for _, c := range changes {
+		offsets, ok := updated[c.Topic]
+		if !ok {
+			offsets = current[c.Topic]
+		}
+		for len(offsets) <= int(c.Partition) {
+			offsets = append(offsets, rumour.OffsetUnknown)
+		}
+		offsets[c.Partition] = c.NewOffset
+		updated[c.Topic] = offsets
+	}
+
+	now := time.Now().Unix()
+	for topic, offsets := range updated {
+		state.UpdateConsumerOffsets(group, topic, now, offsets)
+	}
+}
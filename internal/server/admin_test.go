@@ -0,0 +1,447 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bsm/rumour/internal/rumour"
+	"github.com/bsm/rumour/internal/server"
+	"github.com/go-chi/httplog"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Admin", func() {
+	var state *rumour.State
+	var admin *fakeAdmin
+	var audit *bytes.Buffer
+	var dir string
+
+	request := func(opt server.Options, method, path, token, body string) *httptest.ResponseRecorder {
+		opt.Log = httplog.Options{LogLevel: "error"}
+		opt.AuditLog = server.NewAuditLog(audit)
+		if opt.Auth == nil {
+			opt.Auth = adminAuth(dir)
+		}
+		if token == "" {
+			token = adminToken
+		}
+
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		server.NewHTTP(":0", state, nil, opt).Handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	auditEntries := func() []server.AuditEntry {
+		var entries []server.AuditEntry
+		dec := json.NewDecoder(audit)
+		for dec.More() {
+			var e server.AuditEntry
+			Expect(dec.Decode(&e)).To(Succeed())
+			entries = append(entries, e)
+		}
+		return entries
+	}
+
+	BeforeEach(func() {
+		state = rumour.NewState([]string{"main"})
+		cluster := state.Cluster("main")
+		cluster.UpdateTopic("orders", []int64{100, 100})
+		cluster.UpdateTopic("payments", []int64{100})
+		cluster.UpdateConsumerOffsets("orders-worker", "orders", 1515151515, []int64{40, 60})
+		cluster.UpdateConsumerOffsets("orders-worker", "payments", 1515151515, []int64{90})
+		cluster.UpdateConsumerOffsets("busy-worker", "orders", 1515151515, []int64{40, 60})
+
+		admin = &fakeAdmin{state: state}
+		audit = new(bytes.Buffer)
+
+		var err error
+		dir, err = os.MkdirTemp("", "rumour-admin")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("should be disabled by default", func() {
+		res := request(server.Options{}, "POST", "/v1/clusters/main/consumers/orders-worker/offsets", "", `{"mode":"latest"}`)
+		Expect(res.Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should require authentication", func() {
+		handler := server.NewHTTP(":0", state, nil, server.Options{
+			Log:   httplog.Options{LogLevel: "error"},
+			Admin: admin,
+		}).Handler
+
+		res := serve(handler, "POST", "/v1/clusters/main/consumers/orders-worker/offsets", `{"mode":"latest"}`)
+		Expect(res.Code).To(Equal(http.StatusNotFound))
+		Expect(admin.Requests()).To(BeEmpty())
+	})
+
+	It("should reset offsets", func() {
+		res := request(server.Options{Admin: admin}, "POST", "/v1/clusters/main/consumers/orders-worker/offsets", "", `{"topics":["orders"],"mode":"earliest"}`)
+		Expect(res.Code).To(Equal(http.StatusOK), res.Body.String())
+		Expect(res.Body.String()).To(MatchJSON(`{
+			"cluster": "main",
+			"consumer": "orders-worker",
+			"dry_run": false,
+			"changes": [
+				{"topic": "orders", "partition": 0, "old_offset": 40, "new_offset": 0},
+				{"topic": "orders", "partition": 1, "old_offset": 60, "new_offset": 0}
+			]
+		}`))
+		Expect(admin.Requests()).To(Equal([]rumour.OffsetReset{{Topics: []string{"orders"}, Mode: rumour.ResetToEarliest}}))
+
+		// state is updated immediately
+		topics, _ := state.Cluster("main").ConsumerTopics("orders-worker")
+		Expect(topics[0].Offsets).To(Equal([]rumour.ConsumerOffset{{Offset: 0, Lag: 100}, {Offset: 0, Lag: 100}}))
+
+		entries := auditEntries()
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Action).To(Equal("reset_offsets"))
+		Expect(entries[0].Cluster).To(Equal("main"))
+		Expect(entries[0].Consumer).To(Equal("orders-worker"))
+		Expect(entries[0].Result).To(HaveLen(2))
+	})
+
+	It("should default to all topics of the group", func() {
+		res := request(server.Options{Admin: admin}, "POST", "/v1/clusters/main/consumers/orders-worker/offsets", "", `{"mode":"latest","dry_run":true}`)
+		Expect(res.Code).To(Equal(http.StatusOK), res.Body.String())
+		Expect(admin.Requests()).To(Equal([]rumour.OffsetReset{{Topics: []string{"orders", "payments"}, Mode: rumour.ResetToLatest, DryRun: true}}))
+	})
+
+	It("should not record or apply dry-runs", func() {
+		res := request(server.Options{Admin: admin}, "POST", "/v1/clusters/main/consumers/orders-worker/offsets", "", `{"topics":["orders"],"mode":"earliest","dry_run":true}`)
+		Expect(res.Code).To(Equal(http.StatusOK), res.Body.String())
+		Expect(res.Body.String()).To(ContainSubstring(`"dry_run":true`))
+		Expect(auditEntries()).To(BeEmpty())
+
+		topics, _ := state.Cluster("main").ConsumerTopics("orders-worker")
+		Expect(topics[0].Offsets[0].Offset).To(Equal(int64(40)))
+	})
+
+	It("should reject bad requests", func() {
+		opt := server.Options{Admin: admin}
+		Expect(request(opt, "POST", "/v1/clusters/main/consumers/orders-worker/offsets", "", `{"mode":"bad"}`).Code).To(Equal(http.StatusBadRequest))
+		Expect(request(opt, "POST", "/v1/clusters/main/consumers/orders-worker/offsets", "", `not json`).Code).To(Equal(http.StatusBadRequest))
+		Expect(request(opt, "POST", "/v1/clusters/main/consumers/missing/offsets", "", `{"mode":"latest"}`).Code).To(Equal(http.StatusBadRequest))
+		Expect(request(opt, "POST", "/v1/clusters/missing/consumers/orders-worker/offsets", "", `{"mode":"latest"}`).Code).To(Equal(http.StatusNotFound))
+		Expect(admin.Requests()).To(BeEmpty())
+	})
+
+	It("should retain uncommitted partitions", func() {
+		state.Cluster("main").UpdateConsumerOffsets("new-worker", "orders", 1515151515, []int64{40})
+
+		res := request(server.Options{Admin: admin}, "POST", "/v1/clusters/main/consumers/new-worker/offsets", "", `{"topics":["orders"],"partitions":[0],"mode":"earliest"}`)
+		Expect(res.Code).To(Equal(http.StatusOK), res.Body.String())
+
+		offsets, _ := state.Cluster("main").ConsumerOffsets("new-worker")
+		Expect(offsets).To(Equal(map[string][]int64{"orders": {0}}))
+
+		summary, _ := state.Cluster("main").ConsumerSummary("new-worker")
+		Expect(summary.Uncommitted).To(Equal(1))
+	})
+
+	It("should reset offsets of untracked groups", func() {
+		res := request(server.Options{Admin: admin}, "POST", "/v1/clusters/main/consumers/idle-worker/offsets", "", `{"topics":["orders"],"mode":"earliest"}`)
+		Expect(res.Code).To(Equal(http.StatusOK), res.Body.String())
+		Expect(admin.Requests()).To(HaveLen(1))
+
+		topics, ok := state.Cluster("main").ConsumerTopics("idle-worker")
+		Expect(ok).To(BeTrue())
+		Expect(topics[0].Offsets).To(Equal([]rumour.ConsumerOffset{{Offset: 0, Lag: 100}, {Offset: 0, Lag: 100}}))
+	})
+
+	It("should refuse active groups", func() {
+		res := request(server.Options{Admin: admin}, "POST", "/v1/clusters/main/consumers/busy-worker/offsets", "", `{"mode":"latest"}`)
+		Expect(res.Code).To(Equal(http.StatusConflict))
+
+		entries := auditEntries()
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Error).To(Equal(rumour.ErrGroupActive.Error()))
+	})
+
+	It("should delete consumer groups", func() {
+		opt := server.Options{Admin: admin}
+		res := request(opt, "DELETE", "/v1/clusters/main/consumers/orders-worker", "", "")
+		Expect(res.Code).To(Equal(http.StatusNoContent), res.Body.String())
+		Expect(res.Body.Len()).To(BeZero())
+		Expect(admin.Deleted()).To(Equal([]string{"orders-worker"}))
+		Expect(state.Cluster("main").ConsumerGroups()).To(Equal([]string{"busy-worker"}))
+
+		Expect(request(opt, "DELETE", "/v1/clusters/main/consumers/busy-worker", "", "").Code).To(Equal(http.StatusConflict))
+		Expect(request(opt, "DELETE", "/v1/clusters/main/consumers/missing", "", "").Code).To(Equal(http.StatusNotFound))
+		Expect(request(opt, "DELETE", "/v1/clusters/missing/consumers/orders-worker", "", "").Code).To(Equal(http.StatusNotFound))
+		Expect(state.Cluster("main").ConsumerGroups()).To(Equal([]string{"busy-worker"}))
+
+		entries := auditEntries()
+		Expect(entries).To(HaveLen(3))
+		Expect(entries[0].Action).To(Equal("delete_group"))
+		Expect(entries[0].Consumer).To(Equal("orders-worker"))
+		Expect(entries[0].Error).To(BeEmpty())
+		Expect(entries[1].Error).To(Equal(rumour.ErrGroupActive.Error()))
+		Expect(entries[2].Error).To(Equal(rumour.ErrGroupNotFound.Error()))
+	})
+
+	It("should refresh clusters", func() {
+		opt := server.Options{Admin: admin}
+		Expect(request(opt, "POST", "/v1/clusters/main/refresh", "", "").Code).To(Equal(http.StatusAccepted))
+		Expect(request(opt, "POST", "/v1/clusters/main/refresh?what=offsets&wait=true", "", "").Code).To(Equal(http.StatusNoContent))
+		Expect(admin.Refreshed()).To(Equal([]rumour.RefreshKind{rumour.RefreshMeta, rumour.RefreshOffsets}))
+
+		res := request(opt, "POST", "/v1/clusters/main/refresh", "", "")
+		Expect(res.Code).To(Equal(http.StatusTooManyRequests))
+		Expect(res.Header().Get("Retry-After")).To(Equal("10"))
+
+		Expect(request(opt, "POST", "/v1/clusters/main/refresh?what=all", "", "").Code).To(Equal(http.StatusBadRequest))
+		Expect(request(opt, "POST", "/v1/clusters/main/refresh?wait=maybe", "", "").Code).To(Equal(http.StatusBadRequest))
+		Expect(request(opt, "POST", "/v1/clusters/missing/refresh", "", "").Code).To(Equal(http.StatusNotFound))
+		Expect(admin.Refreshed()).To(HaveLen(2))
+		Expect(auditEntries()).To(BeEmpty())
+	})
+
+	It("should pause and resume clusters", func() {
+		opt := server.Options{Admin: admin}
+		Expect(request(opt, "POST", "/v1/clusters/main/pause", "", "").Code).To(Equal(http.StatusNoContent))
+		Expect(admin.Paused()).To(BeTrue())
+		Expect(state.Cluster("main").Paused()).To(BeTrue())
+
+		res := request(opt, "GET", "/v1/clusters/main", "", "")
+		Expect(res.Code).To(Equal(http.StatusOK))
+		Expect(res.Body.String()).To(ContainSubstring(`"paused":true`))
+
+		res = request(opt, "POST", "/v1/clusters/main/refresh", "", "")
+		Expect(res.Code).To(Equal(http.StatusConflict))
+
+		Expect(request(opt, "POST", "/v1/clusters/main/resume", "", "").Code).To(Equal(http.StatusNoContent))
+		Expect(admin.Paused()).To(BeFalse())
+		Expect(state.Cluster("main").Paused()).To(BeFalse())
+		Expect(request(opt, "POST", "/v1/clusters/missing/pause", "", "").Code).To(Equal(http.StatusNotFound))
+
+		entries := auditEntries()
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0].Action).To(Equal("pause_cluster"))
+		Expect(entries[0].Cluster).To(Equal("main"))
+		Expect(entries[1].Action).To(Equal("resume_cluster"))
+	})
+
+	It("should add, update and remove clusters", func() {
+		opt := server.Options{Admin: admin}
+		res := request(opt, "PUT", "/v1/clusters/prio", "", `{"brokers":["10.0.0.1:9192"],"meta_refresh":"2m"}`)
+		Expect(res.Code).To(Equal(http.StatusCreated), res.Body.String())
+		Expect(res.Body.String()).To(MatchJSON(`{"name":"prio","brokers":["10.0.0.1:9192"],"meta_refresh":"2m0s"}`))
+		Expect(state.Clusters()).To(Equal([]string{"main", "prio"}))
+
+		res = request(opt, "PUT", "/v1/clusters/main", "", `{"brokers":["10.0.0.1:9092"]}`)
+		Expect(res.Code).To(Equal(http.StatusOK), res.Body.String())
+		Expect(admin.Clusters()).To(Equal([]rumour.ClusterConfig{
+			{Name: "prio", Brokers: []string{"10.0.0.1:9192"}, MetaRefresh: 2 * time.Minute},
+			{Name: "main", Brokers: []string{"10.0.0.1:9092"}},
+		}))
+
+		Expect(request(opt, "PUT", "/v1/clusters/other", "", `{"brokers":[]}`).Code).To(Equal(http.StatusBadRequest))
+		Expect(request(opt, "PUT", "/v1/clusters/other", "", `{"meta_refresh":"soon"}`).Code).To(Equal(http.StatusBadRequest))
+		Expect(state.Clusters()).To(Equal([]string{"main", "prio"}))
+
+		Expect(request(opt, "DELETE", "/v1/clusters/prio", "", "").Code).To(Equal(http.StatusNoContent))
+		Expect(request(opt, "DELETE", "/v1/clusters/prio", "", "").Code).To(Equal(http.StatusNotFound))
+		Expect(state.Clusters()).To(Equal([]string{"main"}))
+
+		entries := auditEntries()
+		Expect(entries).To(HaveLen(4))
+		Expect(entries[0].Action).To(Equal("put_cluster"))
+		Expect(entries[0].Cluster).To(Equal("prio"))
+		Expect(entries[2].Error).To(ContainSubstring("brokers are required"))
+		Expect(entries[3].Action).To(Equal("delete_cluster"))
+	})
+
+	It("should require admin credentials", func() {
+		name := filepath.Join(dir, "credentials.json")
+		Expect(os.WriteFile(name, []byte(`{
+			"tokens": [
+				{"name": "reader", "token": "r3ad"},
+				{"name": "operator", "token": "0p3r", "admin": true, "scope": {"topics": ["orders"]}}
+			]
+		}`), 0600)).To(Succeed())
+
+		auth, err := server.NewAuth(name)
+		Expect(err).NotTo(HaveOccurred())
+		opt := server.Options{Admin: admin, Auth: auth}
+
+		Expect(request(opt, "POST", "/v1/clusters/main/consumers/orders-worker/offsets", "r3ad", `{"topics":["orders"],"mode":"latest"}`).Code).To(Equal(http.StatusForbidden))
+		Expect(request(opt, "POST", "/v1/clusters/main/consumers/orders-worker/offsets", "0p3r", `{"topics":["payments"],"mode":"latest"}`).Code).To(Equal(http.StatusForbidden))
+		Expect(admin.Requests()).To(BeEmpty())
+
+		// orders-worker also reads payments
+		Expect(request(opt, "POST", "/v1/clusters/main/consumers/orders-worker/offsets", "0p3r", `{"mode":"latest"}`).Code).To(Equal(http.StatusForbidden))
+		Expect(request(opt, "DELETE", "/v1/clusters/main/consumers/orders-worker", "0p3r", "").Code).To(Equal(http.StatusForbidden))
+		Expect(admin.Requests()).To(BeEmpty())
+		Expect(admin.Deleted()).To(BeEmpty())
+
+		res := request(opt, "POST", "/v1/clusters/main/consumers/orders-worker/offsets", "0p3r", `{"topics":["orders"],"mode":"latest"}`)
+		Expect(res.Code).To(Equal(http.StatusOK), res.Body.String())
+		Expect(admin.Requests()).To(Equal([]rumour.OffsetReset{{Topics: []string{"orders"}, Mode: rumour.ResetToLatest}}))
+
+		res = request(opt, "POST", "/v1/clusters/main/consumers/busy-worker/offsets", "0p3r", `{"mode":"latest"}`)
+		Expect(res.Code).To(Equal(http.StatusConflict), res.Body.String())
+
+		entries := auditEntries()
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0].Principal).To(Equal("operator"))
+	})
+})
+
+// fakeAdmin records requests, it resets all partitions to the log start and
+// permits two refreshes. Like the fetcher, it adds new clusters to the state.
+type fakeAdmin struct {
+	state *rumour.State
+
+	mu        sync.Mutex
+	requests  []rumour.OffsetReset
+	deleted   []string
+	refreshed []rumour.RefreshKind
+	paused    bool
+	clusters  []rumour.ClusterConfig
+}
+
+func (a *fakeAdmin) Clusters() []rumour.ClusterConfig {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.clusters
+}
+
+func (a *fakeAdmin) SetCluster(cc *rumour.ClusterConfig) (bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(cc.Brokers) == 0 {
+		return false, fmt.Errorf("%w: brokers are required", rumour.ErrInvalidClusterConfig)
+	}
+
+	a.clusters = append(a.clusters, *cc)
+	if a.state != nil {
+		a.state.AddCluster(cc.Name)
+	}
+	return cc.Name != "main", nil
+}
+
+func (a *fakeAdmin) RemoveCluster(cluster string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.clusters = append(a.clusters, rumour.ClusterConfig{Name: cluster})
+	return nil
+}
+
+func (a *fakeAdmin) Paused() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.paused
+}
+
+func (a *fakeAdmin) Pause(_ string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.paused = true
+	return nil
+}
+
+func (a *fakeAdmin) Resume(_ string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.paused = false
+	return nil
+}
+
+func (a *fakeAdmin) Requests() []rumour.OffsetReset {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.requests
+}
+
+func (a *fakeAdmin) Deleted() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.deleted
+}
+
+func (a *fakeAdmin) Refreshed() []rumour.RefreshKind {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.refreshed
+}
+
+func (a *fakeAdmin) Refresh(_ context.Context, _ string, kind rumour.RefreshKind, _ bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.paused {
+		return rumour.ErrClusterPaused
+	} else if len(a.refreshed) == 2 {
+		return rumour.ErrRefreshLimited
+	}
+
+	a.refreshed = append(a.refreshed, kind)
+	return nil
+}
+
+func (a *fakeAdmin) DeleteConsumerGroup(_ context.Context, _, group string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switch group {
+	case "busy-worker":
+		return rumour.ErrGroupActive
+	case "missing":
+		return rumour.ErrGroupNotFound
+	}
+
+	a.deleted = append(a.deleted, group)
+	return nil
+}
+
+func (a *fakeAdmin) ResetOffsets(_ context.Context, _, group string, req *rumour.OffsetReset) ([]rumour.OffsetChange, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if group == "busy-worker" {
+		return nil, rumour.ErrGroupActive
+	}
+
+	a.requests = append(a.requests, *req)
+	partitions := req.Partitions
+	if len(partitions) == 0 {
+		partitions = []int32{0, 1}
+	}
+
+	changes := []rumour.OffsetChange{}
+	for _, topic := range req.Topics {
+		for _, part := range partitions {
+			changes = append(changes, rumour.OffsetChange{Topic: topic, Partition: part, OldOffset: 40 + 20*int64(part), NewOffset: 0})
+		}
+	}
+	return changes, nil
+}
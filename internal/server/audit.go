@@ -0,0 +1,60 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// AuditEntry records an admin API action.
+type AuditEntry struct {
+	Time       int64       `json:"time"`
+	Action     string      `json:"action"`
+	Principal  string      `json:"principal,omitempty"`
+	RemoteAddr string      `json:"remote_addr,omitempty"`
+	RequestID  string      `json:"request_id,omitempty"`
+	Cluster    string      `json:"cluster"`
+	Consumer   string      `json:"consumer"`
+	Request    interface{} `json:"request,omitempty"`
+	Result     interface{} `json:"result,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// AuditLog writes audit entries as JSON lines.
+type AuditLog struct {
+	logger *log.Logger
+
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewAuditLog inits an audit log writing to w.
+func NewAuditLog(w io.Writer) *AuditLog {
+	return &AuditLog{
+		logger: log.New(os.Stderr, "[audit] ", log.LstdFlags),
+		enc:    json.NewEncoder(w),
+	}
+}
+
+// record completes and writes an entry.
+func (l *AuditLog) record(r *http.Request, e *AuditEntry) {
+	e.Time = time.Now().Unix()
+	e.RemoteAddr = r.RemoteAddr
+	e.RequestID = middleware.GetReqID(r.Context())
+	if cred := credentialOf(r); cred != nil {
+		e.Principal = cred.name
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.enc.Encode(e); err != nil {
+		l.logger.Printf("unable to record %s of %q on %q: %v", e.Action, e.Consumer, e.Cluster, err)
+	}
+}
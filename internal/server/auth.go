@@ -0,0 +1,322 @@
+package server
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// AuthConfig contains API credentials.
+type AuthConfig struct {
+	// Tokens contains static bearer tokens.
+	Tokens []AuthToken `json:"tokens"`
+	// Users contains basic auth credentials.
+	Users []AuthUser `json:"users"`
+}
+
+// LoadAuthConfig loads credentials from a JSON file.
+func LoadAuthConfig(name string) (*AuthConfig, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := new(AuthConfig)
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("server: unable to parse %s: %w", name, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("server: invalid credentials in %s: %w", name, err)
+	}
+	return cfg, nil
+}
+
+func (c *AuthConfig) validate() error {
+	for i := range c.Tokens {
+		t := &c.Tokens[i]
+		if t.Token == "" {
+			return fmt.Errorf("token #%d is blank", i+1)
+		}
+
+		sc, err := t.Scope.compile()
+		if err != nil {
+			return fmt.Errorf("invalid scope of token #%d: %w", i+1, err)
+		}
+		t.scope = sc
+	}
+	for i := range c.Users {
+		u := &c.Users[i]
+		if u.Username == "" {
+			return errors.New("username is blank")
+		}
+		if !strings.HasPrefix(u.Password, "$2") && !strings.HasPrefix(u.Password, "{SHA}") {
+			return fmt.Errorf("password of %q is not a supported hash", u.Username)
+		}
+
+		sc, err := u.Scope.compile()
+		if err != nil {
+			return fmt.Errorf("invalid scope of %q: %w", u.Username, err)
+		}
+		u.scope = sc
+	}
+	return nil
+}
+
+// AuthToken is a static bearer token. Tokens without a scope have
+// unrestricted access. Admin grants access to the admin API.
+type AuthToken struct {
+	Name  string     `json:"name"`
+	Token string     `json:"token"`
+	Scope *AuthScope `json:"scope,omitempty"`
+	Admin bool       `json:"admin,omitempty"`
+
+	scope *scope
+}
+
+// AuthUser contains basic auth credentials. Passwords are htpasswd-style
+// hashes, either bcrypt (e.g. "$2y$10$...") or SHA1 ("{SHA}..."). Users
+// without a scope have unrestricted access. Admin grants access to the admin
+// API.
+type AuthUser struct {
+	Username string     `json:"username"`
+	Password string     `json:"password"`
+	Scope    *AuthScope `json:"scope,omitempty"`
+	Admin    bool       `json:"admin,omitempty"`
+
+	scope *scope
+}
+
+func (u *AuthUser) verify(password string) bool {
+	if strings.HasPrefix(u.Password, "{SHA}") {
+		sum := sha1.Sum([]byte(password))
+		hash := "{SHA}" + base64.StdEncoding.EncodeToString(sum[:])
+		return subtle.ConstantTimeCompare([]byte(hash), []byte(u.Password)) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)) == nil
+}
+
+// --------------------------------------------------------------------
+
+// Auth authenticates API requests using credentials from a file. Changes to
+// the file are picked up by ReloadLoop, invalid changes are logged and
+// ignored.
+type Auth struct {
+	name   string
+	logger *log.Logger
+
+	mu      sync.RWMutex
+	cfg     *AuthConfig
+	modTime time.Time
+	size    int64
+
+	cmu      sync.Mutex
+	verified map[[sha256.Size]byte]verifiedUser
+}
+
+// verifiedUser is a cached basic auth credential, as verifying password
+// hashes is expensive.
+type verifiedUser struct {
+	cred    *credential
+	cfg     *AuthConfig
+	expires time.Time
+}
+
+const (
+	authCacheTTL  = time.Minute
+	authCacheSize = 1024
+)
+
+// NewAuth inits an authenticator from a credentials file.
+func NewAuth(name string) (*Auth, error) {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := LoadAuthConfig(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Auth{
+		name:     name,
+		logger:   log.New(os.Stdout, "[auth] ", log.LstdFlags),
+		cfg:      cfg,
+		modTime:  fi.ModTime(),
+		size:     fi.Size(),
+		verified: make(map[[sha256.Size]byte]verifiedUser),
+	}, nil
+}
+
+// ReloadLoop checks the credentials file for changes every interval. It
+// blocks until the context is cancelled.
+func (a *Auth) ReloadLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.reload()
+		}
+	}
+}
+
+// reload reloads the credentials if the file has changed.
+func (a *Auth) reload() {
+	fi, err := os.Stat(a.name)
+	if err != nil {
+		a.logger.Printf("unable to check %s: %v", a.name, err)
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if fi.ModTime().Equal(a.modTime) && fi.Size() == a.size {
+		return
+	}
+	// do not retry invalid changes until the file changes again
+	a.modTime = fi.ModTime()
+	a.size = fi.Size()
+
+	cfg, err := LoadAuthConfig(a.name)
+	if err != nil {
+		a.logger.Printf("unable to reload credentials: %v", err)
+		return
+	}
+
+	a.cfg = cfg
+	a.logger.Printf("reloaded credentials from %s", a.name)
+}
+
+// authenticate validates the credentials of a request.
+func (a *Auth) authenticate(r *http.Request) (*credential, bool) {
+	return a.check(r.Header.Get("Authorization"))
+}
+
+// check validates the credentials of an Authorization header.
+func (a *Auth) check(header string) (*credential, bool) {
+	a.mu.RLock()
+	cfg := a.cfg
+	a.mu.RUnlock()
+
+	if username, password, ok := basicAuth(header); ok {
+		key := sha256.Sum256([]byte(username + ":" + password))
+		if cred := a.cached(cfg, key); cred != nil {
+			return cred, true
+		}
+
+		for i := range cfg.Users {
+			if u := &cfg.Users[i]; u.Username == username {
+				if !u.verify(password) {
+					return nil, false
+				}
+
+				cred := &credential{name: u.Username, admin: u.Admin, scope: u.scope}
+				a.cache(cfg, key, cred)
+				return cred, true
+			}
+		}
+		return nil, false
+	}
+
+	if token := bearerToken(header); token != "" {
+		for _, t := range cfg.Tokens {
+			if subtle.ConstantTimeCompare([]byte(t.Token), []byte(token)) == 1 {
+				return &credential{name: t.Name, admin: t.Admin, scope: t.scope}, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// cached returns a verified credential, if it was verified against the same
+// config recently.
+func (a *Auth) cached(cfg *AuthConfig, key [sha256.Size]byte) *credential {
+	a.cmu.Lock()
+	defer a.cmu.Unlock()
+
+	if v, ok := a.verified[key]; ok && v.cfg == cfg && time.Now().Before(v.expires) {
+		return v.cred
+	}
+	return nil
+}
+
+func (a *Auth) cache(cfg *AuthConfig, key [sha256.Size]byte, cred *credential) {
+	a.cmu.Lock()
+	defer a.cmu.Unlock()
+
+	now := time.Now()
+	if len(a.verified) >= authCacheSize {
+		for k, v := range a.verified {
+			if v.cfg != cfg || !now.Before(v.expires) {
+				delete(a.verified, k)
+			}
+		}
+	}
+	if len(a.verified) >= authCacheSize {
+		a.verified = make(map[[sha256.Size]byte]verifiedUser)
+	}
+	a.verified[key] = verifiedUser{cred: cred, cfg: cfg, expires: now.Add(authCacheTTL)}
+}
+
+func bearerToken(h string) string {
+	const prefix = "bearer "
+
+	if len(h) < len(prefix) || !strings.EqualFold(h[:len(prefix)], prefix) {
+		return ""
+	}
+	return strings.TrimSpace(h[len(prefix):])
+}
+
+func basicAuth(h string) (username, password string, ok bool) {
+	const prefix = "basic "
+
+	if len(h) < len(prefix) || !strings.EqualFold(h[:len(prefix)], prefix) {
+		return "", "", false
+	}
+	data, err := base64.StdEncoding.DecodeString(h[len(prefix):])
+	if err != nil {
+		return "", "", false
+	}
+	pair := string(data)
+	i := strings.IndexByte(pair, ':')
+	if i < 0 {
+		return "", "", false
+	}
+	return pair[:i], pair[i+1:], true
+}
+
+func authenticate(auth *Auth) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cred, ok := auth.authenticate(r)
+			if !ok {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Add("WWW-Authenticate", `Bearer realm="rumour"`)
+				w.Header().Add("WWW-Authenticate", `Basic realm="rumour"`)
+				writeError(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			w.Header().Add("Vary", "Authorization")
+			next.ServeHTTP(w, withCredential(r, cred))
+		})
+	}
+}
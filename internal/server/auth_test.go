@@ -0,0 +1,143 @@
+package server_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bsm/rumour/internal/rumour"
+	"github.com/bsm/rumour/internal/server"
+	"github.com/go-chi/httplog"
+	"golang.org/x/crypto/bcrypt"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Auth", func() {
+	var state *rumour.State
+	var auth *server.Auth
+	var dir, name string
+
+	writeCredentials := func(data string) {
+		Expect(os.WriteFile(name, []byte(data), 0600)).To(Succeed())
+	}
+
+	request := func(opt server.Options, path string, creds func(*http.Request)) *http.Response {
+		handler := server.NewHTTP(":0", state, nil, opt).Handler
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		Expect(err).NotTo(HaveOccurred())
+		if creds != nil {
+			creds(req)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Result()
+	}
+
+	bearer := func(token string) func(*http.Request) {
+		return func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+token) }
+	}
+
+	basic := func(username, password string) func(*http.Request) {
+		return func(r *http.Request) { r.SetBasicAuth(username, password) }
+	}
+
+	BeforeEach(func() {
+		state = rumour.NewState([]string{"main"})
+		state.Cluster("main").UpdateBrokers([]string{"10.0.0.1:9092"})
+
+		hash, err := bcrypt.GenerateFromPassword([]byte("s3cr3t"), bcrypt.MinCost)
+		Expect(err).NotTo(HaveOccurred())
+
+		dir, err = os.MkdirTemp("", "rumour-auth")
+		Expect(err).NotTo(HaveOccurred())
+
+		name = filepath.Join(dir, "credentials.json")
+		writeCredentials(`{
+			"tokens": [{"name": "datadog", "token": "t0k3n"}],
+			"users": [
+				{"username": "alice", "password": "` + string(hash) + `"},
+				{"username": "bob", "password": "{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g="}
+			]
+		}`)
+
+		auth, err = server.NewAuth(name)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("should load credentials", func() {
+		cfg, err := server.LoadAuthConfig(name)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.Tokens).To(Equal([]server.AuthToken{{Name: "datadog", Token: "t0k3n"}}))
+		Expect(cfg.Users).To(HaveLen(2))
+
+		writeCredentials(`{"users": [{"username": "alice", "password": "plain"}]}`)
+		_, err = server.LoadAuthConfig(name)
+		Expect(err).To(MatchError(ContainSubstring(`password of "alice" is not a supported hash`)))
+
+		_, err = server.NewAuth(filepath.Join(dir, "missing.json"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should authenticate requests", func() {
+		opt := server.Options{Log: httplog.Options{LogLevel: "error"}, Auth: auth}
+
+		res := request(opt, "/v1/clusters", nil)
+		Expect(res.StatusCode).To(Equal(http.StatusUnauthorized))
+		Expect(res.Header.Get("Content-Type")).To(Equal("application/json"))
+		Expect(res.Header.Values("WWW-Authenticate")).To(ConsistOf(`Bearer realm="rumour"`, `Basic realm="rumour"`))
+
+		Expect(request(opt, "/v1/clusters", bearer("t0k3n")).StatusCode).To(Equal(http.StatusOK))
+		Expect(request(opt, "/v1/clusters", bearer("wrong")).StatusCode).To(Equal(http.StatusUnauthorized))
+		Expect(request(opt, "/v2/clusters", basic("alice", "s3cr3t")).StatusCode).To(Equal(http.StatusOK))
+		Expect(request(opt, "/v2/clusters", basic("alice", "wrong")).StatusCode).To(Equal(http.StatusUnauthorized))
+		Expect(request(opt, "/v1/clusters", basic("bob", "password")).StatusCode).To(Equal(http.StatusOK))
+		Expect(request(opt, "/v1/clusters", basic("carol", "password")).StatusCode).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("should optionally authenticate health checks", func() {
+		opt := server.Options{Log: httplog.Options{LogLevel: "error"}, Auth: auth}
+		Expect(request(opt, "/healthz", nil).StatusCode).To(Equal(http.StatusOK))
+		Expect(request(opt, "/readyz", nil).StatusCode).To(Equal(http.StatusOK))
+
+		opt.AuthHealthChecks = true
+		Expect(request(opt, "/healthz", nil).StatusCode).To(Equal(http.StatusUnauthorized))
+		Expect(request(opt, "/readyz", nil).StatusCode).To(Equal(http.StatusUnauthorized))
+		Expect(request(opt, "/readyz", bearer("t0k3n")).StatusCode).To(Equal(http.StatusOK))
+	})
+
+	It("should reload credentials on change", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go auth.ReloadLoop(ctx, 10*time.Millisecond)
+
+		opt := server.Options{Log: httplog.Options{LogLevel: "error"}, Auth: auth}
+		status := func(creds func(*http.Request)) func() int {
+			return func() int { return request(opt, "/v1/clusters", creds).StatusCode }
+		}
+		Expect(status(bearer("t0k3n"))()).To(Equal(http.StatusOK))
+		Expect(status(basic("alice", "s3cr3t"))()).To(Equal(http.StatusOK))
+
+		writeCredentials(`{
+			"tokens": [{"name": "datadog", "token": "n3w-t0k3n"}],
+			"users": [{"username": "alice", "password": "{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g="}]
+		}`)
+		Eventually(status(bearer("n3w-t0k3n"))).Should(Equal(http.StatusOK))
+		Expect(status(bearer("t0k3n"))()).To(Equal(http.StatusUnauthorized))
+
+		// verified passwords are not cached across reloads
+		Expect(status(basic("alice", "s3cr3t"))()).To(Equal(http.StatusUnauthorized))
+		Expect(status(basic("alice", "password"))()).To(Equal(http.StatusOK))
+
+		writeCredentials(`{"tokens": [{"name": "datadog"}]}`)
+		Consistently(status(bearer("n3w-t0k3n")), 50*time.Millisecond).Should(Equal(http.StatusOK))
+	})
+})
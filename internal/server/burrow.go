@@ -0,0 +1,299 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	"github.com/bsm/rumour/internal/rumour"
+	"github.com/go-chi/chi/v5"
+)
+
+// burrowWindow is the number of lag samples used to evaluate the status of
+// consumer groups, equivalent to Burrow's default number of intervals.
+const burrowWindow = 10
+
+// Burrow consumer statuses.
+const (
+	burrowStatusOK   = "OK"
+	burrowStatusWarn = "WARN"
+)
+
+type burrowRequest struct {
+	URL  string `json:"url"`
+	Host string `json:"host"`
+}
+
+type burrowOffset struct {
+	Offset    int64 `json:"offset"`
+	Timestamp int64 `json:"timestamp"`
+	Lag       int64 `json:"lag"`
+}
+
+type burrowConsumerPartition struct {
+	Offsets    []burrowOffset `json:"offsets"`
+	Owner      string         `json:"owner"`
+	ClientID   string         `json:"client_id"`
+	CurrentLag int64          `json:"current-lag"`
+}
+
+type burrowPartitionStatus struct {
+	Topic      string        `json:"topic"`
+	Partition  int           `json:"partition"`
+	Owner      string        `json:"owner"`
+	ClientID   string        `json:"client_id"`
+	Status     string        `json:"status"`
+	Start      *burrowOffset `json:"start"`
+	End        *burrowOffset `json:"end"`
+	CurrentLag int64         `json:"current_lag"`
+	Complete   float64       `json:"complete"`
+}
+
+type burrowGroupStatus struct {
+	Cluster        string                   `json:"cluster"`
+	Group          string                   `json:"group"`
+	Status         string                   `json:"status"`
+	Complete       float64                  `json:"complete"`
+	Partitions     []*burrowPartitionStatus `json:"partitions"`
+	PartitionCount int                      `json:"partition_count"`
+	MaxLag         *burrowPartitionStatus   `json:"maxlag"`
+	TotalLag       int64                    `json:"totallag"`
+}
+
+func writeBurrow(w http.ResponseWriter, r *http.Request, message string, v map[string]interface{}) {
+	host, _ := os.Hostname()
+	v["error"] = false
+	v["message"] = message
+	v["request"] = burrowRequest{URL: r.URL.Path, Host: host}
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeBurrowError(w http.ResponseWriter, r *http.Request, message string, status int) {
+	host, _ := os.Hostname()
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(struct {
+		Error   bool          `json:"error"`
+		Message string        `json:"message"`
+		Request burrowRequest `json:"request"`
+	}{
+		Error:   true,
+		Message: message,
+		Request: burrowRequest{URL: r.URL.Path, Host: host},
+	})
+}
+
+func burrowHealthCheck(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = w.Write([]byte("GOOD"))
+}
+
+func burrowListClusters(s *rumour.State) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeBurrow(w, r, "cluster list returned", map[string]interface{}{
+			"clusters": scopeOf(r).clusterNames(s.Clusters()),
+		})
+	})
+}
+
+func burrowShowCluster(s *rumour.State) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, state := lookupCluster(s, r)
+		if state == nil {
+			writeBurrowError(w, r, "cluster not found", http.StatusNotFound)
+			return
+		}
+
+		writeBurrow(w, r, "cluster module detail returned", map[string]interface{}{
+			"module": map[string]interface{}{
+				"class-name": "kafka",
+				"servers":    state.Brokers(),
+				"client-profile": map[string]interface{}{
+					"name":          "rumour",
+					"client-id":     "rumour",
+					"kafka-version": "0.10.0.0",
+				},
+			},
+		})
+	})
+}
+
+func burrowListTopics(s *rumour.State) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, state := lookupCluster(s, r)
+		if state == nil {
+			writeBurrowError(w, r, "cluster not found", http.StatusNotFound)
+			return
+		}
+
+		writeBurrow(w, r, "topic list returned", map[string]interface{}{
+			"topics": scopeOf(r).topicNames(state.Topics()),
+		})
+	})
+}
+
+func burrowShowTopic(s *rumour.State) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, state := lookupCluster(s, r)
+		if state == nil {
+			writeBurrowError(w, r, "cluster not found", http.StatusNotFound)
+			return
+		}
+
+		topic := chi.URLParam(r, "topic")
+		partitions, ok := state.TopicPartitions(topic)
+		if !ok || !scopeOf(r).topic(topic) {
+			writeBurrowError(w, r, "topic not found", http.StatusNotFound)
+			return
+		}
+
+		offsets := make([]int64, len(partitions))
+		for i, tp := range partitions {
+			if tp.LogEndOffset != nil {
+				offsets[i] = *tp.LogEndOffset
+			}
+		}
+
+		writeBurrow(w, r, "topic offsets returned", map[string]interface{}{
+			"offsets": offsets,
+		})
+	})
+}
+
+func burrowListConsumers(s *rumour.State) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, state := lookupCluster(s, r)
+		if state == nil {
+			writeBurrowError(w, r, "cluster not found", http.StatusNotFound)
+			return
+		}
+
+		writeBurrow(w, r, "consumer list returned", map[string]interface{}{
+			"consumers": scopeOf(r).groupNames(state.ConsumerGroups()),
+		})
+	})
+}
+
+func burrowShowConsumer(s *rumour.State) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, state := lookupCluster(s, r)
+		if state == nil {
+			writeBurrowError(w, r, "cluster not found", http.StatusNotFound)
+			return
+		}
+
+		group := chi.URLParam(r, "consumer")
+		cp, ok := state.ConsumerPartitions(group)
+		if !ok || !scopeOf(r).group(group) {
+			writeBurrowError(w, r, "consumer group not found", http.StatusNotFound)
+			return
+		}
+		if sc := scopeOf(r); sc != nil {
+			cp = cp.FilterTopics(sc.topic)
+		}
+
+		topics := make(map[string][]burrowConsumerPartition, len(cp.Topics))
+		for _, ct := range cp.Topics {
+			partitions := make([]burrowConsumerPartition, len(ct.Partitions))
+			for i, po := range ct.Partitions {
+				partitions[i].Offsets = []burrowOffset{}
+				if off := burrowOffsetOf(ct.Timestamp, po); off != nil {
+					partitions[i].Offsets = append(partitions[i].Offsets, *off)
+					partitions[i].CurrentLag = off.Lag
+				}
+			}
+			topics[ct.Topic] = partitions
+		}
+
+		writeBurrow(w, r, "consumer detail returned", map[string]interface{}{
+			"topics": topics,
+		})
+	})
+}
+
+// burrowConsumerStatus evaluates the status of a consumer group. Unlike
+// Burrow, which evaluates a window of committed offsets per partition,
+// partitions with lag are reported as WARN when the total lag of their topic
+// has grown across the last burrowWindow samples. When all is false,
+// only partitions with a non-OK status are included.
+func burrowConsumerStatus(s *rumour.State, all bool) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cluster, state := lookupCluster(s, r)
+		if state == nil {
+			writeBurrowError(w, r, "cluster not found", http.StatusNotFound)
+			return
+		}
+
+		group := chi.URLParam(r, "consumer")
+		cp, ok := state.ConsumerPartitions(group)
+		if !ok || !scopeOf(r).group(group) {
+			writeBurrowError(w, r, "consumer group not found", http.StatusNotFound)
+			return
+		}
+		if sc := scopeOf(r); sc != nil {
+			cp = cp.FilterTopics(sc.topic)
+		}
+
+		status := burrowGroupStatus{
+			Cluster:    cluster,
+			Group:      group,
+			Status:     burrowStatusOK,
+			Complete:   1,
+			Partitions: []*burrowPartitionStatus{},
+		}
+		for _, ct := range cp.Topics {
+			samples, _ := state.ConsumerLagHistory(group, ct.Topic)
+			if len(samples) > burrowWindow {
+				samples = samples[len(samples)-burrowWindow:]
+			}
+
+			complete := float64(len(samples)) / burrowWindow
+			growing := len(samples) > 1 && samples[len(samples)-1].Lag > samples[0].Lag
+
+			for _, po := range ct.Partitions {
+				off := burrowOffsetOf(ct.Timestamp, po)
+				if off == nil {
+					continue
+				}
+
+				ps := &burrowPartitionStatus{
+					Topic:      ct.Topic,
+					Partition:  po.Partition,
+					Status:     burrowStatusOK,
+					Start:      off,
+					End:        off,
+					CurrentLag: off.Lag,
+					Complete:   complete,
+				}
+				if growing && off.Lag > 0 {
+					ps.Status = burrowStatusWarn
+					status.Status = burrowStatusWarn
+				}
+				if complete < status.Complete {
+					status.Complete = complete
+				}
+
+				status.PartitionCount++
+				status.TotalLag += ps.CurrentLag
+				if status.MaxLag == nil || ps.CurrentLag > status.MaxLag.CurrentLag {
+					status.MaxLag = ps
+				}
+				if all || ps.Status != burrowStatusOK {
+					status.Partitions = append(status.Partitions, ps)
+				}
+			}
+		}
+
+		writeBurrow(w, r, "consumer status returned", map[string]interface{}{
+			"status": status,
+		})
+	})
+}
+
+// burrowOffsetOf converts a partition offset, timestamps are in milliseconds.
+// Returns nil if the offset or lag of the partition are unknown.
+func burrowOffsetOf(timestamp int64, po rumour.PartitionOffset) *burrowOffset {
+	if po.Offset == nil || po.Lag == nil {
+		return nil
+	}
+	return &burrowOffset{Offset: *po.Offset, Timestamp: timestamp * 1000, Lag: *po.Lag}
+}
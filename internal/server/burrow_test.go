@@ -0,0 +1,256 @@
+package server_test
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/bsm/rumour/internal/rumour"
+	"github.com/bsm/rumour/internal/server"
+	"github.com/go-chi/httplog"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Burrow", func() {
+	var handler http.Handler
+
+	BeforeEach(func() {
+		state := rumour.NewState([]string{"main"})
+		cluster := state.Cluster("main")
+		cluster.UpdateBrokers([]string{"10.0.0.1:9092"})
+
+		// orders-worker catches up, partition 2 is uncommitted
+		cluster.UpdateTopic("orders", []int64{100, 100, 100})
+		cluster.UpdateConsumerOffsets("orders-worker", "orders", 1515151515, []int64{40, 90})
+		cluster.UpdateConsumerOffsets("orders-worker", "orders", 1515151525, []int64{50, 95})
+
+		// payments-worker falls behind
+		cluster.UpdateTopic("payments", []int64{50})
+		cluster.UpdateConsumerOffsets("payments-worker", "payments", 1515151515, []int64{45})
+		cluster.UpdateTopic("payments", []int64{60})
+		cluster.UpdateConsumerOffsets("payments-worker", "payments", 1515151525, []int64{46})
+
+		handler = server.NewHTTP(":0", state, nil, server.Options{
+			Log:    httplog.Options{LogLevel: "error"},
+			Burrow: true,
+		}).Handler
+	})
+
+	DescribeTable("responses",
+		func(path string, status int, fixture string) {
+			host, err := os.Hostname()
+			Expect(err).NotTo(HaveOccurred())
+
+			res := serve(handler, http.MethodGet, path, "")
+			Expect(res.Code).To(Equal(status))
+			Expect(res.Header().Get("Content-Type")).To(Equal("application/json"))
+			Expect(res.Body.String()).To(MatchJSON(strings.NewReplacer("$URL", path, "$HOST", host).Replace(fixture)))
+		},
+		Entry("cluster list", "/v3/kafka/", http.StatusOK, `{
+			"error": false,
+			"message": "cluster list returned",
+			"clusters": ["main"],
+			"request": {"url": "$URL", "host": "$HOST"}
+		}`),
+		Entry("cluster detail", "/v3/kafka/main", http.StatusOK, `{
+			"error": false,
+			"message": "cluster module detail returned",
+			"module": {
+				"class-name": "kafka",
+				"servers": ["10.0.0.1:9092"],
+				"client-profile": {"name": "rumour", "client-id": "rumour", "kafka-version": "0.10.0.0"}
+			},
+			"request": {"url": "$URL", "host": "$HOST"}
+		}`),
+		Entry("missing cluster", "/v3/kafka/missing", http.StatusNotFound, `{
+			"error": true,
+			"message": "cluster not found",
+			"request": {"url": "$URL", "host": "$HOST"}
+		}`),
+		Entry("topic list", "/v3/kafka/main/topic", http.StatusOK, `{
+			"error": false,
+			"message": "topic list returned",
+			"topics": ["orders", "payments"],
+			"request": {"url": "$URL", "host": "$HOST"}
+		}`),
+		Entry("topic detail", "/v3/kafka/main/topic/orders", http.StatusOK, `{
+			"error": false,
+			"message": "topic offsets returned",
+			"offsets": [100, 100, 100],
+			"request": {"url": "$URL", "host": "$HOST"}
+		}`),
+		Entry("missing topic", "/v3/kafka/main/topic/missing", http.StatusNotFound, `{
+			"error": true,
+			"message": "topic not found",
+			"request": {"url": "$URL", "host": "$HOST"}
+		}`),
+		Entry("consumer list", "/v3/kafka/main/consumer", http.StatusOK, `{
+			"error": false,
+			"message": "consumer list returned",
+			"consumers": ["orders-worker", "payments-worker"],
+			"request": {"url": "$URL", "host": "$HOST"}
+		}`),
+		Entry("consumer detail", "/v3/kafka/main/consumer/orders-worker", http.StatusOK, `{
+			"error": false,
+			"message": "consumer detail returned",
+			"topics": {
+				"orders": [
+					{
+						"offsets": [{"offset": 50, "timestamp": 1515151525000, "lag": 50}],
+						"owner": "",
+						"client_id": "",
+						"current-lag": 50
+					},
+					{
+						"offsets": [{"offset": 95, "timestamp": 1515151525000, "lag": 5}],
+						"owner": "",
+						"client_id": "",
+						"current-lag": 5
+					},
+					{
+						"offsets": [],
+						"owner": "",
+						"client_id": "",
+						"current-lag": 0
+					}
+				]
+			},
+			"request": {"url": "$URL", "host": "$HOST"}
+		}`),
+		Entry("missing consumer", "/v3/kafka/main/consumer/missing", http.StatusNotFound, `{
+			"error": true,
+			"message": "consumer group not found",
+			"request": {"url": "$URL", "host": "$HOST"}
+		}`),
+		Entry("consumer status", "/v3/kafka/main/consumer/orders-worker/status", http.StatusOK, `{
+			"error": false,
+			"message": "consumer status returned",
+			"status": {
+				"cluster": "main",
+				"group": "orders-worker",
+				"status": "OK",
+				"complete": 0.2,
+				"partitions": [],
+				"partition_count": 2,
+				"maxlag": {
+					"topic": "orders",
+					"partition": 0,
+					"owner": "",
+					"client_id": "",
+					"status": "OK",
+					"start": {"offset": 50, "timestamp": 1515151525000, "lag": 50},
+					"end": {"offset": 50, "timestamp": 1515151525000, "lag": 50},
+					"current_lag": 50,
+					"complete": 0.2
+				},
+				"totallag": 55
+			},
+			"request": {"url": "$URL", "host": "$HOST"}
+		}`),
+		Entry("consumer lag", "/v3/kafka/main/consumer/orders-worker/lag", http.StatusOK, `{
+			"error": false,
+			"message": "consumer status returned",
+			"status": {
+				"cluster": "main",
+				"group": "orders-worker",
+				"status": "OK",
+				"complete": 0.2,
+				"partitions": [
+					{
+						"topic": "orders",
+						"partition": 0,
+						"owner": "",
+						"client_id": "",
+						"status": "OK",
+						"start": {"offset": 50, "timestamp": 1515151525000, "lag": 50},
+						"end": {"offset": 50, "timestamp": 1515151525000, "lag": 50},
+						"current_lag": 50,
+						"complete": 0.2
+					},
+					{
+						"topic": "orders",
+						"partition": 1,
+						"owner": "",
+						"client_id": "",
+						"status": "OK",
+						"start": {"offset": 95, "timestamp": 1515151525000, "lag": 5},
+						"end": {"offset": 95, "timestamp": 1515151525000, "lag": 5},
+						"current_lag": 5,
+						"complete": 0.2
+					}
+				],
+				"partition_count": 2,
+				"maxlag": {
+					"topic": "orders",
+					"partition": 0,
+					"owner": "",
+					"client_id": "",
+					"status": "OK",
+					"start": {"offset": 50, "timestamp": 1515151525000, "lag": 50},
+					"end": {"offset": 50, "timestamp": 1515151525000, "lag": 50},
+					"current_lag": 50,
+					"complete": 0.2
+				},
+				"totallag": 55
+			},
+			"request": {"url": "$URL", "host": "$HOST"}
+		}`),
+		Entry("growing consumer status", "/v3/kafka/main/consumer/payments-worker/status", http.StatusOK, `{
+			"error": false,
+			"message": "consumer status returned",
+			"status": {
+				"cluster": "main",
+				"group": "payments-worker",
+				"status": "WARN",
+				"complete": 0.2,
+				"partitions": [
+					{
+						"topic": "payments",
+						"partition": 0,
+						"owner": "",
+						"client_id": "",
+						"status": "WARN",
+						"start": {"offset": 46, "timestamp": 1515151525000, "lag": 14},
+						"end": {"offset": 46, "timestamp": 1515151525000, "lag": 14},
+						"current_lag": 14,
+						"complete": 0.2
+					}
+				],
+				"partition_count": 1,
+				"maxlag": {
+					"topic": "payments",
+					"partition": 0,
+					"owner": "",
+					"client_id": "",
+					"status": "WARN",
+					"start": {"offset": 46, "timestamp": 1515151525000, "lag": 14},
+					"end": {"offset": 46, "timestamp": 1515151525000, "lag": 14},
+					"current_lag": 14,
+					"complete": 0.2
+				},
+				"totallag": 14
+			},
+			"request": {"url": "$URL", "host": "$HOST"}
+		}`),
+		Entry("missing consumer status", "/v3/kafka/main/consumer/missing/status", http.StatusNotFound, `{
+			"error": true,
+			"message": "consumer group not found",
+			"request": {"url": "$URL", "host": "$HOST"}
+		}`),
+	)
+
+	It("should serve health checks", func() {
+		res := serve(handler, http.MethodGet, "/burrow/admin", "")
+		Expect(res.Code).To(Equal(http.StatusOK))
+		Expect(res.Body.String()).To(Equal("GOOD"))
+	})
+
+	It("should be disabled by default", func() {
+		handler = server.NewHTTP(":0", rumour.NewState([]string{"main"}), nil, server.Options{
+			Log: httplog.Options{LogLevel: "error"},
+		}).Handler
+		Expect(serve(handler, http.MethodGet, "/v3/kafka/", "").Code).To(Equal(http.StatusNotFound))
+	})
+})
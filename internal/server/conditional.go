@@ -0,0 +1,178 @@
+package server
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bsm/rumour/internal/rumour"
+)
+
+// conditional answers conditional GET requests with 304 Not Modified.
+// Cluster resources are validated by revisionValidators, all other
+// responses are buffered and receive an ETag derived from the request URI,
+// the paused status and the response body. Validators are only sent once
+// the resource is known to exist and to be in scope.
+func conditional(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &conditionalWriter{ResponseWriter: w}
+		next.ServeHTTP(cw, r)
+
+		switch cw.status {
+		case http.StatusOK:
+		case http.StatusNotModified:
+			w.Header().Del("Content-Type")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		default:
+			w.Header().Del("ETag")
+			w.Header().Del("Last-Modified")
+			cw.flush()
+			return
+		}
+
+		etag := w.Header().Get("ETag")
+		if etag == "" {
+			hash := fnv.New64a()
+			_, _ = hash.Write([]byte(r.URL.RequestURI()))
+			_, _ = hash.Write([]byte{0})
+			_, _ = hash.Write([]byte(w.Header().Get(PausedHeader)))
+			_, _ = hash.Write([]byte{0})
+			_, _ = hash.Write(cw.buf.Bytes())
+			etag = `W/"` + strconv.FormatUint(hash.Sum64(), 16) + `"`
+			w.Header().Set("ETag", etag)
+		}
+
+		modified, _ := http.ParseTime(w.Header().Get("Last-Modified"))
+		if isNotModified(r, etag, modified) {
+			w.Header().Del("Content-Type")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		cw.flush()
+	})
+}
+
+// revisionValidators sets the ETag and Last-Modified headers of cluster
+// resources, derived from the revision of the cluster state, and answers
+// requests listing the ETag in If-None-Match without calling the handler.
+// It must be wrapped by conditional.
+func revisionValidators(s *rumour.State) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, state := lookupCluster(s, r)
+			if state == nil || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			revision, modified := state.Revision()
+			etag := revisionETag(r, revision, w.Header().Get(PausedHeader))
+			w.Header().Set("ETag", etag)
+			if !modified.IsZero() {
+				w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+			}
+
+			if listsETag(r, etag) {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// etagKey keys revision ETags, so they cannot be derived by clients to probe
+// resources and are invalidated by restarts, which reset revisions.
+var etagKey = func() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return key
+}()
+
+// revisionETag derives an ETag from the request URI, the credential scope,
+// the paused status and the revision of a cluster.
+func revisionETag(r *http.Request, revision uint64, paused string) string {
+	mac := hmac.New(sha256.New, etagKey)
+	_, _ = mac.Write([]byte(r.URL.RequestURI()))
+	_, _ = mac.Write([]byte{0})
+	_, _ = mac.Write([]byte(scopeOf(r).String()))
+	_, _ = mac.Write([]byte{0})
+	_, _ = mac.Write([]byte(paused))
+	_, _ = mac.Write([]byte{0})
+	_, _ = mac.Write([]byte(strconv.FormatUint(revision, 10)))
+	return `W/"` + hex.EncodeToString(mac.Sum(nil)[:8]) + `"`
+}
+
+// listsETag returns true if the If-None-Match header of the request lists
+// the ETag, ignoring wildcards.
+func listsETag(r *http.Request, etag string) bool {
+	for _, tag := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+func isNotModified(r *http.Request, etag string, modified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			if strings.TrimSpace(tag) == "*" {
+				return true
+			}
+		}
+		return listsETag(r, etag)
+	}
+
+	if modified.IsZero() {
+		return false
+	}
+	if ims, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil {
+		return !modified.Truncate(time.Second).After(ims)
+	}
+	return false
+}
+
+// conditionalWriter buffers a response.
+type conditionalWriter struct {
+	http.ResponseWriter
+	status int
+	buf    bytes.Buffer
+}
+
+func (w *conditionalWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *conditionalWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.buf.Write(p)
+}
+
+// flush writes the buffered response.
+func (w *conditionalWriter) flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+}
@@ -0,0 +1,169 @@
+package server_test
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/bsm/rumour/internal/alert"
+	"github.com/bsm/rumour/internal/rumour"
+	"github.com/bsm/rumour/internal/server"
+	"github.com/go-chi/httplog"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Conditional requests", func() {
+	var handler http.Handler
+	var state *rumour.State
+
+	get := func(path string, headers ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+
+	BeforeEach(func() {
+		state = rumour.NewState([]string{"main"})
+		cluster := state.Cluster("main")
+		cluster.UpdateTopic("orders", []int64{100})
+		cluster.UpdateConsumerOffsets("orders-worker", "orders", 1515151515, []int64{40})
+
+		alerts, err := alert.NewEngine(state, &alert.Config{
+			Clusters: map[string]alert.ClusterConfig{"main": {Rules: []alert.Rule{
+				{Name: "lagging", Warn: alert.Threshold{TotalLag: 50}},
+			}}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		alerts.Evaluate(time.Now())
+
+		handler = server.NewHTTP(":0", state, alerts, server.Options{
+			Log: httplog.Options{LogLevel: "error"},
+		}).Handler
+	})
+
+	DescribeTable("ETags",
+		func(path string) {
+			res := get(path)
+			Expect(res.Code).To(Equal(http.StatusOK))
+			etag := res.Header().Get("ETag")
+			Expect(etag).To(HavePrefix(`W/"`))
+
+			res = get(path, "If-None-Match", etag)
+			Expect(res.Code).To(Equal(http.StatusNotModified))
+			Expect(res.Body.Len()).To(BeZero())
+			Expect(res.Header().Get("Content-Type")).To(BeEmpty())
+
+			res = get(path, "If-None-Match", `W/"other"`)
+			Expect(res.Code).To(Equal(http.StatusOK))
+			Expect(res.Header().Get("ETag")).To(Equal(etag))
+		},
+		Entry(nil, "/v1/clusters"),
+		Entry(nil, "/v1/clusters/main"),
+		Entry(nil, "/v1/clusters/main/topics"),
+		Entry(nil, "/v1/clusters/main/consumers/orders-worker"),
+		Entry(nil, "/v1/clusters/main/consumers/top"),
+		Entry(nil, "/v1/top"),
+		Entry(nil, "/v1/alerts"),
+		Entry(nil, "/v1/silences"),
+		Entry(nil, "/v2/clusters"),
+		Entry(nil, "/v2/clusters/main/consumers/orders-worker"),
+	)
+
+	It("should include the route and query in the ETag", func() {
+		a := get("/v1/clusters/main/consumers?limit=1").Header().Get("ETag")
+		b := get("/v1/clusters/main/consumers?limit=2").Header().Get("ETag")
+		c := get("/v2/clusters/main/consumers?limit=1").Header().Get("ETag")
+		Expect(a).NotTo(BeEmpty())
+		Expect(a).NotTo(Equal(b))
+		Expect(a).NotTo(Equal(c))
+	})
+
+	It("should change ETags with the state", func() {
+		etag := get("/v1/clusters/main/consumers/orders-worker").Header().Get("ETag")
+		state.Cluster("main").UpdateConsumerOffsets("orders-worker", "orders", 1515151525, []int64{50})
+		res := get("/v1/clusters/main/consumers/orders-worker", "If-None-Match", etag)
+		Expect(res.Code).To(Equal(http.StatusOK))
+		Expect(res.Header().Get("ETag")).NotTo(Equal(etag))
+	})
+
+	It("should derive ETags of cluster resources from the revision", func() {
+		res := get("/v1/clusters/main/topics/orders")
+		etag, modified := res.Header().Get("ETag"), res.Header().Get("Last-Modified")
+
+		// refreshed without changes
+		state.Cluster("main").UpdateTopic("orders", []int64{100})
+		res = get("/v1/clusters/main/topics/orders", "If-None-Match", etag)
+		Expect(res.Code).To(Equal(http.StatusNotModified))
+		Expect(res.Header().Get("Last-Modified")).To(Equal(modified))
+
+		// changes to other resources of the cluster
+		state.Cluster("main").UpdateTopic("payments", []int64{100})
+		res = get("/v1/clusters/main/topics/orders", "If-None-Match", etag)
+		Expect(res.Code).To(Equal(http.StatusOK))
+		Expect(res.Header().Get("ETag")).NotTo(Equal(etag))
+	})
+
+	It("should support If-Modified-Since", func() {
+		res := get("/v1/clusters/main")
+		modified := res.Header().Get("Last-Modified")
+		Expect(modified).NotTo(BeEmpty())
+
+		res = get("/v1/clusters/main", "If-Modified-Since", modified)
+		Expect(res.Code).To(Equal(http.StatusNotModified))
+
+		res = get("/v1/clusters/main", "If-Modified-Since", time.Unix(0, 0).UTC().Format(http.TimeFormat))
+		Expect(res.Code).To(Equal(http.StatusOK))
+
+		// rankings change over time
+		Expect(get("/v1/clusters/main/consumers/top").Header().Get("Last-Modified")).To(BeEmpty())
+	})
+
+	It("should only answer 304 for existing resources", func() {
+		for _, path := range []string{
+			"/v1/clusters/missing",
+			"/v1/clusters/main/topics/missing",
+			"/v1/clusters/main/consumers/missing",
+			"/v2/clusters/main/consumers/missing/history",
+			"/v1/silences/missing",
+		} {
+			res := get(path, "If-None-Match", "*")
+			Expect(res.Code).To(Equal(http.StatusNotFound), path)
+			Expect(res.Header().Get("ETag")).To(BeEmpty(), path)
+			Expect(res.Header().Get("Last-Modified")).To(BeEmpty(), path)
+		}
+
+		res := get("/v1/clusters/main/topics/orders", "If-None-Match", "*")
+		Expect(res.Code).To(Equal(http.StatusNotModified))
+	})
+
+	It("should compress responses", func() {
+		res := get("/v1/clusters/main/consumers/orders-worker", "Accept-Encoding", "gzip")
+		Expect(res.Code).To(Equal(http.StatusOK))
+		Expect(res.Header().Get("Content-Encoding")).To(Equal("gzip"))
+
+		rd, err := gzip.NewReader(res.Body)
+		Expect(err).NotTo(HaveOccurred())
+		data, err := io.ReadAll(rd)
+		Expect(err).NotTo(HaveOccurred())
+
+		var detail server.ConsumerDetail
+		Expect(json.Unmarshal(data, &detail)).To(Succeed())
+		Expect(detail.Consumer).To(Equal("orders-worker"))
+
+		etag := res.Header().Get("ETag")
+		Expect(etag).To(Equal(get("/v1/clusters/main/consumers/orders-worker").Header().Get("ETag")))
+
+		res = get("/v1/clusters/main/consumers/orders-worker", "Accept-Encoding", "gzip", "If-None-Match", etag)
+		Expect(res.Code).To(Equal(http.StatusNotModified))
+		Expect(res.Body.Len()).To(BeZero())
+	})
+})
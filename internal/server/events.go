@@ -0,0 +1,87 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bsm/rumour/internal/rumour"
+)
+
+// eventsKeepAlive is the interval at which keep-alive comments are sent to
+// idle event streams.
+const eventsKeepAlive = 15 * time.Second
+
+func streamEvents(s *rumour.State) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filter := rumour.EventFilter{
+			Cluster: r.URL.Query().Get("cluster"),
+			Group:   r.URL.Query().Get("consumer"),
+			Topic:   r.URL.Query().Get("topic"),
+		}
+		sc := scopeOf(r)
+		if filter.Cluster != "" && (s.Cluster(filter.Cluster) == nil || !sc.cluster(filter.Cluster)) {
+			writeError(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			writeError(w, "streaming not supported", http.StatusInternalServerError)
+			return
+		}
+
+		// streams outlive the server's write timeout
+		clearWriteDeadline(r)
+
+		sub := s.Subscribe(filter, 256)
+		defer sub.Close()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		ticker := time.NewTicker(eventsKeepAlive)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-ticker.C:
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+			case <-sub.Lagged:
+				// events were dropped, tell clients to resync
+				if err := writeEvent(w, rumour.Event{
+					Type:      rumour.EventLagged,
+					Cluster:   filter.Cluster,
+					Timestamp: time.Now().Unix(),
+				}); err != nil {
+					return
+				}
+			case ev := <-sub.C:
+				if !sc.event(ev) {
+					continue
+				}
+				if err := writeEvent(w, ev); err != nil {
+					return
+				}
+			}
+			flusher.Flush()
+		}
+	})
+}
+
+func writeEvent(w http.ResponseWriter, ev rumour.Event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data)
+	return err
+}
@@ -0,0 +1,79 @@
+package server_test
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/bsm/rumour/internal/rumour"
+	"github.com/bsm/rumour/internal/server"
+	"github.com/go-chi/httplog"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Events", func() {
+	var state *rumour.State
+	var srv *httptest.Server
+
+	BeforeEach(func() {
+		state = rumour.NewState([]string{"main"})
+		state.Cluster("main").UpdateTopic("orders", []int64{100})
+
+		srv = httptest.NewUnstartedServer(nil)
+		srv.Config = server.NewHTTP("", state, nil, server.Options{
+			Log: httplog.Options{LogLevel: "error"},
+		})
+		srv.Config.WriteTimeout = 100 * time.Millisecond
+		srv.Start()
+	})
+
+	AfterEach(func() {
+		srv.Close()
+	})
+
+	It("should keep streams open beyond the write timeout", func() {
+		res, err := http.Get(srv.URL + "/v1/events?cluster=main")
+		Expect(err).NotTo(HaveOccurred())
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		Expect(res.Header.Get("Content-Type")).To(Equal("text/event-stream"))
+
+		time.Sleep(300 * time.Millisecond)
+		state.Cluster("main").UpdateConsumerOffsets("orders-worker", "orders", 1515151515, []int64{40})
+
+		// the stream must survive the timeout to deliver the update
+		rd := bufio.NewReader(res.Body)
+		for {
+			line, err := rd.ReadString('\n')
+			Expect(err).NotTo(HaveOccurred())
+			if line == "event: consumer_offsets\n" {
+				break
+			}
+		}
+	})
+
+	It("should notify clients that cannot keep up", func() {
+		res, err := http.Get(srv.URL + "/v1/events?cluster=main")
+		Expect(err).NotTo(HaveOccurred())
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+
+		// publish more than the connection can buffer while not reading
+		offsets := make([]int64, 1000)
+		for i := 0; i < 2000; i++ {
+			state.Cluster("main").UpdateTopic("orders", offsets)
+		}
+
+		rd := bufio.NewReader(res.Body)
+		for {
+			line, err := rd.ReadString('\n')
+			Expect(err).NotTo(HaveOccurred())
+			if line == "event: lagged\n" {
+				break
+			}
+		}
+	})
+})
@@ -0,0 +1,296 @@
+package server
+
+import (
+	"context"
+
+	"github.com/bsm/rumour/internal/rumour"
+	"github.com/bsm/rumour/rumourpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// NewGRPC inits a gRPC server. If auth is set, clients must pass their
+// credentials as "authorization" metadata, in the same format as the HTTP
+// Authorization header.
+func NewGRPC(state *rumour.State, auth *Auth, opts ...grpc.ServerOption) *grpc.Server {
+	if auth != nil {
+		opts = append(opts,
+			grpc.ChainUnaryInterceptor(grpcUnaryAuth(auth)),
+			grpc.ChainStreamInterceptor(grpcStreamAuth(auth)),
+		)
+	}
+
+	srv := grpc.NewServer(opts...)
+	rumourpb.RegisterRumourServer(srv, &grpcService{state: state})
+	return srv
+}
+
+func grpcUnaryAuth(auth *Auth) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := grpcAuthenticate(ctx, auth)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func grpcStreamAuth(auth *Auth) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := grpcAuthenticate(ss.Context(), auth)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &grpcServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// grpcAuthenticate validates the credentials of a call and stores them in
+// the returned context.
+func grpcAuthenticate(ctx context.Context, auth *Auth) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "unauthorized")
+	}
+
+	cred, ok := auth.check(values[0])
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "unauthorized")
+	}
+	return context.WithValue(ctx, credentialContextKey{}, cred), nil
+}
+
+// grpcServerStream overrides the context of a stream.
+type grpcServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *grpcServerStream) Context() context.Context { return s.ctx }
+
+type grpcService struct {
+	rumourpb.UnimplementedRumourServer
+	state *rumour.State
+}
+
+func (g *grpcService) ListClusters(ctx context.Context, _ *rumourpb.ListClustersRequest) (*rumourpb.ListClustersResponse, error) {
+	return &rumourpb.ListClustersResponse{Clusters: scopeFrom(ctx).clusterNames(g.state.Clusters())}, nil
+}
+
+func (g *grpcService) GetCluster(ctx context.Context, req *rumourpb.GetClusterRequest) (*rumourpb.Cluster, error) {
+	state, err := g.cluster(ctx, req.Cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	sc := scopeFrom(ctx)
+	return &rumourpb.Cluster{
+		Name:      req.Cluster,
+		Brokers:   state.Brokers(),
+		Topics:    sc.topicNames(state.Topics()),
+		Consumers: sc.groupNames(state.ConsumerGroups()),
+	}, nil
+}
+
+func (g *grpcService) ListTopics(ctx context.Context, req *rumourpb.ListTopicsRequest) (*rumourpb.ListTopicsResponse, error) {
+	state, err := g.cluster(ctx, req.Cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rumourpb.ListTopicsResponse{Topics: scopeFrom(ctx).topicNames(state.Topics())}, nil
+}
+
+func (g *grpcService) GetTopic(ctx context.Context, req *rumourpb.GetTopicRequest) (*rumourpb.Topic, error) {
+	state, err := g.cluster(ctx, req.Cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	partitions, ok := state.TopicPartitions(req.Topic)
+	if !ok || !scopeFrom(ctx).topic(req.Topic) {
+		return nil, status.Error(codes.NotFound, "topic not found")
+	}
+
+	res := &rumourpb.Topic{
+		Cluster:    req.Cluster,
+		Name:       req.Topic,
+		Partitions: make([]*rumourpb.TopicPartition, len(partitions)),
+	}
+	for i, tp := range partitions {
+		res.Partitions[i] = &rumourpb.TopicPartition{
+			Partition:    int32(tp.Partition),
+			LogEndOffset: tp.LogEndOffset,
+		}
+	}
+	return res, nil
+}
+
+func (g *grpcService) ListConsumers(ctx context.Context, req *rumourpb.ListConsumersRequest) (*rumourpb.ListConsumersResponse, error) {
+	state, err := g.cluster(ctx, req.Cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	sc := scopeFrom(ctx)
+	consumers := state.AllConsumerPartitions()
+	res := &rumourpb.ListConsumersResponse{
+		Consumers: make([]*rumourpb.ConsumerGroup, 0, len(consumers)),
+	}
+	for _, c := range consumers {
+		if !sc.group(c.Group) {
+			continue
+		}
+		if sc != nil {
+			c = c.FilterTopics(sc.topic)
+		}
+		res.Consumers = append(res.Consumers, &rumourpb.ConsumerGroup{
+			Group:   c.Group,
+			Summary: lagSummaryPB(c.Summary),
+		})
+	}
+	return res, nil
+}
+
+func (g *grpcService) GetConsumer(ctx context.Context, req *rumourpb.GetConsumerRequest) (*rumourpb.Consumer, error) {
+	state, err := g.cluster(ctx, req.Cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	sc := scopeFrom(ctx)
+	cp, ok := state.ConsumerPartitions(req.Group)
+	if !ok || !sc.group(req.Group) {
+		return nil, status.Error(codes.NotFound, "consumer group not found")
+	}
+	if sc != nil {
+		cp = cp.FilterTopics(sc.topic)
+	}
+
+	res := &rumourpb.Consumer{
+		Cluster: req.Cluster,
+		Group:   req.Group,
+		Topics:  make([]*rumourpb.ConsumerTopic, len(cp.Topics)),
+		Summary: lagSummaryPB(cp.Summary),
+	}
+	for i, ct := range cp.Topics {
+		topic := &rumourpb.ConsumerTopic{
+			Topic:      ct.Topic,
+			Timestamp:  ct.Timestamp,
+			Partitions: make([]*rumourpb.PartitionOffset, len(ct.Partitions)),
+			Summary:    lagSummaryPB(ct.Summary),
+		}
+		for j, po := range ct.Partitions {
+			topic.Partitions[j] = &rumourpb.PartitionOffset{
+				Partition:    int32(po.Partition),
+				Offset:       po.Offset,
+				LogEndOffset: po.LogEndOffset,
+				Lag:          po.Lag,
+			}
+		}
+		res.Topics[i] = topic
+	}
+	return res, nil
+}
+
+func (g *grpcService) WatchConsumerLag(req *rumourpb.WatchConsumerLagRequest, stream rumourpb.Rumour_WatchConsumerLagServer) error {
+	ctx := stream.Context()
+	sc := scopeFrom(ctx)
+	clusters := sc.clusterNames(g.state.Clusters())
+	if req.Cluster != "" {
+		if _, err := g.cluster(ctx, req.Cluster); err != nil {
+			return err
+		}
+		clusters = []string{req.Cluster}
+	}
+
+	// subscribe before taking the snapshot to avoid missing updates
+	sub := g.state.Subscribe(rumour.EventFilter{
+		Cluster: req.Cluster,
+		Group:   req.Group,
+		Topic:   req.Topic,
+	}, 256)
+	defer sub.Close()
+
+	for _, cluster := range clusters {
+		cs := g.state.Cluster(cluster)
+		if cs == nil {
+			continue
+		}
+		for _, c := range cs.Consumers() {
+			if (req.Group != "" && req.Group != c.Group) || !sc.group(c.Group) {
+				continue
+			}
+			for _, ct := range c.Topics {
+				if (req.Topic != "" && req.Topic != ct.Topic) || !sc.topic(ct.Topic) {
+					continue
+				}
+				if err := stream.Send(&rumourpb.ConsumerLag{
+					Cluster:   cluster,
+					Group:     c.Group,
+					Topic:     ct.Topic,
+					Timestamp: ct.Timestamp,
+					Summary:   lagSummaryPB(ct.Summary),
+				}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-sub.Lagged:
+			return status.Error(codes.Aborted, "updates were dropped, resubscribe")
+		case ev := <-sub.C:
+			if ev.Type != rumour.EventConsumerOffsets || ev.Summary == nil || !sc.event(ev) {
+				continue
+			}
+			if err := stream.Send(&rumourpb.ConsumerLag{
+				Cluster:   ev.Cluster,
+				Group:     ev.Group,
+				Topic:     ev.Topic,
+				Timestamp: ev.Timestamp,
+				Summary:   lagSummaryPB(*ev.Summary),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// grpcPausedHeader marks responses of paused clusters.
+const grpcPausedHeader = "rumour-cluster-paused"
+
+// cluster returns the state of a cluster, out of scope clusters are not
+// found. Responses of paused clusters are marked with the grpcPausedHeader.
+func (g *grpcService) cluster(ctx context.Context, name string) (*rumour.ClusterState, error) {
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "cluster is required")
+	}
+
+	state := g.state.Cluster(name)
+	if state == nil || !scopeFrom(ctx).cluster(name) {
+		return nil, status.Error(codes.NotFound, "cluster not found")
+	}
+	if state.Paused() {
+		_ = grpc.SetHeader(ctx, metadata.Pairs(grpcPausedHeader, "true"))
+	}
+	return state, nil
+}
+
+func lagSummaryPB(s rumour.LagSummary) *rumourpb.LagSummary {
+	return &rumourpb.LagSummary{
+		TotalLag:              s.TotalLag,
+		MaxLag:                s.MaxLag,
+		MaxLagTopic:           s.MaxLagTopic,
+		MaxLagPartition:       int32(s.MaxLagPartition),
+		Partitions:            int32(s.Partitions),
+		UncommittedPartitions: int32(s.Uncommitted),
+	}
+}
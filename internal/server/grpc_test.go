@@ -0,0 +1,338 @@
+package server_test
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bsm/rumour/internal/rumour"
+	"github.com/bsm/rumour/internal/server"
+	"github.com/bsm/rumour/rumourpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("GRPC", func() {
+	var subject rumourpb.RumourClient
+	var state *rumour.State
+	var srv *grpc.Server
+	var conn *grpc.ClientConn
+	var ctx = context.Background()
+
+	BeforeEach(func() {
+		state = rumour.NewState([]string{"main"})
+		cluster := state.Cluster("main")
+		cluster.UpdateBrokers([]string{"10.0.0.1:9092"})
+		cluster.UpdateTopic("orders", []int64{100, 100, rumour.OffsetUnknown})
+		cluster.UpdateTopic("payments", []int64{50})
+		cluster.UpdateConsumerOffsets("orders-worker", "orders", 1515151515, []int64{40, rumour.OffsetUnknown})
+		cluster.UpdateConsumerOffsets("payments-worker", "payments", 1515151515, []int64{45})
+
+		lis := bufconn.Listen(1024 * 1024)
+		srv = server.NewGRPC(state, nil)
+		go func() { _ = srv.Serve(lis) }()
+
+		var err error
+		conn, err = grpc.Dial("bufnet",
+			grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+			grpc.WithInsecure(),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		subject = rumourpb.NewRumourClient(conn)
+	})
+
+	AfterEach(func() {
+		Expect(conn.Close()).To(Succeed())
+		srv.Stop()
+	})
+
+	It("should list clusters", func() {
+		res, err := subject.ListClusters(ctx, &rumourpb.ListClustersRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Clusters).To(Equal([]string{"main"}))
+	})
+
+	It("should get clusters", func() {
+		res, err := subject.GetCluster(ctx, &rumourpb.GetClusterRequest{Cluster: "main"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Name).To(Equal("main"))
+		Expect(res.Brokers).To(Equal([]string{"10.0.0.1:9092"}))
+		Expect(res.Topics).To(Equal([]string{"orders", "payments"}))
+		Expect(res.Consumers).To(Equal([]string{"orders-worker", "payments-worker"}))
+
+		_, err = subject.GetCluster(ctx, &rumourpb.GetClusterRequest{Cluster: "missing"})
+		Expect(status.Code(err)).To(Equal(codes.NotFound))
+
+		_, err = subject.GetCluster(ctx, &rumourpb.GetClusterRequest{})
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+	})
+
+	It("should list and get topics", func() {
+		topics, err := subject.ListTopics(ctx, &rumourpb.ListTopicsRequest{Cluster: "main"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(topics.Topics).To(Equal([]string{"orders", "payments"}))
+
+		topic, err := subject.GetTopic(ctx, &rumourpb.GetTopicRequest{Cluster: "main", Topic: "orders"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(topic.Name).To(Equal("orders"))
+		Expect(topic.Partitions).To(HaveLen(3))
+		Expect(topic.Partitions[1].GetLogEndOffset()).To(Equal(int64(100)))
+		Expect(topic.Partitions[2].LogEndOffset).To(BeNil())
+
+		_, err = subject.GetTopic(ctx, &rumourpb.GetTopicRequest{Cluster: "main", Topic: "missing"})
+		Expect(status.Code(err)).To(Equal(codes.NotFound))
+	})
+
+	It("should list and get consumers", func() {
+		consumers, err := subject.ListConsumers(ctx, &rumourpb.ListConsumersRequest{Cluster: "main"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(consumers.Consumers).To(HaveLen(2))
+		Expect(consumers.Consumers[0].Group).To(Equal("orders-worker"))
+		Expect(consumers.Consumers[0].Summary.TotalLag).To(Equal(int64(60)))
+		Expect(consumers.Consumers[1].Group).To(Equal("payments-worker"))
+		Expect(consumers.Consumers[1].Summary.TotalLag).To(Equal(int64(5)))
+
+		consumer, err := subject.GetConsumer(ctx, &rumourpb.GetConsumerRequest{Cluster: "main", Group: "orders-worker"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(consumer.Summary.TotalLag).To(Equal(int64(60)))
+		Expect(consumer.Topics).To(HaveLen(1))
+		Expect(consumer.Topics[0].Topic).To(Equal("orders"))
+		Expect(consumer.Topics[0].Timestamp).To(Equal(int64(1515151515)))
+		Expect(consumer.Topics[0].Partitions).To(HaveLen(3))
+		Expect(consumer.Topics[0].Partitions[0].GetOffset()).To(Equal(int64(40)))
+		Expect(consumer.Topics[0].Partitions[0].GetLag()).To(Equal(int64(60)))
+		Expect(consumer.Topics[0].Partitions[1].Offset).To(BeNil())
+
+		_, err = subject.GetConsumer(ctx, &rumourpb.GetConsumerRequest{Cluster: "main", Group: "missing"})
+		Expect(status.Code(err)).To(Equal(codes.NotFound))
+	})
+
+	It("should watch consumer lag", func() {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		stream, err := subject.WatchConsumerLag(ctx, &rumourpb.WatchConsumerLagRequest{Topic: "payments"})
+		Expect(err).NotTo(HaveOccurred())
+
+		lag, err := stream.Recv()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lag.Cluster).To(Equal("main"))
+		Expect(lag.Group).To(Equal("payments-worker"))
+		Expect(lag.Summary.TotalLag).To(Equal(int64(5)))
+
+		cluster := state.Cluster("main")
+		cluster.UpdateConsumerOffsets("orders-worker", "orders", 1515151525, []int64{50, 60})
+		cluster.UpdateConsumerOffsets("payments-worker", "payments", 1515151525, []int64{48})
+
+		lag, err = stream.Recv()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lag.Group).To(Equal("payments-worker"))
+		Expect(lag.Timestamp).To(Equal(int64(1515151525)))
+		Expect(lag.Summary.TotalLag).To(Equal(int64(2)))
+
+		stream, err = subject.WatchConsumerLag(ctx, &rumourpb.WatchConsumerLagRequest{Cluster: "missing"})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = stream.Recv()
+		Expect(status.Code(err)).To(Equal(codes.NotFound))
+	})
+
+	It("should abort watches that cannot keep up", func() {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		stream, err := subject.WatchConsumerLag(ctx, &rumourpb.WatchConsumerLagRequest{Topic: "payments"})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = stream.Recv()
+		Expect(err).NotTo(HaveOccurred())
+
+		// publish more than the stream can buffer while not receiving
+		for i := 0; i < 20000; i++ {
+			state.Cluster("main").UpdateConsumerOffsets("payments-worker", "payments", int64(1515151525+i), []int64{48})
+		}
+
+		for {
+			if _, err = stream.Recv(); err != nil {
+				break
+			}
+		}
+		Expect(status.Code(err)).To(Equal(codes.Aborted))
+	})
+
+	It("should mark paused clusters", func() {
+		var header metadata.MD
+		_, err := subject.GetConsumer(ctx, &rumourpb.GetConsumerRequest{Cluster: "main", Group: "orders-worker"}, grpc.Header(&header))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(header.Get("rumour-cluster-paused")).To(BeEmpty())
+
+		state.Cluster("main").SetPaused(true)
+		_, err = subject.ListTopics(ctx, &rumourpb.ListTopicsRequest{Cluster: "main"}, grpc.Header(&header))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(header.Get("rumour-cluster-paused")).To(Equal([]string{"true"}))
+
+		_, err = subject.GetConsumer(ctx, &rumourpb.GetConsumerRequest{Cluster: "main", Group: "orders-worker"}, grpc.Header(&header))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(header.Get("rumour-cluster-paused")).To(Equal([]string{"true"}))
+
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		stream, err := subject.WatchConsumerLag(ctx, &rumourpb.WatchConsumerLagRequest{Cluster: "main"})
+		Expect(err).NotTo(HaveOccurred())
+		header, err = stream.Header()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(header.Get("rumour-cluster-paused")).To(Equal([]string{"true"}))
+	})
+})
+
+var _ = Describe("GRPC auth", func() {
+	var subject rumourpb.RumourClient
+	var state *rumour.State
+	var srv *grpc.Server
+	var conn *grpc.ClientConn
+	var dir string
+	var ctx = context.Background()
+
+	withToken := func(token string) context.Context {
+		return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+	}
+
+	BeforeEach(func() {
+		state = rumour.NewState([]string{"main", "other"})
+		cluster := state.Cluster("main")
+		cluster.UpdateTopic("orders", []int64{100})
+		cluster.UpdateTopic("payments", []int64{100})
+		cluster.UpdateConsumerOffsets("orders-worker", "orders", 1515151515, []int64{40})
+		cluster.UpdateConsumerOffsets("orders-worker", "payments", 1515151515, []int64{90})
+		cluster.UpdateConsumerOffsets("shipping", "orders", 1515151515, []int64{70})
+		state.Cluster("other").UpdateTopic("orders", []int64{100})
+
+		var err error
+		dir, err = os.MkdirTemp("", "rumour-grpc")
+		Expect(err).NotTo(HaveOccurred())
+
+		name := filepath.Join(dir, "credentials.json")
+		Expect(os.WriteFile(name, []byte(`{
+			"tokens": [
+				{"name": "reader", "token": "t0k3n"},
+				{"name": "scoped", "token": "sc0p3d", "scope": {"clusters": ["main"], "groups": ["orders-*"], "topics": ["orders"]}}
+			]
+		}`), 0600)).To(Succeed())
+
+		auth, err := server.NewAuth(name)
+		Expect(err).NotTo(HaveOccurred())
+
+		lis := bufconn.Listen(1024 * 1024)
+		srv = server.NewGRPC(state, auth)
+		go func() { _ = srv.Serve(lis) }()
+
+		conn, err = grpc.Dial("bufnet",
+			grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+			grpc.WithInsecure(),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		subject = rumourpb.NewRumourClient(conn)
+	})
+
+	AfterEach(func() {
+		Expect(conn.Close()).To(Succeed())
+		srv.Stop()
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("should require credentials", func() {
+		_, err := subject.ListClusters(ctx, &rumourpb.ListClustersRequest{})
+		Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+
+		_, err = subject.ListClusters(withToken("wrong"), &rumourpb.ListClustersRequest{})
+		Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+
+		res, err := subject.ListClusters(withToken("t0k3n"), &rumourpb.ListClustersRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Clusters).To(Equal([]string{"main", "other"}))
+	})
+
+	It("should require credentials for streams", func() {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		stream, err := subject.WatchConsumerLag(ctx, &rumourpb.WatchConsumerLagRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = stream.Recv()
+		Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+
+		stream, err = subject.WatchConsumerLag(metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer t0k3n"), &rumourpb.WatchConsumerLagRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		lag, err := stream.Recv()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lag.Group).To(Equal("orders-worker"))
+	})
+
+	It("should apply scopes", func() {
+		ctx := withToken("sc0p3d")
+
+		clusters, err := subject.ListClusters(ctx, &rumourpb.ListClustersRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(clusters.Clusters).To(Equal([]string{"main"}))
+
+		_, err = subject.GetCluster(ctx, &rumourpb.GetClusterRequest{Cluster: "other"})
+		Expect(status.Code(err)).To(Equal(codes.NotFound))
+
+		cluster, err := subject.GetCluster(ctx, &rumourpb.GetClusterRequest{Cluster: "main"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cluster.Topics).To(Equal([]string{"orders"}))
+		Expect(cluster.Consumers).To(Equal([]string{"orders-worker"}))
+
+		_, err = subject.GetTopic(ctx, &rumourpb.GetTopicRequest{Cluster: "main", Topic: "payments"})
+		Expect(status.Code(err)).To(Equal(codes.NotFound))
+
+		consumers, err := subject.ListConsumers(ctx, &rumourpb.ListConsumersRequest{Cluster: "main"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(consumers.Consumers).To(HaveLen(1))
+		Expect(consumers.Consumers[0].Summary.TotalLag).To(Equal(int64(60)))
+
+		_, err = subject.GetConsumer(ctx, &rumourpb.GetConsumerRequest{Cluster: "main", Group: "shipping"})
+		Expect(status.Code(err)).To(Equal(codes.NotFound))
+
+		consumer, err := subject.GetConsumer(ctx, &rumourpb.GetConsumerRequest{Cluster: "main", Group: "orders-worker"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(consumer.Topics).To(HaveLen(1))
+		Expect(consumer.Topics[0].Topic).To(Equal("orders"))
+		Expect(consumer.Summary.TotalLag).To(Equal(int64(60)))
+	})
+
+	It("should apply scopes to streams", func() {
+		ctx, cancel := context.WithTimeout(withToken("sc0p3d"), 5*time.Second)
+		defer cancel()
+
+		stream, err := subject.WatchConsumerLag(ctx, &rumourpb.WatchConsumerLagRequest{Cluster: "other"})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = stream.Recv()
+		Expect(status.Code(err)).To(Equal(codes.NotFound))
+
+		stream, err = subject.WatchConsumerLag(ctx, &rumourpb.WatchConsumerLagRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		lag, err := stream.Recv()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lag.Group).To(Equal("orders-worker"))
+		Expect(lag.Topic).To(Equal("orders"))
+
+		cluster := state.Cluster("main")
+		cluster.UpdateConsumerOffsets("shipping", "orders", 1515151525, []int64{80})
+		cluster.UpdateConsumerOffsets("orders-worker", "payments", 1515151525, []int64{95})
+		cluster.UpdateConsumerOffsets("orders-worker", "orders", 1515151525, []int64{50})
+
+		lag, err = stream.Recv()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lag.Group).To(Equal("orders-worker"))
+		Expect(lag.Topic).To(Equal("orders"))
+		Expect(lag.Summary.TotalLag).To(Equal(int64(50)))
+	})
+})
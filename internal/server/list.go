@@ -0,0 +1,195 @@
+package server
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bsm/rumour/internal/rumour"
+)
+
+// Supported list sort orders.
+const (
+	sortByName = "name"
+	sortByLag  = "lag"
+)
+
+// listQuery contains the filtering, sorting and pagination options of list
+// endpoints. Entries are further restricted to names within scope, if set.
+type listQuery struct {
+	prefix string
+	match  *regexp.Regexp
+	minLag int64
+	sort   string
+	limit  int
+	after  *listCursor
+	scope  func(string) bool
+}
+
+func parseListQuery(r *http.Request) (*listQuery, error) {
+	params := r.URL.Query()
+	q := &listQuery{prefix: params.Get("prefix"), sort: sortByName}
+
+	if s := params.Get("match"); s != "" {
+		rx, err := regexp.Compile(s)
+		if err != nil {
+			return nil, errors.New("invalid match")
+		}
+		q.match = rx
+	}
+	if s := params.Get("min_lag"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || n < 0 {
+			return nil, errors.New("invalid min_lag")
+		}
+		q.minLag = n
+	}
+	if s := params.Get("sort"); s != "" {
+		if s != sortByName && s != sortByLag {
+			return nil, errors.New("invalid sort")
+		}
+		q.sort = s
+	}
+	if s := params.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return nil, errors.New("invalid limit")
+		}
+		q.limit = n
+	}
+	if s := params.Get("after"); s != "" {
+		c, err := parseListCursor(s)
+		if err != nil || c.sort != q.sort {
+			return nil, errors.New("invalid cursor")
+		}
+		q.after = c
+	}
+	return q, nil
+}
+
+// withLag returns true if lags are required to apply the query.
+func (q *listQuery) withLag() bool {
+	return q.minLag > 0 || q.sort == sortByLag
+}
+
+// page selects a page of n items, described by entry. It returns the
+// indices of the selected items and the cursor of the next page, if there is
+// one.
+func (q *listQuery) page(n int, entry func(i int) listEntry) ([]int, string) {
+	entries := make([]listEntry, n)
+	for i := range entries {
+		entries[i] = entry(i)
+		entries[i].Index = i
+	}
+	entries, next := q.apply(entries)
+
+	indices := make([]int, len(entries))
+	for i, e := range entries {
+		indices[i] = e.Index
+	}
+	return indices, next
+}
+
+// pageNames selects a page of names, lags are optional.
+func (q *listQuery) pageNames(names []string, lags map[string]int64) ([]string, string) {
+	indices, next := q.page(len(names), func(i int) listEntry {
+		return listEntry{Name: names[i], Lag: lags[names[i]]}
+	})
+
+	page := make([]string, len(indices))
+	for i, j := range indices {
+		page[i] = names[j]
+	}
+	return page, next
+}
+
+// apply filters, sorts and pages entries. It returns the selected entries
+// and the cursor of the next page, if there is one.
+func (q *listQuery) apply(entries []listEntry) ([]listEntry, string) {
+	res := entries[:0]
+	for _, e := range entries {
+		if q.includes(e) {
+			res = append(res, e)
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool { return q.less(res[i], res[j]) })
+	if q.limit > 0 && len(res) > q.limit {
+		res = res[:q.limit]
+		last := res[len(res)-1]
+		return res, listCursor{sort: q.sort, lag: last.Lag, name: last.Name}.String()
+	}
+	return res, ""
+}
+
+func (q *listQuery) includes(e listEntry) bool {
+	if q.scope != nil && !q.scope(e.Name) {
+		return false
+	}
+	if !strings.HasPrefix(e.Name, q.prefix) {
+		return false
+	}
+	if q.match != nil && !q.match.MatchString(e.Name) {
+		return false
+	}
+	if e.Lag < q.minLag {
+		return false
+	}
+	if q.after != nil && !q.less(listEntry{Name: q.after.name, Lag: q.after.lag}, e) {
+		return false
+	}
+	return true
+}
+
+func (q *listQuery) less(a, b listEntry) bool {
+	if q.sort == sortByLag && a.Lag != b.Lag {
+		return a.Lag > b.Lag
+	}
+	return a.Name < b.Name
+}
+
+// listEntry is a list item, referenced by its original index.
+type listEntry struct {
+	Name  string
+	Lag   int64
+	Index int
+}
+
+// consumerEntry returns the list entry of a consumer group.
+func consumerEntry(group string, summary rumour.LagSummary) listEntry {
+	return listEntry{Name: group, Lag: summary.TotalLag}
+}
+
+// listCursor marks the position of the last item of a page.
+type listCursor struct {
+	sort string
+	lag  int64
+	name string
+}
+
+func parseListCursor(s string) (*listCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.SplitN(string(b), ":", 3)
+	if len(parts) != 3 {
+		return nil, errors.New("invalid cursor")
+	}
+	lag, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &listCursor{sort: parts[0], lag: lag, name: parts[2]}, nil
+}
+
+// String encodes the cursor.
+func (c listCursor) String() string {
+	s := c.sort + ":" + strconv.FormatInt(c.lag, 10) + ":" + c.name
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
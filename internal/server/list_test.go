@@ -0,0 +1,157 @@
+package server_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/bsm/rumour/internal/rumour"
+	"github.com/bsm/rumour/internal/server"
+	"github.com/go-chi/httplog"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("List queries", func() {
+	var handler http.Handler
+
+	// groups returns the consumer groups and the next cursor of a list response.
+	groups := func(path string) ([]string, string) {
+		res := serve(handler, http.MethodGet, path, "")
+		Expect(res.Code).To(Equal(http.StatusOK), path)
+
+		var list struct {
+			Consumers []json.RawMessage `json:"consumers"`
+			Next      string            `json:"next"`
+		}
+		Expect(json.Unmarshal(res.Body.Bytes(), &list)).To(Succeed())
+
+		names := make([]string, len(list.Consumers))
+		for i, raw := range list.Consumers {
+			if err := json.Unmarshal(raw, &names[i]); err != nil {
+				var c struct {
+					Group string `json:"consumer"`
+				}
+				Expect(json.Unmarshal(raw, &c)).To(Succeed())
+				names[i] = c.Group
+			}
+		}
+		return names, list.Next
+	}
+
+	BeforeEach(func() {
+		state := rumour.NewState([]string{"main"})
+		cluster := state.Cluster("main")
+		cluster.UpdateTopic("orders", []int64{100})
+		cluster.UpdateTopic("payments", []int64{100})
+		cluster.UpdateConsumerOffsets("alpha", "orders", 1515151515, []int64{90})
+		cluster.UpdateConsumerOffsets("beta", "orders", 1515151515, []int64{70})
+		cluster.UpdateConsumerOffsets("gamma", "orders", 1515151515, []int64{80})
+		cluster.UpdateConsumerOffsets("delta", "orders", 1515151515, []int64{70})
+		cluster.UpdateConsumerOffsets("delta", "payments", 1515151515, []int64{100})
+
+		handler = server.NewHTTP(":0", state, nil, server.Options{
+			Log: httplog.Options{LogLevel: "error"},
+		}).Handler
+	})
+
+	DescribeTable("invalid queries",
+		func(query, message string) {
+			for _, path := range []string{
+				"/v1/clusters/main/topics",
+				"/v1/clusters/main/consumers",
+				"/v1/clusters/main/topics/orders/consumers",
+				"/v2/clusters/main/consumers?expand=true",
+				"/v2/clusters/main/topics/orders/consumers",
+			} {
+				sep := "?"
+				if strings.Contains(path, "?") {
+					sep = "&"
+				}
+
+				res := serve(handler, http.MethodGet, path+sep+query, "")
+				Expect(res.Code).To(Equal(http.StatusBadRequest), path)
+
+				var msg server.ErrorResponse
+				Expect(json.Unmarshal(res.Body.Bytes(), &msg)).To(Succeed())
+				Expect(msg.Message).To(Equal(message), path)
+			}
+		},
+		Entry("bad regexp", "match=(orders", "invalid match"),
+		Entry("negative min_lag", "min_lag=-1", "invalid min_lag"),
+		Entry("unknown sort", "sort=size", "invalid sort"),
+		Entry("zero limit", "limit=0", "invalid limit"),
+		Entry("bad limit", "limit=ten", "invalid limit"),
+		Entry("bad cursor encoding", "after=!!", "invalid cursor"),
+		Entry("bad cursor format", "after="+cursor("name:1"), "invalid cursor"),
+		Entry("bad cursor lag", "after="+cursor("name:x:alpha"), "invalid cursor"),
+		Entry("cursor of other sort", "sort=lag&after="+cursor("name:0:alpha"), "invalid cursor"),
+	)
+
+	It("should filter by prefix and regexp", func() {
+		Expect(groups("/v1/clusters/main/consumers?prefix=ga")).To(Equal([]string{"gamma"}))
+		Expect(groups("/v1/clusters/main/consumers?match=^(a|d)")).To(Equal([]string{"alpha", "delta"}))
+		Expect(groups("/v1/clusters/main/topics/orders/consumers?match=a$&prefix=b")).To(Equal([]string{"beta"}))
+		Expect(groups("/v2/clusters/main/consumers?expand=true&min_lag=25")).To(Equal([]string{"beta", "delta"}))
+	})
+
+	It("should sort by lag", func() {
+		// delta lags 30 on orders and 0 on payments
+		for _, path := range []string{
+			"/v1/clusters/main/consumers?sort=lag",
+			"/v1/clusters/main/consumers?sort=lag&expand=true",
+			"/v1/clusters/main/topics/orders/consumers?sort=lag",
+			"/v2/clusters/main/consumers?sort=lag&expand=true",
+			"/v2/clusters/main/topics/orders/consumers?sort=lag",
+		} {
+			names, next := groups(path)
+			Expect(names).To(Equal([]string{"beta", "delta", "gamma", "alpha"}), path)
+			Expect(next).To(BeEmpty(), path)
+		}
+	})
+
+	It("should paginate", func() {
+		for _, path := range []string{
+			"/v1/clusters/main/consumers?sort=lag&limit=3",
+			"/v1/clusters/main/topics/orders/consumers?sort=lag&limit=3",
+			"/v2/clusters/main/consumers?sort=lag&limit=3&expand=true",
+			"/v2/clusters/main/topics/orders/consumers?sort=lag&limit=3",
+		} {
+			names, next := groups(path)
+			Expect(names).To(Equal([]string{"beta", "delta", "gamma"}), path)
+			Expect(base64.RawURLEncoding.DecodeString(next)).To(Equal([]byte("lag:20:gamma")), path)
+
+			names, next = groups(path + "&after=" + next)
+			Expect(names).To(Equal([]string{"alpha"}), path)
+			Expect(next).To(BeEmpty(), path)
+		}
+
+		names, next := groups("/v1/clusters/main/consumers?limit=2")
+		Expect(names).To(Equal([]string{"alpha", "beta"}))
+		Expect(base64.RawURLEncoding.DecodeString(next)).To(Equal([]byte("name:0:beta")))
+		Expect(groups("/v1/clusters/main/consumers?limit=2&after=" + next)).To(Equal([]string{"delta", "gamma"}))
+	})
+
+	It("should paginate topics", func() {
+		res := serve(handler, http.MethodGet, "/v1/clusters/main/topics?limit=1", "")
+		Expect(res.Code).To(Equal(http.StatusOK))
+
+		var list server.TopicList
+		Expect(json.Unmarshal(res.Body.Bytes(), &list)).To(Succeed())
+		Expect(list.Topics).To(Equal([]string{"orders"}))
+		Expect(list.Next).NotTo(BeEmpty())
+
+		res = serve(handler, http.MethodGet, "/v1/clusters/main/topics?limit=1&after="+list.Next, "")
+		Expect(res.Code).To(Equal(http.StatusOK))
+		list = server.TopicList{}
+		Expect(json.Unmarshal(res.Body.Bytes(), &list)).To(Succeed())
+		Expect(list.Topics).To(Equal([]string{"payments"}))
+		Expect(list.Next).To(BeEmpty())
+	})
+})
+
+func cursor(s string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
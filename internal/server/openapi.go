@@ -0,0 +1,13 @@
+package server
+
+import (
+	_ "embed" // embed the OpenAPI specification
+	"net/http"
+)
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+func showOpenAPI(w http.ResponseWriter, _ *http.Request) {
+	_, _ = w.Write(openAPISpec)
+}
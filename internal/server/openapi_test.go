@@ -0,0 +1,344 @@
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bsm/rumour/internal/alert"
+	"github.com/bsm/rumour/internal/rumour"
+	"github.com/bsm/rumour/internal/server"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/httplog"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("OpenAPI", func() {
+	var handler http.Handler
+	var routes chi.Routes
+	var spec map[string]interface{}
+	var silenceID string
+
+	BeforeEach(func() {
+		state := rumour.NewState([]string{"main"})
+		cluster := state.Cluster("main")
+		cluster.UpdateBrokers([]string{"10.0.0.1:9092"})
+		cluster.UpdateTopic("orders", []int64{100, 100, rumour.OffsetUnknown})
+		cluster.UpdateConsumerOffsets("orders-worker", "orders", 1515151515, []int64{40, rumour.OffsetUnknown})
+
+		alerts, err := alert.NewEngine(state, &alert.Config{
+			Clusters: map[string]alert.ClusterConfig{"main": {Rules: []alert.Rule{
+				{Name: "lagging", Warn: alert.Threshold{TotalLag: 50}},
+			}}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		alerts.Evaluate(time.Now())
+
+		now := time.Now().Unix()
+		silenceID, err = alerts.AddSilence(&alert.Silence{Group: "orders-*", StartsAt: now, EndsAt: now + 3600})
+		Expect(err).NotTo(HaveOccurred())
+
+		dir, err := os.MkdirTemp("", "rumour-openapi")
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(os.RemoveAll, dir)
+
+		router := server.NewHTTP(":0", state, alerts, server.Options{
+			Log:      httplog.Options{LogLevel: "error"},
+			Auth:     adminAuth(dir),
+			Admin:    new(fakeAdmin),
+			AuditLog: server.NewAuditLog(io.Discard),
+		}).Handler
+		routes = router.(chi.Routes)
+		handler = withAdminToken(router)
+
+		res := serve(handler, http.MethodGet, "/v1/openapi.json", "")
+		Expect(res.Code).To(Equal(http.StatusOK))
+		Expect(json.Unmarshal(res.Body.Bytes(), &spec)).To(Succeed())
+	})
+
+	It("should document all routes", func() {
+		paths := spec["paths"].(map[string]interface{})
+
+		var undocumented []string
+		Expect(chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+			route = strings.TrimSuffix(route, "/")
+			if !strings.HasPrefix(route, "/v1/") && !strings.HasPrefix(route, "/v2/") {
+				return nil
+			}
+			if ops, ok := paths[route].(map[string]interface{}); !ok || ops[strings.ToLower(method)] == nil {
+				undocumented = append(undocumented, method+" "+route)
+			}
+			return nil
+		})).To(Succeed())
+		Expect(undocumented).To(BeEmpty())
+	})
+
+	DescribeTable("responses",
+		func(method, path, body string, status int) {
+			res := serve(handler, method, strings.Replace(path, "SILENCE", silenceID, 1), body)
+			Expect(res.Code).To(Equal(status), res.Body.String())
+
+			schema, err := responseSchema(spec, method, path, status)
+			Expect(err).NotTo(HaveOccurred())
+			if schema == nil {
+				Expect(res.Body.Len()).To(BeZero())
+				return
+			}
+
+			var v interface{}
+			Expect(json.Unmarshal(res.Body.Bytes(), &v)).To(Succeed())
+			Expect(validateSchema(spec, schema, v, "$")).To(Succeed())
+		},
+
+		Entry(nil, "GET", "/v1/clusters", "", 200),
+		Entry(nil, "GET", "/v1/clusters/main", "", 200),
+		Entry(nil, "GET", "/v1/clusters/missing", "", 404),
+		Entry(nil, "GET", "/v1/clusters/main/topics", "", 200),
+		Entry(nil, "GET", "/v1/clusters/main/topics?limit=0", "", 400),
+		Entry(nil, "GET", "/v1/clusters/main/topics/orders", "", 200),
+		Entry(nil, "GET", "/v1/clusters/main/topics/missing", "", 404),
+		Entry(nil, "GET", "/v1/clusters/main/topics/orders/consumers", "", 200),
+		Entry(nil, "GET", "/v1/clusters/main/consumers", "", 200),
+		Entry(nil, "GET", "/v1/clusters/main/consumers?expand=true&sort=lag&limit=1", "", 200),
+		Entry(nil, "GET", "/v1/clusters/main/consumers/top", "", 200),
+		Entry(nil, "GET", "/v1/clusters/main/consumers/top?by=x", "", 400),
+		Entry(nil, "GET", "/v1/clusters/main/consumers/orders-worker", "", 200),
+		Entry(nil, "GET", "/v1/clusters/main/consumers/missing", "", 404),
+		Entry(nil, "POST", "/v1/clusters/main/consumers/orders-worker/offsets", `{"mode":"earliest","dry_run":true}`, 200),
+		Entry(nil, "POST", "/v1/clusters/main/consumers/orders-worker/offsets", `{"mode":"timestamp"}`, 400),
+		Entry(nil, "POST", "/v1/clusters/missing/consumers/orders-worker/offsets", `{"mode":"latest"}`, 404),
+		Entry(nil, "DELETE", "/v1/clusters/main/consumers/orders-worker", "", 204),
+		Entry(nil, "DELETE", "/v1/clusters/main/consumers/missing", "", 404),
+		Entry(nil, "POST", "/v1/clusters/main/refresh?what=offsets", "", 202),
+		Entry(nil, "POST", "/v1/clusters/main/refresh?wait=true", "", 204),
+		Entry(nil, "POST", "/v1/clusters/main/refresh?what=all", "", 400),
+		Entry(nil, "PUT", "/v1/clusters/prio", `{"brokers":["10.0.0.1:9192"]}`, 201),
+		Entry(nil, "PUT", "/v1/clusters/main", `{"brokers":["10.0.0.1:9092"],"meta_refresh":"2m"}`, 200),
+		Entry(nil, "PUT", "/v1/clusters/main", `{}`, 400),
+		Entry(nil, "DELETE", "/v1/clusters/main", "", 204),
+		Entry(nil, "DELETE", "/v1/clusters/missing", "", 404),
+		Entry(nil, "POST", "/v1/clusters/main/pause", "", 204),
+		Entry(nil, "POST", "/v1/clusters/main/resume", "", 204),
+		Entry(nil, "POST", "/v1/clusters/missing/pause", "", 404),
+		Entry(nil, "GET", "/v1/top?by=growth", "", 200),
+		Entry(nil, "GET", "/v1/events?cluster=missing", "", 404),
+		Entry(nil, "GET", "/v1/alerts", "", 200),
+		Entry(nil, "GET", "/v1/silences", "", 200),
+		Entry(nil, "POST", "/v1/silences", `{"topic":"orders","duration":"1h","comment":"backfill"}`, 201),
+		Entry(nil, "POST", "/v1/silences", `{"topic":"orders"}`, 400),
+		Entry(nil, "GET", "/v1/silences/SILENCE", "", 200),
+		Entry(nil, "GET", "/v1/silences/missing", "", 404),
+		Entry(nil, "DELETE", "/v1/silences/SILENCE", "", 204),
+		Entry(nil, "DELETE", "/v1/silences/missing", "", 404),
+		Entry(nil, "GET", "/v1/openapi.json", "", 200),
+
+		Entry(nil, "GET", "/v2/clusters", "", 200),
+		Entry(nil, "GET", "/v2/clusters/main", "", 200),
+		Entry(nil, "GET", "/v2/clusters/main/topics", "", 200),
+		Entry(nil, "GET", "/v2/clusters/main/topics/orders", "", 200),
+		Entry(nil, "GET", "/v2/clusters/main/topics/orders/consumers", "", 200),
+		Entry(nil, "GET", "/v2/clusters/main/consumers", "", 200),
+		Entry(nil, "GET", "/v2/clusters/main/consumers?expand=true", "", 200),
+		Entry(nil, "GET", "/v2/clusters/main/consumers/orders-worker", "", 200),
+		Entry(nil, "GET", "/v2/clusters/main/consumers/missing", "", 404),
+		Entry(nil, "GET", "/v2/clusters/main/consumers/orders-worker/history", "", 200),
+		Entry(nil, "GET", "/v2/clusters/main/consumers/missing/history", "", 404),
+	)
+})
+
+func serve(handler http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	var rd io.Reader
+	if body != "" {
+		rd = strings.NewReader(body)
+	}
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(method, path, rd))
+	return res
+}
+
+// adminToken is the token of the unrestricted admin credential created by
+// adminAuth.
+const adminToken = "4dm1n"
+
+// adminAuth writes a credentials file with an unrestricted admin token to
+// dir and loads it.
+func adminAuth(dir string) *server.Auth {
+	name := filepath.Join(dir, "admin.json")
+	Expect(os.WriteFile(name, []byte(`{"tokens": [{"name": "admin", "token": "`+adminToken+`", "admin": true}]}`), 0600)).To(Succeed())
+
+	auth, err := server.NewAuth(name)
+	Expect(err).NotTo(HaveOccurred())
+	return auth
+}
+
+// withAdminToken authenticates all requests with the admin token.
+func withAdminToken(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Set("Authorization", "Bearer "+adminToken)
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// responseSchema finds the JSON response schema of an operation. It returns
+// nil if the response has no content.
+func responseSchema(spec map[string]interface{}, method, path string, status int) (map[string]interface{}, error) {
+	path = strings.SplitN(path, "?", 2)[0]
+
+	// prefer static over parameterised path segments
+	var op map[string]interface{}
+	params := -1
+	for tmpl, ops := range spec["paths"].(map[string]interface{}) {
+		if n := strings.Count(tmpl, "{"); matchPath(tmpl, path) && (params < 0 || n < params) {
+			op, _ = ops.(map[string]interface{})[strings.ToLower(method)].(map[string]interface{})
+			params = n
+		}
+	}
+	if op == nil {
+		return nil, fmt.Errorf("no operation for %s %s", method, path)
+	}
+
+	res, ok := op["responses"].(map[string]interface{})[fmt.Sprint(status)].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("undocumented %d response for %s %s", status, method, path)
+	}
+	content, ok := res["content"].(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	media, ok := content["application/json"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("no JSON response for %s %s", method, path)
+	}
+	return media["schema"].(map[string]interface{}), nil
+}
+
+func matchPath(tmpl, path string) bool {
+	a, b := strings.Split(tmpl, "/"), strings.Split(path, "/")
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] && !strings.HasPrefix(a[i], "{") {
+			return false
+		}
+	}
+	return true
+}
+
+// validateSchema validates a decoded JSON value against the subset of the
+// OpenAPI schema object used by the spec. Objects with properties are
+// treated as closed, undocumented fields are reported as errors.
+func validateSchema(spec, schema map[string]interface{}, v interface{}, at string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		resolved, ok := spec["components"].(map[string]interface{})["schemas"].(map[string]interface{})[name].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: unknown schema %q", at, ref)
+		}
+		return validateSchema(spec, resolved, v, at)
+	}
+
+	if alts, ok := schema["oneOf"].([]interface{}); ok {
+		matches := 0
+		for _, alt := range alts {
+			if validateSchema(spec, alt.(map[string]interface{}), v, at) == nil {
+				matches++
+			}
+		}
+		if matches != 1 {
+			return fmt.Errorf("%s: matches %d of %d schemas", at, matches, len(alts))
+		}
+		return nil
+	}
+
+	if v == nil {
+		if nullable, _ := schema["nullable"].(bool); nullable {
+			return nil
+		}
+		return fmt.Errorf("%s: must not be null", at)
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, x := range enum {
+			found = found || x == v
+		}
+		if !found {
+			return fmt.Errorf("%s: %v is not one of %v", at, v, enum)
+		}
+	}
+
+	switch typ := schema["type"]; typ {
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected object, got %T", at, v)
+		}
+		props, ok := schema["properties"].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, key := range required {
+				if _, ok := obj[key.(string)]; !ok {
+					return fmt.Errorf("%s: missing required field %q", at, key)
+				}
+			}
+		}
+
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			prop, ok := props[key].(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s: undocumented field %q", at, key)
+			}
+			if err := validateSchema(spec, prop, obj[key], at+"."+key); err != nil {
+				return err
+			}
+		}
+	case "array":
+		arr, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected array, got %T", at, v)
+		}
+		items := schema["items"].(map[string]interface{})
+		for i, x := range arr {
+			if err := validateSchema(spec, items, x, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		if _, ok := v.(string); !ok {
+			return fmt.Errorf("%s: expected string, got %T", at, v)
+		}
+	case "integer":
+		if n, ok := v.(float64); !ok || n != math.Trunc(n) {
+			return fmt.Errorf("%s: expected integer, got %v", at, v)
+		}
+	case "number":
+		if _, ok := v.(float64); !ok {
+			return fmt.Errorf("%s: expected number, got %T", at, v)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%s: expected boolean, got %T", at, v)
+		}
+	default:
+		return fmt.Errorf("%s: unsupported schema type %v", at, typ)
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/bsm/rumour/internal/pattern"
+	"github.com/bsm/rumour/internal/rumour"
+	"github.com/go-chi/chi/v5"
+)
+
+// AuthScope restricts credentials to clusters, consumer groups and topics.
+// Patterns are globs, unless wrapped in slashes, in which case they are
+// parsed as regular expressions. Empty lists allow everything.
+type AuthScope struct {
+	Clusters []string `json:"clusters"`
+	Groups   []string `json:"groups"`
+	Topics   []string `json:"topics"`
+}
+
+func (s *AuthScope) compile() (*scope, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	var (
+		res = &scope{key: fmt.Sprintf("%q %q %q", s.Clusters, s.Groups, s.Topics)}
+		err error
+	)
+	if res.clusters, err = pattern.Compile(s.Clusters); err != nil {
+		return nil, err
+	}
+	if res.groups, err = pattern.Compile(s.Groups); err != nil {
+		return nil, err
+	}
+	if res.topics, err = pattern.Compile(s.Topics); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// --------------------------------------------------------------------
+
+// credential identifies the authenticated client of a request.
+type credential struct {
+	name  string
+	admin bool
+	scope *scope
+}
+
+type credentialContextKey struct{}
+
+// credentialFrom returns the credential of a context, nil if authentication
+// is disabled.
+func credentialFrom(ctx context.Context) *credential {
+	cred, _ := ctx.Value(credentialContextKey{}).(*credential)
+	return cred
+}
+
+// credentialOf returns the credential of the request, nil if authentication
+// is disabled.
+func credentialOf(r *http.Request) *credential {
+	return credentialFrom(r.Context())
+}
+
+func withCredential(r *http.Request, cred *credential) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), credentialContextKey{}, cred))
+}
+
+// scopeFrom returns the scope of a context. A nil scope allows everything.
+func scopeFrom(ctx context.Context) *scope {
+	if cred := credentialFrom(ctx); cred != nil {
+		return cred.scope
+	}
+	return nil
+}
+
+// scopeOf returns the scope of the request. A nil scope allows everything.
+func scopeOf(r *http.Request) *scope {
+	return scopeFrom(r.Context())
+}
+
+// lookupCluster returns the name and state of the requested cluster. The
+// state is nil if the cluster does not exist or is out of scope.
+func lookupCluster(s *rumour.State, r *http.Request) (string, *rumour.ClusterState) {
+	name := chi.URLParam(r, "cluster")
+	if !scopeOf(r).cluster(name) {
+		return name, nil
+	}
+	return name, s.Cluster(name)
+}
+
+// scope is a compiled AuthScope, nil scopes allow everything.
+type scope struct {
+	clusters, groups, topics pattern.List
+	key                      string
+}
+
+// String returns a key that identifies the scope, empty if unrestricted.
+func (s *scope) String() string {
+	if s == nil {
+		return ""
+	}
+	return s.key
+}
+
+func (s *scope) cluster(name string) bool { return s == nil || s.clusters.Match(name) }
+func (s *scope) group(name string) bool   { return s == nil || s.groups.Match(name) }
+func (s *scope) topic(name string) bool   { return s == nil || s.topics.Match(name) }
+
+// event returns true if the event is in scope.
+func (s *scope) event(ev rumour.Event) bool {
+	switch {
+	case !s.cluster(ev.Cluster):
+		return false
+	case ev.Group != "":
+		return s.group(ev.Group) && (ev.Topic == "" || s.topic(ev.Topic))
+	case ev.Topic != "":
+		return s.topic(ev.Topic)
+	}
+	return true
+}
+
+// consumer returns true if the consumer group of a cluster is in scope.
+func (s *scope) consumer(cluster, group string) bool {
+	return s.cluster(cluster) && s.group(group)
+}
+
+// lagFilter returns a filter of consumer lags in scope, nil if unrestricted.
+func (s *scope) lagFilter() rumour.LagFilter {
+	if s == nil {
+		return nil
+	}
+	return func(cluster, group, topic string) bool {
+		return s.consumer(cluster, group) && s.topic(topic)
+	}
+}
+
+// selector returns true if the cluster, group and topic patterns of a
+// silence or an alert are in scope. Empty values match everything and are
+// only in scope when the respective dimension is unrestricted.
+func (s *scope) selector(cluster, group, topic string) bool {
+	if s == nil {
+		return true
+	}
+	return selects(s.clusters, cluster) && selects(s.groups, group) && selects(s.topics, topic)
+}
+
+func (s *scope) clusterNames(names []string) []string {
+	if s == nil {
+		return names
+	}
+
+	res := names[:0]
+	for _, name := range names {
+		if s.cluster(name) {
+			res = append(res, name)
+		}
+	}
+	return res
+}
+
+func (s *scope) groupNames(names []string) []string {
+	if s == nil {
+		return names
+	}
+
+	res := names[:0]
+	for _, name := range names {
+		if s.group(name) {
+			res = append(res, name)
+		}
+	}
+	return res
+}
+
+func (s *scope) topicNames(names []string) []string {
+	if s == nil {
+		return names
+	}
+
+	res := names[:0]
+	for _, name := range names {
+		if s.topic(name) {
+			res = append(res, name)
+		}
+	}
+	return res
+}
+
+// --------------------------------------------------------------------
+
+// selects returns true if a selector value is within the patterns. Empty
+// values select everything and are only allowed without restrictions.
+func selects(pp pattern.List, s string) bool {
+	if len(pp) == 0 {
+		return true
+	}
+	return s != "" && pp.Match(s)
+}
@@ -0,0 +1,288 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bsm/rumour/internal/alert"
+	"github.com/bsm/rumour/internal/rumour"
+	"github.com/bsm/rumour/internal/server"
+	"github.com/go-chi/httplog"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("AuthScope", func() {
+	var handler http.Handler
+	var alerts *alert.Engine
+	var dir string
+
+	request := func(method, path, body string) (int, map[string]interface{}) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer team-orders")
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		var v map[string]interface{}
+		if rec.Body.Len() != 0 {
+			Expect(json.Unmarshal(rec.Body.Bytes(), &v)).To(Succeed(), rec.Body.String())
+		}
+		return rec.Code, v
+	}
+
+	BeforeEach(func() {
+		state := rumour.NewState([]string{"main", "prio"})
+		for _, name := range []string{"main", "prio"} {
+			cluster := state.Cluster(name)
+			cluster.UpdateBrokers([]string{"10.0.0.1:9092"})
+			cluster.UpdateTopic("orders", []int64{100})
+			cluster.UpdateTopic("payments", []int64{100})
+			cluster.UpdateConsumerOffsets("orders-worker", "orders", 1515151515, []int64{10})
+			cluster.UpdateConsumerOffsets("payments-worker", "payments", 1515151515, []int64{20})
+			cluster.UpdateConsumerOffsets("orders-worker", "payments", 1515151515, []int64{30})
+		}
+
+		var err error
+		alerts, err = alert.NewEngine(state, &alert.Config{
+			Clusters: map[string]alert.ClusterConfig{
+				"main": {Rules: []alert.Rule{{Name: "lagging", Warn: alert.Threshold{TotalLag: 50}}}},
+				"prio": {Rules: []alert.Rule{{Name: "lagging", Warn: alert.Threshold{TotalLag: 50}}}},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		alerts.Evaluate(time.Now())
+
+		dir, err = os.MkdirTemp("", "rumour-scope")
+		Expect(err).NotTo(HaveOccurred())
+
+		name := filepath.Join(dir, "credentials.json")
+		Expect(os.WriteFile(name, []byte(`{
+			"tokens": [{
+				"name": "orders",
+				"token": "team-orders",
+				"scope": {"clusters": ["main"], "groups": ["orders-*"], "topics": ["/^orders$/"]}
+			}, {
+				"name": "ops",
+				"token": "team-ops"
+			}]
+		}`), 0600)).To(Succeed())
+
+		auth, err := server.NewAuth(name)
+		Expect(err).NotTo(HaveOccurred())
+
+		handler = server.NewHTTP(":0", state, alerts, server.Options{
+			Log:    httplog.Options{LogLevel: "error"},
+			Auth:   auth,
+			Burrow: true,
+		}).Handler
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("should validate scopes", func() {
+		name := filepath.Join(dir, "invalid.json")
+		Expect(os.WriteFile(name, []byte(`{"tokens": [{"token": "x", "scope": {"groups": ["/(/"]}}]}`), 0600)).To(Succeed())
+		_, err := server.LoadAuthConfig(name)
+		Expect(err).To(MatchError(ContainSubstring("invalid scope of token #1")))
+	})
+
+	It("should include the scope in ETags", func() {
+		etag := func(token string) string {
+			req := httptest.NewRequest("GET", "/v1/clusters/main", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			return rec.Header().Get("ETag")
+		}
+		Expect(etag("team-orders")).NotTo(BeEmpty())
+		Expect(etag("team-orders")).NotTo(Equal(etag("team-ops")))
+	})
+
+	It("should filter clusters", func() {
+		code, res := request("GET", "/v1/clusters", "")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(res["clusters"]).To(Equal([]interface{}{"main"}))
+
+		code, res = request("GET", "/v1/clusters/main", "")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(res["topics"]).To(Equal([]interface{}{"orders"}))
+		Expect(res["consumers"]).To(Equal([]interface{}{"orders-worker"}))
+
+		code, _ = request("GET", "/v1/clusters/prio", "")
+		Expect(code).To(Equal(http.StatusNotFound))
+		code, _ = request("GET", "/v2/clusters/prio/topics", "")
+		Expect(code).To(Equal(http.StatusNotFound))
+		code, _ = request("GET", "/v3/kafka/prio", "")
+		Expect(code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should filter topics", func() {
+		code, res := request("GET", "/v1/clusters/main/topics", "")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(res["topics"]).To(Equal([]interface{}{"orders"}))
+
+		for _, path := range []string{
+			"/v1/clusters/main/topics/payments",
+			"/v1/clusters/main/topics/payments/consumers",
+			"/v2/clusters/main/topics/payments",
+			"/v2/clusters/main/topics/payments/consumers",
+			"/v3/kafka/main/topic/payments",
+		} {
+			code, _ = request("GET", path, "")
+			Expect(code).To(Equal(http.StatusNotFound), path)
+		}
+
+		code, res = request("GET", "/v3/kafka/main/topic", "")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(res["topics"]).To(Equal([]interface{}{"orders"}))
+	})
+
+	It("should filter consumers", func() {
+		code, res := request("GET", "/v1/clusters/main/consumers", "")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(res["consumers"]).To(Equal([]interface{}{"orders-worker"}))
+
+		code, res = request("GET", "/v2/clusters/main/consumers?expand=true&sort=lag", "")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(res["consumers"]).To(HaveLen(1))
+
+		code, res = request("GET", "/v1/top", "")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(res["consumers"]).To(HaveLen(1))
+		Expect(res["consumers"].([]interface{})[0]).To(HaveKeyWithValue("cluster", "main"))
+		Expect(res["consumers"].([]interface{})[0]).To(HaveKeyWithValue("consumer", "orders-worker"))
+
+		code, res = request("GET", "/v1/clusters/main/consumers/top", "")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(res["consumers"]).To(HaveLen(1))
+
+		for _, path := range []string{
+			"/v1/clusters/main/consumers/payments-worker",
+			"/v2/clusters/main/consumers/payments-worker",
+			"/v3/kafka/main/consumer/payments-worker",
+			"/v3/kafka/main/consumer/payments-worker/status",
+		} {
+			code, _ = request("GET", path, "")
+			Expect(code).To(Equal(http.StatusNotFound), path)
+		}
+		code, _ = request("GET", "/v1/clusters/main/consumers/orders-worker", "")
+		Expect(code).To(Equal(http.StatusOK))
+	})
+
+	It("should filter consumer topics", func() {
+		topics := func(v interface{}) []string {
+			var names []string
+			switch v := v.(type) {
+			case []interface{}:
+				for _, t := range v {
+					names = append(names, t.(map[string]interface{})["topic"].(string))
+				}
+			case map[string]interface{}:
+				for name := range v {
+					names = append(names, name)
+				}
+			}
+			return names
+		}
+
+		code, res := request("GET", "/v1/clusters/main/consumers/orders-worker", "")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(topics(res["topics"])).To(ConsistOf("orders"))
+		Expect(res["summary"]).To(HaveKeyWithValue("total_lag", 90.0))
+
+		code, res = request("GET", "/v1/clusters/main/consumers?expand=true", "")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(res["consumers"]).To(HaveLen(1))
+		Expect(topics(res["consumers"].([]interface{})[0].(map[string]interface{})["topics"])).To(ConsistOf("orders"))
+
+		code, res = request("GET", "/v2/clusters/main/consumers/orders-worker", "")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(topics(res["topics"])).To(ConsistOf("orders"))
+		Expect(res["summary"]).To(HaveKeyWithValue("total_lag", 90.0))
+
+		code, res = request("GET", "/v2/clusters/main/consumers?expand=true", "")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(topics(res["consumers"].([]interface{})[0].(map[string]interface{})["topics"])).To(ConsistOf("orders"))
+
+		code, res = request("GET", "/v2/clusters/main/consumers/orders-worker/history", "")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(topics(res["topics"])).To(ConsistOf("orders"))
+
+		code, res = request("GET", "/v3/kafka/main/consumer/orders-worker", "")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(topics(res["topics"])).To(ConsistOf("orders"))
+
+		code, res = request("GET", "/v3/kafka/main/consumer/orders-worker/lag", "")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(topics(res["status"].(map[string]interface{})["partitions"])).To(ConsistOf("orders"))
+
+		for _, path := range []string{"/v1/top", "/v1/clusters/main/consumers/top"} {
+			code, res = request("GET", path, "")
+			Expect(code).To(Equal(http.StatusOK))
+			Expect(topics(res["consumers"])).To(ConsistOf("orders"), path)
+		}
+
+		code, res = request("GET", "/v1/alerts", "")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(topics(res["alerts"])).To(ConsistOf("orders"))
+	})
+
+	It("should filter alerts and silences", func() {
+		code, res := request("GET", "/v1/alerts", "")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(res["alerts"]).To(HaveLen(1))
+		Expect(res["alerts"].([]interface{})[0]).To(HaveKeyWithValue("cluster", "main"))
+		Expect(res["alerts"].([]interface{})[0]).To(HaveKeyWithValue("group", "orders-worker"))
+
+		now := time.Now().Unix()
+		other, err := alerts.AddSilence(&alert.Silence{Cluster: "main", StartsAt: now, EndsAt: now + 3600})
+		Expect(err).NotTo(HaveOccurred())
+
+		code, _ = request("POST", "/v1/silences", `{"cluster":"main","group":"payments-*","topic":"orders","duration":"1h"}`)
+		Expect(code).To(Equal(http.StatusForbidden))
+		code, res = request("POST", "/v1/silences", `{"cluster":"main","group":"orders-*","topic":"orders","duration":"1h"}`)
+		Expect(code).To(Equal(http.StatusCreated))
+		own := res["id"].(string)
+
+		code, res = request("GET", "/v1/silences", "")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(res["silences"]).To(HaveLen(1))
+		Expect(res["silences"].([]interface{})[0]).To(HaveKeyWithValue("id", own))
+
+		code, _ = request("GET", "/v1/silences/"+other, "")
+		Expect(code).To(Equal(http.StatusNotFound))
+		code, _ = request("DELETE", "/v1/silences/"+other, "")
+		Expect(code).To(Equal(http.StatusNotFound))
+		code, _ = request("DELETE", "/v1/silences/"+own, "")
+		Expect(code).To(Equal(http.StatusNoContent))
+		Expect(alerts.Silences()).To(HaveLen(1))
+	})
+
+	It("should not answer conditional requests out of scope", func() {
+		for _, path := range []string{
+			"/v1/clusters/prio",
+			"/v1/clusters/main/topics/payments",
+			"/v1/clusters/main/consumers/payments-worker",
+			"/v2/clusters/main/consumers/payments-worker",
+		} {
+			req := httptest.NewRequest("GET", path, nil)
+			req.Header.Set("Authorization", "Bearer team-orders")
+			req.Header.Set("If-None-Match", "*")
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			Expect(rec.Code).To(Equal(http.StatusNotFound), path)
+			Expect(rec.Header().Get("ETag")).To(BeEmpty(), path)
+		}
+	})
+})
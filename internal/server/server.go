@@ -1,10 +1,16 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"net"
 	"net/http"
+	"os"
+	"strconv"
 	"time"
 
+	"github.com/bsm/rumour/internal/alert"
 	"github.com/bsm/rumour/internal/rumour"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -12,35 +18,168 @@ import (
 	"github.com/rs/zerolog"
 )
 
-// NewHTTP inits an HTTP server.
-func NewHTTP(addr string, state *rumour.State, logOpt httplog.Options) *http.Server {
+// Options contains additional server options.
+type Options struct {
+	// Log contains request logging options.
+	Log httplog.Options
+	// Burrow enables the Burrow v3 compatible API under /v3/kafka.
+	Burrow bool
+	// Auth enables authentication of API requests.
+	Auth *Auth
+	// AuthHealthChecks requires authentication for /healthz and /readyz,
+	// which are unauthenticated by default.
+	AuthHealthChecks bool
+	// Admin enables the admin API, which is disabled by default. The admin
+	// API also requires Auth and stays disabled without it.
+	Admin Admin
+	// AuditLog records admin API actions. Defaults to stdout.
+	AuditLog *AuditLog
+}
+
+// NewHTTP inits an HTTP server. The alerts engine is optional.
+func NewHTTP(addr string, state *rumour.State, alerts *alert.Engine, opt Options) *http.Server {
 	return &http.Server{
 		Addr:         addr,
-		Handler:      newRouter(state, httplog.NewLogger("http", logOpt)),
+		Handler:      newRouter(state, alerts, httplog.NewLogger("http", opt.Log), opt),
 		ReadTimeout:  5 * time.Second,
 		WriteTimeout: 300 * time.Second,
 		IdleTimeout:  15 * time.Second,
+		ConnContext:  withConn,
 	}
 }
 
-func newRouter(state *rumour.State, logger zerolog.Logger) *chi.Mux {
+type connContextKey struct{}
+
+// withConn stores the connection in the context, so long-lived responses
+// can lift the write timeout via clearWriteDeadline.
+func withConn(ctx context.Context, c net.Conn) context.Context {
+	return context.WithValue(ctx, connContextKey{}, c)
+}
+
+// clearWriteDeadline removes the write deadline from the connection of a
+// request. HTTP/2 connections are shared by multiplexed streams and are left
+// untouched, the server's write timeout applies to their responses.
+func clearWriteDeadline(r *http.Request) {
+	if r.ProtoMajor >= 2 {
+		return
+	}
+	if c, ok := r.Context().Value(connContextKey{}).(net.Conn); ok {
+		_ = c.SetWriteDeadline(time.Time{})
+	}
+}
+
+func newRouter(state *rumour.State, alerts *alert.Engine, logger zerolog.Logger, opt Options) *chi.Mux {
 	r := chi.NewRouter()
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Recoverer)
+	if opt.Auth != nil && opt.AuthHealthChecks {
+		r.Use(authenticate(opt.Auth))
+	}
 	r.Use(middleware.Heartbeat("/healthz"))
+	r.Use(readiness(state, "/readyz"))
+	if opt.Auth != nil && !opt.AuthHealthChecks {
+		r.Use(authenticate(opt.Auth))
+	}
 
 	r.Route("/v1", func(v1 chi.Router) {
 		v1.Use(httplog.Handler(logger))
+		v1.Use(middleware.Compress(5, "application/json"))
 		v1.Use(middleware.SetHeader("Content-Type", "application/json"))
 
-		v1.Get("/clusters", listClusters(state))
-		v1.Get("/clusters/{cluster}", showCluster(state))
-		v1.Get("/clusters/{cluster}/topics", listTopics(state))
-		v1.Get("/clusters/{cluster}/topics/{topic}", showTopic(state))
-		v1.Get("/clusters/{cluster}/consumers", listConsumers(state))
-		v1.Get("/clusters/{cluster}/consumers/{consumer}", showConsumer(state))
+		v1.Get("/events", streamEvents(state))
+		v1.Post("/silences", createSilence(alerts))
+		v1.Delete("/silences/{silence}", deleteSilence(alerts))
+		v1.Group(func(v1 chi.Router) {
+			v1.Use(conditional)
+
+			v1.Get("/openapi.json", showOpenAPI)
+			v1.Get("/clusters", listClusters(state))
+			// shadows consumer groups named "top"
+			v1.With(markPaused(state)).Get("/clusters/{cluster}/consumers/top", topClusterConsumers(state))
+			v1.Group(func(v1 chi.Router) {
+				v1.Use(markPaused(state))
+				v1.Use(revisionValidators(state))
+
+				v1.Get("/clusters/{cluster}", showCluster(state))
+				v1.Get("/clusters/{cluster}/topics", listTopics(state))
+				v1.Get("/clusters/{cluster}/topics/{topic}", showTopic(state))
+				v1.Get("/clusters/{cluster}/topics/{topic}/consumers", listTopicConsumers(state))
+				v1.Get("/clusters/{cluster}/consumers", listConsumers(state))
+				v1.Get("/clusters/{cluster}/consumers/{consumer}", showConsumer(state))
+			})
+			v1.Get("/top", topConsumers(state))
+			v1.Get("/alerts", listAlerts(alerts))
+			v1.Get("/silences", listSilences(alerts))
+			v1.Get("/silences/{silence}", showSilence(alerts))
+		})
+
+		if opt.Admin != nil && opt.Auth != nil {
+			audit := opt.AuditLog
+			if audit == nil {
+				audit = NewAuditLog(os.Stdout)
+			}
+
+			v1.Group(func(v1 chi.Router) {
+				v1.Use(requireAdmin)
+
+				v1.Put("/clusters/{cluster}", putCluster(opt.Admin, audit))
+				v1.Delete("/clusters/{cluster}", deleteCluster(state, opt.Admin, audit))
+				v1.Delete("/clusters/{cluster}/consumers/{consumer}", deleteConsumerGroup(state, opt.Admin, audit))
+				v1.Post("/clusters/{cluster}/consumers/{consumer}/offsets", resetConsumerOffsets(state, opt.Admin, audit))
+				v1.Post("/clusters/{cluster}/refresh", refreshCluster(state, opt.Admin))
+				v1.Post("/clusters/{cluster}/pause", pauseCluster(state, opt.Admin, audit, true))
+				v1.Post("/clusters/{cluster}/resume", pauseCluster(state, opt.Admin, audit, false))
+			})
+		}
+	})
+
+	r.Route("/v2", func(v2 chi.Router) {
+		v2.Use(httplog.Handler(logger))
+		v2.Use(middleware.Compress(5, "application/json"))
+		v2.Use(middleware.SetHeader("Content-Type", "application/json"))
+
+		v2.Use(conditional)
+
+		v2.Get("/clusters", listClusters(state))
+		v2.Group(func(v2 chi.Router) {
+			v2.Use(markPaused(state))
+			v2.Use(revisionValidators(state))
+
+			v2.Get("/clusters/{cluster}", showCluster(state))
+			v2.Get("/clusters/{cluster}/topics", listTopics(state))
+			v2.Get("/clusters/{cluster}/topics/{topic}", showTopicV2(state))
+			v2.Get("/clusters/{cluster}/topics/{topic}/consumers", listTopicConsumersV2(state))
+			v2.Get("/clusters/{cluster}/consumers", listConsumersV2(state))
+			v2.Get("/clusters/{cluster}/consumers/{consumer}", showConsumerV2(state))
+			v2.Get("/clusters/{cluster}/consumers/{consumer}/history", showConsumerHistoryV2(state))
+		})
 	})
+
+	r.Get("/ui", http.RedirectHandler("/ui/", http.StatusMovedPermanently).ServeHTTP)
+	r.With(middleware.Compress(5, "text/html", "text/css", "application/javascript")).Handle("/ui/*", uiHandler())
+
+	if opt.Burrow {
+		r.Route("/v3/kafka", func(v3 chi.Router) {
+			v3.Use(httplog.Handler(logger))
+			v3.Use(middleware.Compress(5, "application/json"))
+			v3.Use(middleware.SetHeader("Content-Type", "application/json"))
+
+			v3.Get("/", burrowListClusters(state))
+			v3.Group(func(v3 chi.Router) {
+				v3.Use(markPaused(state))
+
+				v3.Get("/{cluster}", burrowShowCluster(state))
+				v3.Get("/{cluster}/topic", burrowListTopics(state))
+				v3.Get("/{cluster}/topic/{topic}", burrowShowTopic(state))
+				v3.Get("/{cluster}/consumer", burrowListConsumers(state))
+				v3.Get("/{cluster}/consumer/{consumer}", burrowShowConsumer(state))
+				v3.Get("/{cluster}/consumer/{consumer}/status", burrowConsumerStatus(state, false))
+				v3.Get("/{cluster}/consumer/{consumer}/lag", burrowConsumerStatus(state, true))
+			})
+		})
+		r.Get("/burrow/admin", burrowHealthCheck)
+	}
 	return r
 }
 
@@ -48,71 +187,114 @@ func newRouter(state *rumour.State, logger zerolog.Logger) *chi.Mux {
 
 func writeError(w http.ResponseWriter, message string, status int) {
 	w.WriteHeader(status)
-	_ = json.NewEncoder(w).Encode(struct {
-		Error   bool   `json:"error"`
-		Message string `json:"message"`
-	}{
+	_ = json.NewEncoder(w).Encode(&ErrorResponse{
 		Error:   true,
 		Message: message,
 	})
 }
 
+// readiness responds to requests on path once all clusters have received
+// their first update.
+func readiness(s *rumour.State, path string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != path || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Content-Type", "text/plain")
+			for _, name := range s.Clusters() {
+				cs := s.Cluster(name)
+				if cs == nil {
+					continue
+				}
+				if rev, _ := cs.Revision(); rev == 0 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					_, _ = w.Write([]byte("not ready"))
+					return
+				}
+			}
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte("."))
+		})
+	}
+}
+
+// PausedHeader marks responses of paused clusters.
+const PausedHeader = "Rumour-Cluster-Paused"
+
+// markPaused sets the PausedHeader on responses of paused clusters.
+func markPaused(s *rumour.State) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, state := lookupCluster(s, r); state != nil && state.Paused() {
+				w.Header().Set(PausedHeader, "true")
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func listClusters(s *rumour.State) http.HandlerFunc {
-	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-		_ = json.NewEncoder(w).Encode(struct {
-			Clusters []string `json:"clusters"`
-		}{
-			Clusters: s.Clusters(),
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(&ClusterList{
+			Clusters: scopeOf(r).clusterNames(s.Clusters()),
 		})
 	})
 }
 
 func showCluster(s *rumour.State) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		cluster := chi.URLParam(r, "cluster")
-		state := s.Cluster(cluster)
+		cluster, state := lookupCluster(s, r)
 		if state == nil {
 			writeError(w, "not found", http.StatusNotFound)
 			return
 		}
 
-		_ = json.NewEncoder(w).Encode(struct {
-			Cluster   string   `json:"cluster"`
-			Brokers   []string `json:"brokers"`
-			Topics    []string `json:"topics"`
-			Consumers []string `json:"consumers"`
-		}{
+		_ = json.NewEncoder(w).Encode(&ClusterDetail{
 			Cluster:   cluster,
 			Brokers:   state.Brokers(),
-			Topics:    state.Topics(),
-			Consumers: state.ConsumerGroups(),
+			Topics:    scopeOf(r).topicNames(state.Topics()),
+			Consumers: scopeOf(r).groupNames(state.ConsumerGroups()),
+			Paused:    state.Paused(),
 		})
 	})
 }
 
 func listTopics(s *rumour.State) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		cluster := chi.URLParam(r, "cluster")
-		state := s.Cluster(cluster)
+		cluster, state := lookupCluster(s, r)
 		if state == nil {
 			writeError(w, "not found", http.StatusNotFound)
 			return
 		}
 
-		_ = json.NewEncoder(w).Encode(struct {
-			Cluster string   `json:"cluster"`
-			Topics  []string `json:"topics"`
-		}{
+		q, err := parseListQuery(r)
+		if err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		q.scope = scopeOf(r).topic
+
+		var lags map[string]int64
+		if q.withLag() {
+			lags = state.TopicLags()
+		}
+
+		topics, next := q.pageNames(state.Topics(), lags)
+
+		_ = json.NewEncoder(w).Encode(&TopicList{
 			Cluster: cluster,
-			Topics:  state.Topics(),
+			Topics:  topics,
+			Next:    next,
 		})
 	})
 }
 
 func showTopic(s *rumour.State) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		cluster := chi.URLParam(r, "cluster")
-		state := s.Cluster(cluster)
+		cluster, state := lookupCluster(s, r)
 		if state == nil {
 			writeError(w, "not found", http.StatusNotFound)
 			return
@@ -120,66 +302,331 @@ func showTopic(s *rumour.State) http.HandlerFunc {
 
 		topic := chi.URLParam(r, "topic")
 		offsets, ok := state.TopicOffsets(topic)
-		if !ok {
+		if !ok || !scopeOf(r).topic(topic) {
 			writeError(w, "not found", http.StatusNotFound)
 			return
 		}
 
-		_ = json.NewEncoder(w).Encode(struct {
-			Cluster string  `json:"cluster"`
-			Topic   string  `json:"topic"`
-			Offsets []int64 `json:"offsets"`
-		}{
+		// v1 reports unknown offsets as zero
+		v1 := make([]int64, len(offsets))
+		for i, off := range offsets {
+			if off != rumour.OffsetUnknown {
+				v1[i] = off
+			}
+		}
+
+		_ = json.NewEncoder(w).Encode(&TopicDetail{
 			Cluster: cluster,
 			Topic:   topic,
-			Offsets: offsets,
+			Offsets: v1,
+		})
+	})
+}
+
+func listTopicConsumers(s *rumour.State) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cluster, state := lookupCluster(s, r)
+		if state == nil {
+			writeError(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		q, err := parseListQuery(r)
+		if err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		q.scope = scopeOf(r).group
+
+		topic := chi.URLParam(r, "topic")
+		consumers, ok := state.TopicConsumers(topic)
+		if !ok || !scopeOf(r).topic(topic) {
+			writeError(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		indices, next := q.page(len(consumers), func(i int) listEntry {
+			return consumerEntry(consumers[i].Group, consumers[i].Summary)
+		})
+
+		page := make([]rumour.TopicConsumer, len(indices))
+		for i, j := range indices {
+			page[i] = consumers[j]
+			v1ConsumerOffsets(page[i].Offsets)
+		}
+
+		_ = json.NewEncoder(w).Encode(&TopicConsumerList{
+			Cluster:   cluster,
+			Topic:     topic,
+			Consumers: page,
+			Next:      next,
 		})
 	})
 }
 
 func listConsumers(s *rumour.State) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		cluster := chi.URLParam(r, "cluster")
-		state := s.Cluster(cluster)
+		cluster, state := lookupCluster(s, r)
 		if state == nil {
 			writeError(w, "not found", http.StatusNotFound)
 			return
 		}
 
-		_ = json.NewEncoder(w).Encode(struct {
-			Cluster   string   `json:"cluster"`
-			Consumers []string `json:"consumers"`
-		}{
+		q, err := parseListQuery(r)
+		if err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		q.scope = scopeOf(r).group
+
+		expand, _ := strconv.ParseBool(r.URL.Query().Get("expand"))
+		if expand || q.withLag() {
+			consumers := state.Consumers()
+			if sc := scopeOf(r); sc != nil {
+				for i, c := range consumers {
+					consumers[i] = c.FilterTopics(sc.topic)
+				}
+			}
+			indices, next := q.page(len(consumers), func(i int) listEntry {
+				return consumerEntry(consumers[i].Group, consumers[i].Summary)
+			})
+
+			if !expand {
+				groups := make([]string, len(indices))
+				for i, j := range indices {
+					groups[i] = consumers[j].Group
+				}
+
+				_ = json.NewEncoder(w).Encode(&ConsumerGroupList{
+					Cluster:   cluster,
+					Consumers: groups,
+					Next:      next,
+				})
+				return
+			}
+
+			page := make([]rumour.Consumer, len(indices))
+			for i, j := range indices {
+				page[i] = consumers[j]
+				for _, ct := range page[i].Topics {
+					v1ConsumerOffsets(ct.Offsets)
+				}
+			}
+
+			_ = json.NewEncoder(w).Encode(&ConsumerList{
+				Cluster:   cluster,
+				Consumers: page,
+				Next:      next,
+			})
+			return
+		}
+
+		groups, next := q.pageNames(state.ConsumerGroups(), nil)
+
+		_ = json.NewEncoder(w).Encode(&ConsumerGroupList{
 			Cluster:   cluster,
-			Consumers: state.ConsumerGroups(),
+			Consumers: groups,
+			Next:      next,
 		})
 	})
 }
 
 func showConsumer(s *rumour.State) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		cluster := chi.URLParam(r, "cluster")
-		state := s.Cluster(cluster)
+		cluster, state := lookupCluster(s, r)
 		if state == nil {
 			writeError(w, "not found", http.StatusNotFound)
 			return
 		}
 
 		consumer := chi.URLParam(r, "consumer")
-		topics, ok := state.ConsumerTopics(consumer)
-		if !ok {
+		c, ok := state.Consumer(consumer)
+		if !ok || !scopeOf(r).group(consumer) {
 			writeError(w, "not found", http.StatusNotFound)
 			return
 		}
+		if sc := scopeOf(r); sc != nil {
+			c = c.FilterTopics(sc.topic)
+		}
+		for _, ct := range c.Topics {
+			v1ConsumerOffsets(ct.Offsets)
+		}
 
-		_ = json.NewEncoder(w).Encode(struct {
-			Cluster  string                 `json:"cluster"`
-			Consumer string                 `json:"consumer"`
-			Topics   []rumour.ConsumerTopic `json:"topics"`
-		}{
+		_ = json.NewEncoder(w).Encode(&ConsumerDetail{
 			Cluster:  cluster,
 			Consumer: consumer,
-			Topics:   topics,
+			Topics:   c.Topics,
+			Summary:  c.Summary,
 		})
 	})
 }
+
+// v1ConsumerOffsets reports unknown offsets as zero, like showTopic.
+func v1ConsumerOffsets(offsets []rumour.ConsumerOffset) {
+	for i := range offsets {
+		if offsets[i].Offset == rumour.OffsetUnknown {
+			offsets[i].Offset = 0
+		}
+	}
+}
+
+func parseRanking(r *http.Request) (rumour.RankBy, int, error) {
+	by, err := rumour.ParseRankBy(r.URL.Query().Get("by"))
+	if err != nil {
+		return "", 0, err
+	}
+
+	limit := 10
+	if s := r.URL.Query().Get("limit"); s != "" {
+		if limit, err = strconv.Atoi(s); err != nil || limit < 1 {
+			return "", 0, errors.New("invalid limit")
+		}
+	}
+	return by, limit, nil
+}
+
+func topClusterConsumers(s *rumour.State) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cluster, state := lookupCluster(s, r)
+		if state == nil {
+			writeError(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		by, limit, err := parseRanking(r)
+		if err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		consumers := state.TopConsumers(by, limit, scopeOf(r).lagFilter())
+
+		_ = json.NewEncoder(w).Encode(&TopConsumerList{
+			Cluster:   cluster,
+			By:        by,
+			Consumers: consumers,
+		})
+	})
+}
+
+func topConsumers(s *rumour.State) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		by, limit, err := parseRanking(r)
+		if err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		consumers := s.TopConsumers(by, limit, scopeOf(r).lagFilter())
+
+		_ = json.NewEncoder(w).Encode(&TopConsumerList{
+			By:        by,
+			Consumers: consumers,
+		})
+	})
+}
+
+func listAlerts(e *alert.Engine) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sc := scopeOf(r)
+		alerts := []alert.Alert{}
+		if e != nil {
+			for _, a := range e.Active() {
+				if sc.consumer(a.Cluster, a.Group) && sc.topic(a.Topic) {
+					alerts = append(alerts, a)
+				}
+			}
+		}
+
+		_ = json.NewEncoder(w).Encode(&AlertList{
+			Alerts: alerts,
+		})
+	})
+}
+
+func listSilences(e *alert.Engine) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sc := scopeOf(r)
+		silences := []*alert.Silence{}
+		if e != nil {
+			for _, silence := range e.Silences() {
+				if sc.selector(silence.Cluster, silence.Group, silence.Topic) {
+					silences = append(silences, silence)
+				}
+			}
+		}
+
+		_ = json.NewEncoder(w).Encode(&SilenceList{
+			Silences: silences,
+		})
+	})
+}
+
+func showSilence(e *alert.Engine) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if e == nil {
+			writeError(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		silence, ok := e.Silence(chi.URLParam(r, "silence"))
+		if !ok || !scopeOf(r).selector(silence.Cluster, silence.Group, silence.Topic) {
+			writeError(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(silence)
+	})
+}
+
+func createSilence(e *alert.Engine) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if e == nil {
+			writeError(w, "alerting is not enabled", http.StatusNotImplemented)
+			return
+		}
+
+		var req SilenceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		silence := req.Silence
+		if !scopeOf(r).selector(silence.Cluster, silence.Group, silence.Topic) {
+			writeError(w, "silence is out of scope", http.StatusForbidden)
+			return
+		}
+		if silence.StartsAt == 0 {
+			silence.StartsAt = time.Now().Unix()
+		}
+		if silence.EndsAt == 0 && req.Duration > 0 {
+			silence.EndsAt = silence.StartsAt + int64(time.Duration(req.Duration)/time.Second)
+		}
+
+		if _, err := e.AddSilence(&silence); err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(&silence)
+	})
+}
+
+func deleteSilence(e *alert.Engine) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if e == nil {
+			writeError(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		id := chi.URLParam(r, "silence")
+		if silence, ok := e.Silence(id); !ok || !scopeOf(r).selector(silence.Cluster, silence.Group, silence.Topic) || !e.DeleteSilence(id) {
+			writeError(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
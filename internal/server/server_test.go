@@ -0,0 +1,273 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bsm/rumour/internal/alert"
+	"github.com/bsm/rumour/internal/rumour"
+	"github.com/bsm/rumour/internal/server"
+	"github.com/go-chi/httplog"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("HTTP", func() {
+	var handler http.Handler
+	var state *rumour.State
+
+	get := func(path string, v interface{}) int {
+		res := serve(handler, http.MethodGet, path, "")
+		if v != nil && res.Code == http.StatusOK {
+			Expect(json.Unmarshal(res.Body.Bytes(), v)).To(Succeed())
+		}
+		return res.Code
+	}
+
+	BeforeEach(func() {
+		state = rumour.NewState([]string{"main"})
+		cluster := state.Cluster("main")
+		cluster.UpdateTopic("orders", []int64{100, 100, rumour.OffsetUnknown})
+		cluster.UpdateConsumerOffsets("orders-worker", "orders", 1515151515, []int64{40, rumour.OffsetUnknown})
+
+		handler = server.NewHTTP(":0", state, nil, server.Options{
+			Log:    httplog.Options{LogLevel: "error"},
+			Burrow: true,
+		}).Handler
+	})
+
+	It("should report unknown v1 consumer offsets as zero", func() {
+		offsets := []rumour.ConsumerOffset{
+			{Offset: 40, Lag: 60},
+			{Offset: 0, Lag: 100},
+			{Offset: 0, Lag: 0},
+		}
+
+		var detail server.ConsumerDetail
+		Expect(get("/v1/clusters/main/consumers/orders-worker", &detail)).To(Equal(http.StatusOK))
+		Expect(detail.Topics).To(HaveLen(1))
+		Expect(detail.Topics[0].Offsets).To(Equal(offsets))
+		Expect(detail.Summary.TotalLag).To(Equal(int64(160)))
+
+		var list server.ConsumerList
+		Expect(get("/v1/clusters/main/consumers?expand=true", &list)).To(Equal(http.StatusOK))
+		Expect(list.Consumers).To(HaveLen(1))
+		Expect(list.Consumers[0].Topics[0].Offsets).To(Equal(offsets))
+
+		var readers server.TopicConsumerList
+		Expect(get("/v1/clusters/main/topics/orders/consumers", &readers)).To(Equal(http.StatusOK))
+		Expect(readers.Consumers).To(HaveLen(1))
+		Expect(readers.Consumers[0].Offsets).To(Equal(offsets))
+	})
+
+	It("should mark responses of paused clusters", func() {
+		paths := []string{
+			"/v1/clusters/main",
+			"/v1/clusters/main/topics",
+			"/v1/clusters/main/topics/orders",
+			"/v1/clusters/main/topics/orders/consumers",
+			"/v1/clusters/main/consumers",
+			"/v1/clusters/main/consumers/orders-worker",
+			"/v1/clusters/main/consumers/top",
+			"/v2/clusters/main/topics/orders",
+			"/v2/clusters/main/topics/orders/consumers",
+			"/v2/clusters/main/consumers",
+			"/v2/clusters/main/consumers/orders-worker",
+			"/v2/clusters/main/consumers/orders-worker/history",
+			"/v3/kafka/main",
+			"/v3/kafka/main/topic",
+			"/v3/kafka/main/topic/orders",
+			"/v3/kafka/main/consumer",
+			"/v3/kafka/main/consumer/orders-worker",
+			"/v3/kafka/main/consumer/orders-worker/status",
+			"/v3/kafka/main/consumer/orders-worker/lag",
+		}
+		etags := make(map[string]string, len(paths))
+		for _, path := range paths {
+			res := serve(handler, http.MethodGet, path, "")
+			Expect(res.Code).To(Equal(http.StatusOK), path)
+			Expect(res.Header().Get(server.PausedHeader)).To(BeEmpty(), path)
+			etags[path] = res.Header().Get("ETag")
+		}
+
+		state.Cluster("main").SetPaused(true)
+		for _, path := range paths {
+			res := serve(handler, http.MethodGet, path, "")
+			Expect(res.Code).To(Equal(http.StatusOK), path)
+			Expect(res.Header().Get(server.PausedHeader)).To(Equal("true"), path)
+			if etag := etags[path]; etag != "" {
+				Expect(res.Header().Get("ETag")).NotTo(Equal(etag), path)
+			}
+		}
+	})
+})
+
+var _ = Describe("Silences", func() {
+	var handler http.Handler
+	var alerts *alert.Engine
+
+	BeforeEach(func() {
+		state := rumour.NewState([]string{"main"})
+		state.Cluster("main").UpdateTopic("orders", []int64{100})
+
+		var err error
+		alerts, err = alert.NewEngine(state, &alert.Config{
+			Clusters: map[string]alert.ClusterConfig{"main": {Rules: []alert.Rule{
+				{Name: "lagging", Warn: alert.Threshold{TotalLag: 50}},
+			}}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		handler = server.NewHTTP(":0", state, alerts, server.Options{
+			Log: httplog.Options{LogLevel: "error"},
+		}).Handler
+	})
+
+	It("should create, show and delete silences", func() {
+		before := time.Now().Unix()
+		res := serve(handler, http.MethodPost, "/v1/silences", `{"cluster":"main","group":"orders-*","duration":"1h","comment":"backfill"}`)
+		Expect(res.Code).To(Equal(http.StatusCreated))
+
+		var created alert.Silence
+		Expect(json.Unmarshal(res.Body.Bytes(), &created)).To(Succeed())
+		Expect(created.ID).To(HaveLen(16))
+		Expect(created.Cluster).To(Equal("main"))
+		Expect(created.Group).To(Equal("orders-*"))
+		Expect(created.Comment).To(Equal("backfill"))
+		Expect(created.StartsAt).To(BeNumerically(">=", before))
+		Expect(created.EndsAt).To(Equal(created.StartsAt + 3600))
+
+		res = serve(handler, http.MethodGet, "/v1/silences/"+created.ID, "")
+		Expect(res.Code).To(Equal(http.StatusOK))
+		var shown alert.Silence
+		Expect(json.Unmarshal(res.Body.Bytes(), &shown)).To(Succeed())
+		Expect(shown).To(Equal(created))
+
+		var list server.SilenceList
+		res = serve(handler, http.MethodGet, "/v1/silences", "")
+		Expect(res.Code).To(Equal(http.StatusOK))
+		Expect(json.Unmarshal(res.Body.Bytes(), &list)).To(Succeed())
+		Expect(list.Silences).To(HaveLen(1))
+		Expect(list.Silences[0].ID).To(Equal(created.ID))
+
+		Expect(serve(handler, http.MethodDelete, "/v1/silences/"+created.ID, "").Code).To(Equal(http.StatusNoContent))
+		Expect(serve(handler, http.MethodGet, "/v1/silences/"+created.ID, "").Code).To(Equal(http.StatusNotFound))
+		Expect(serve(handler, http.MethodDelete, "/v1/silences/"+created.ID, "").Code).To(Equal(http.StatusNotFound))
+		Expect(alerts.Silences()).To(BeEmpty())
+	})
+
+	It("should accept explicit periods", func() {
+		res := serve(handler, http.MethodPost, "/v1/silences", `{"topic":"orders","starts_at":4102444800,"ends_at":4102448400}`)
+		Expect(res.Code).To(Equal(http.StatusCreated))
+
+		var created alert.Silence
+		Expect(json.Unmarshal(res.Body.Bytes(), &created)).To(Succeed())
+		Expect(created.StartsAt).To(Equal(int64(4102444800)))
+		Expect(created.EndsAt).To(Equal(int64(4102448400)))
+		Expect(alerts.Silences()).To(HaveLen(1))
+	})
+
+	It("should reject invalid silences", func() {
+		for _, body := range []string{
+			`{"topic":"orders"`,
+			`{"topic":"orders"}`,
+			`{"topic":"orders","duration":"forever"}`,
+			`{"group":"[x","duration":"1h"}`,
+		} {
+			res := serve(handler, http.MethodPost, "/v1/silences", body)
+			Expect(res.Code).To(Equal(http.StatusBadRequest), body)
+		}
+		Expect(alerts.Silences()).To(BeEmpty())
+	})
+
+	It("should not show or delete expired silences", func() {
+		now := time.Now().Unix()
+		id, err := alerts.AddSilence(&alert.Silence{Group: "orders-*", StartsAt: now - 3600, EndsAt: now - 1})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(serve(handler, http.MethodGet, "/v1/silences/"+id, "").Code).To(Equal(http.StatusNotFound))
+		Expect(serve(handler, http.MethodDelete, "/v1/silences/"+id, "").Code).To(Equal(http.StatusNotFound))
+		Expect(serve(handler, http.MethodGet, "/v1/silences", "").Body.String()).To(MatchJSON(`{"silences":[]}`))
+	})
+
+	It("should require alerting", func() {
+		handler = server.NewHTTP(":0", rumour.NewState(nil), nil, server.Options{
+			Log: httplog.Options{LogLevel: "error"},
+		}).Handler
+
+		Expect(serve(handler, http.MethodPost, "/v1/silences", `{"duration":"1h"}`).Code).To(Equal(http.StatusNotImplemented))
+		Expect(serve(handler, http.MethodGet, "/v1/silences/x", "").Code).To(Equal(http.StatusNotFound))
+		Expect(serve(handler, http.MethodDelete, "/v1/silences/x", "").Code).To(Equal(http.StatusNotFound))
+	})
+})
+
+var _ = Describe("Alerts", func() {
+	var handler http.Handler
+	var state *rumour.State
+	var alerts *alert.Engine
+
+	t0 := time.Unix(1515151515, 0)
+
+	BeforeEach(func() {
+		state = rumour.NewState([]string{"main"})
+		state.Cluster("main").UpdateTopic("orders", []int64{100, 100})
+		state.Cluster("main").UpdateConsumerOffsets("orders-worker", "orders", 1515151515, []int64{40, 90})
+		state.Cluster("main").UpdateConsumerOffsets("payments-worker", "orders", 1515151515, []int64{100, 100})
+
+		var err error
+		alerts, err = alert.NewEngine(state, &alert.Config{
+			Clusters: map[string]alert.ClusterConfig{"main": {Rules: []alert.Rule{
+				{Name: "lagging", Warn: alert.Threshold{TotalLag: 50}},
+			}}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		handler = server.NewHTTP(":0", state, alerts, server.Options{
+			Log: httplog.Options{LogLevel: "error"},
+		}).Handler
+	})
+
+	It("should list active alerts", func() {
+		Expect(serve(handler, http.MethodGet, "/v1/alerts", "").Body.String()).To(MatchJSON(`{"alerts":[]}`))
+
+		Expect(alerts.Evaluate(t0)).To(HaveLen(1))
+		res := serve(handler, http.MethodGet, "/v1/alerts", "")
+		Expect(res.Code).To(Equal(http.StatusOK))
+		Expect(res.Body.String()).To(MatchJSON(`{"alerts":[{
+			"cluster": "main",
+			"group": "orders-worker",
+			"topic": "orders",
+			"rule": "lagging",
+			"severity": "warn",
+			"status": "firing",
+			"total_lag": 70,
+			"max_lag": 60,
+			"time_lag": 0,
+			"starts_at": 1515151515,
+			"silenced": false
+		}]}`))
+
+		// resolve
+		state.Cluster("main").UpdateConsumerOffsets("orders-worker", "orders", 1515151600, []int64{100, 100})
+		Expect(alerts.Evaluate(t0.Add(time.Minute))).To(HaveLen(1))
+		Expect(serve(handler, http.MethodGet, "/v1/alerts", "").Body.String()).To(MatchJSON(`{"alerts":[]}`))
+	})
+
+	It("should list no alerts without alerting", func() {
+		handler = server.NewHTTP(":0", state, nil, server.Options{
+			Log: httplog.Options{LogLevel: "error"},
+		}).Handler
+
+		res := serve(handler, http.MethodGet, "/v1/alerts", "")
+		Expect(res.Code).To(Equal(http.StatusOK))
+		Expect(res.Body.String()).To(MatchJSON(`{"alerts":[]}`))
+	})
+})
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "internal/server")
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// NewTLSConfig inits a TLS config which serves the certificate and key from
+// the given files. If a client CA file is given, clients must present a
+// certificate signed by one of its CAs. The files are reloaded when they
+// change, e.g. when certificates are rotated, invalid changes are logged and
+// ignored.
+func NewTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	tf := &tlsFiles{
+		certFile:     certFile,
+		keyFile:      keyFile,
+		clientCAFile: clientCAFile,
+		logger:       log.New(os.Stdout, "[tls] ", log.LstdFlags),
+	}
+	if err := tf.load(); err != nil {
+		return nil, err
+	}
+
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			cert, _ := tf.current()
+			return cert, nil
+		},
+	}
+	if clientCAFile != "" {
+		// client certificates are verified manually, against the current CAs;
+		// unlike VerifyPeerCertificate, VerifyConnection is also called when
+		// sessions are resumed
+		cfg.ClientAuth = tls.RequireAnyClientCert
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			_, roots := tf.current()
+			return verifyClientCert(cs.PeerCertificates, roots)
+		}
+	}
+	return cfg, nil
+}
+
+func verifyClientCert(certs []*x509.Certificate, roots *x509.CertPool) error {
+	if len(certs) == 0 {
+		return errors.New("server: no client certificate")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	return err
+}
+
+// --------------------------------------------------------------------
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func equalStamps(a, b []fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].modTime.Equal(b[i].modTime) || a[i].size != b[i].size {
+			return false
+		}
+	}
+	return true
+}
+
+type tlsFiles struct {
+	certFile, keyFile, clientCAFile string
+	logger                          *log.Logger
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	stamps    []fileStamp
+}
+
+func (f *tlsFiles) names() []string {
+	if f.clientCAFile == "" {
+		return []string{f.certFile, f.keyFile}
+	}
+	return []string{f.certFile, f.keyFile, f.clientCAFile}
+}
+
+// current returns the current certificate and client CAs, reloading them if
+// any of the files have changed.
+func (f *tlsFiles) current() (*tls.Certificate, *x509.CertPool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stamps, err := f.stat()
+	if err != nil {
+		f.logger.Printf("unable to check certificates: %v", err)
+		return f.cert, f.clientCAs
+	}
+	if equalStamps(stamps, f.stamps) {
+		return f.cert, f.clientCAs
+	}
+
+	if err := f.load(); err != nil {
+		// don't retry until the files change again
+		f.stamps = stamps
+		f.logger.Printf("unable to reload certificates: %v", err)
+	} else {
+		f.logger.Printf("reloaded certificates from %s", f.certFile)
+	}
+	return f.cert, f.clientCAs
+}
+
+func (f *tlsFiles) stat() ([]fileStamp, error) {
+	names := f.names()
+	stamps := make([]fileStamp, len(names))
+	for i, name := range names {
+		fi, err := os.Stat(name)
+		if err != nil {
+			return nil, err
+		}
+		stamps[i] = fileStamp{modTime: fi.ModTime(), size: fi.Size()}
+	}
+	return stamps, nil
+}
+
+// load loads the certificate and client CAs. The previous values are retained
+// on errors.
+func (f *tlsFiles) load() error {
+	stamps, err := f.stat()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(f.certFile, f.keyFile)
+	if err != nil {
+		return err
+	}
+
+	var clientCAs *x509.CertPool
+	if f.clientCAFile != "" {
+		pem, err := os.ReadFile(f.clientCAFile)
+		if err != nil {
+			return err
+		}
+
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("server: no certificates found in %s", f.clientCAFile)
+		}
+	}
+
+	f.cert = &cert
+	f.clientCAs = clientCAs
+	f.stamps = stamps
+	return nil
+}
@@ -0,0 +1,242 @@
+package server_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bsm/rumour/internal/server"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("NewTLSConfig", func() {
+	var dir string
+	var ca *testCA
+	var lis net.Listener
+
+	certFile := func() string { return filepath.Join(dir, "tls.crt") }
+	keyFile := func() string { return filepath.Join(dir, "tls.key") }
+	caFile := func() string { return filepath.Join(dir, "ca.crt") }
+
+	listen := func(clientCA string) {
+		cfg, err := server.NewTLSConfig(certFile(), keyFile(), clientCA)
+		Expect(err).NotTo(HaveOccurred())
+
+		lis, err = tls.Listen("tcp", "127.0.0.1:0", cfg)
+		Expect(err).NotTo(HaveOccurred())
+
+		go func() {
+			for {
+				conn, err := lis.Accept()
+				if err != nil {
+					return
+				}
+				go func() {
+					defer conn.Close()
+					_, _ = io.Copy(io.Discard, conn)
+				}()
+			}
+		}()
+	}
+
+	dialWith := func(cfg *tls.Config) (*x509.Certificate, error) {
+		conn, err := tls.Dial("tcp", lis.Addr().String(), cfg)
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+
+		// client certificate errors surface on first read in TLS 1.3
+		_ = conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		if _, err := conn.Read(make([]byte, 1)); err != nil {
+			if ne, ok := err.(net.Error); !ok || !ne.Timeout() {
+				return nil, err
+			}
+		}
+		return conn.ConnectionState().PeerCertificates[0], nil
+	}
+
+	dial := func(certs ...tls.Certificate) (*x509.Certificate, error) {
+		return dialWith(&tls.Config{
+			RootCAs:      ca.pool(),
+			ServerName:   "rumour.test",
+			Certificates: certs,
+		})
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "rumour-tls")
+		Expect(err).NotTo(HaveOccurred())
+
+		ca = newTestCA()
+		ca.writeCert(caFile())
+		ca.issue("rumour.test", x509.ExtKeyUsageServerAuth, certFile(), keyFile())
+	})
+
+	AfterEach(func() {
+		if lis != nil {
+			_ = lis.Close()
+		}
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("should validate files", func() {
+		_, err := server.NewTLSConfig(certFile(), filepath.Join(dir, "missing.key"), "")
+		Expect(err).To(HaveOccurred())
+		_, err = server.NewTLSConfig(certFile(), keyFile(), keyFile())
+		Expect(err).To(MatchError(ContainSubstring("no certificates found")))
+	})
+
+	It("should serve and reload certificates", func() {
+		listen("")
+
+		cert, err := dial()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cert.Subject.CommonName).To(Equal("rumour.test"))
+		serial := cert.SerialNumber
+
+		ca.issue("rumour.test", x509.ExtKeyUsageServerAuth, certFile(), keyFile())
+		cert, err = dial()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cert.SerialNumber).NotTo(Equal(serial))
+	})
+
+	It("should retain certificates on invalid changes", func() {
+		listen("")
+
+		cert, err := dial()
+		Expect(err).NotTo(HaveOccurred())
+		serial := cert.SerialNumber
+
+		Expect(os.WriteFile(keyFile(), []byte("invalid"), 0600)).To(Succeed())
+		cert, err = dial()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cert.SerialNumber).To(Equal(serial))
+	})
+
+	It("should verify client certificates", func() {
+		listen(caFile())
+
+		_, err := dial()
+		Expect(err).To(HaveOccurred())
+
+		client := ca.issue("client", x509.ExtKeyUsageClientAuth, filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key"))
+		_, err = dial(client)
+		Expect(err).NotTo(HaveOccurred())
+
+		other := newTestCA().issue("client", x509.ExtKeyUsageClientAuth, filepath.Join(dir, "other.crt"), filepath.Join(dir, "other.key"))
+		_, err = dial(other)
+		Expect(err).To(HaveOccurred())
+
+		// rotate the client CA
+		ca = newTestCA()
+		ca.writeCert(caFile())
+		ca.issue("rumour.test", x509.ExtKeyUsageServerAuth, certFile(), keyFile())
+
+		_, err = dial(client)
+		Expect(err).To(HaveOccurred())
+		_, err = dial(ca.issue("client", x509.ExtKeyUsageClientAuth, filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should verify client certificates of resumed sessions", func() {
+		listen(caFile())
+
+		client := ca.issue("client", x509.ExtKeyUsageClientAuth, filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key"))
+		cfg := &tls.Config{
+			RootCAs:            ca.pool(),
+			ServerName:         "rumour.test",
+			Certificates:       []tls.Certificate{client},
+			ClientSessionCache: tls.NewLRUClientSessionCache(1),
+		}
+		_, err := dialWith(cfg)
+		Expect(err).NotTo(HaveOccurred())
+
+		// rotate the client CA only
+		newTestCA().writeCert(caFile())
+
+		_, err = dialWith(cfg)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+// --------------------------------------------------------------------
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func newTestCA() *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	tpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).NotTo(HaveOccurred())
+	return &testCA{cert: cert, key: key, der: der}
+}
+
+func (ca *testCA) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return pool
+}
+
+func (ca *testCA) writeCert(name string) {
+	Expect(os.WriteFile(name, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.der}), 0600)).To(Succeed())
+}
+
+func (ca *testCA) issue(cn string, usage x509.ExtKeyUsage, certFile, keyFile string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     []string{cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, ca.cert, &key.PublicKey, ca.key)
+	Expect(err).NotTo(HaveOccurred())
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	Expect(err).NotTo(HaveOccurred())
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	Expect(os.WriteFile(certFile, certPEM, 0600)).To(Succeed())
+	Expect(os.WriteFile(keyFile, keyPEM, 0600)).To(Succeed())
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	Expect(err).NotTo(HaveOccurred())
+	return cert
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"github.com/bsm/rumour/internal/alert"
+	"github.com/bsm/rumour/internal/rumour"
+)
+
+// ErrorResponse is returned on errors.
+type ErrorResponse struct {
+	Error   bool   `json:"error"`
+	Message string `json:"message"`
+}
+
+// ClusterList lists the cluster names.
+type ClusterList struct {
+	Clusters []string `json:"clusters"`
+}
+
+// ClusterDetail contains the brokers, topics and consumer groups of a cluster.
+// Paused clusters retain their last known state.
+type ClusterDetail struct {
+	Cluster   string   `json:"cluster"`
+	Brokers   []string `json:"brokers"`
+	Topics    []string `json:"topics"`
+	Consumers []string `json:"consumers"`
+	Paused    bool     `json:"paused,omitempty"`
+}
+
+// TopicList lists the topic names of a cluster.
+type TopicList struct {
+	Cluster string   `json:"cluster"`
+	Topics  []string `json:"topics"`
+	Next    string   `json:"next,omitempty"`
+}
+
+// TopicDetail contains the log end offsets of a topic.
+type TopicDetail struct {
+	Cluster string  `json:"cluster"`
+	Topic   string  `json:"topic"`
+	Offsets []int64 `json:"offsets"`
+}
+
+// TopicConsumerList lists the consumer groups reading a topic.
+type TopicConsumerList struct {
+	Cluster   string                 `json:"cluster"`
+	Topic     string                 `json:"topic"`
+	Consumers []rumour.TopicConsumer `json:"consumers"`
+	Next      string                 `json:"next,omitempty"`
+}
+
+// ConsumerGroupList lists the consumer group names of a cluster.
+type ConsumerGroupList struct {
+	Cluster   string   `json:"cluster"`
+	Consumers []string `json:"consumers"`
+	Next      string   `json:"next,omitempty"`
+}
+
+// ConsumerList lists the consumer groups of a cluster with their topics.
+type ConsumerList struct {
+	Cluster   string            `json:"cluster"`
+	Consumers []rumour.Consumer `json:"consumers"`
+	Next      string            `json:"next,omitempty"`
+}
+
+// ConsumerDetail contains the topics of a consumer group.
+type ConsumerDetail struct {
+	Cluster  string                 `json:"cluster"`
+	Consumer string                 `json:"consumer"`
+	Topics   []rumour.ConsumerTopic `json:"topics"`
+	Summary  rumour.LagSummary      `json:"summary"`
+}
+
+// TopConsumerList contains ranked consumer lags. The cluster is omitted
+// for rankings across all clusters.
+type TopConsumerList struct {
+	Cluster   string               `json:"cluster,omitempty"`
+	By        rumour.RankBy        `json:"by"`
+	Consumers []rumour.ConsumerLag `json:"consumers"`
+}
+
+// AlertList lists active alerts.
+type AlertList struct {
+	Alerts []alert.Alert `json:"alerts"`
+}
+
+// SilenceList lists active silences.
+type SilenceList struct {
+	Silences []*alert.Silence `json:"silences"`
+}
+
+// SilenceRequest creates a silence. Duration is used when EndsAt is not set.
+type SilenceRequest struct {
+	alert.Silence
+	Duration alert.Duration `json:"duration"`
+}
+
+// OffsetResetResponse contains the offset changes of a consumer group.
+type OffsetResetResponse struct {
+	Cluster  string                `json:"cluster"`
+	Consumer string                `json:"consumer"`
+	DryRun   bool                  `json:"dry_run"`
+	Changes  []rumour.OffsetChange `json:"changes"`
+}
+
+// --------------------------------------------------------------------
+
+// TopicDetailV2 contains the partitions of a topic.
+type TopicDetailV2 struct {
+	Cluster    string                  `json:"cluster"`
+	Topic      string                  `json:"topic"`
+	Partitions []rumour.TopicPartition `json:"partitions"`
+}
+
+// TopicConsumerListV2 lists the partition offsets of consumer groups
+// reading a topic.
+type TopicConsumerListV2 struct {
+	Cluster   string                           `json:"cluster"`
+	Topic     string                           `json:"topic"`
+	Consumers []rumour.ConsumerTopicPartitions `json:"consumers"`
+	Next      string                           `json:"next,omitempty"`
+}
+
+// ConsumerListV2 lists the partition offsets of consumer groups.
+type ConsumerListV2 struct {
+	Cluster   string                      `json:"cluster"`
+	Consumers []rumour.ConsumerPartitions `json:"consumers"`
+	Next      string                      `json:"next,omitempty"`
+}
+
+// ConsumerDetailV2 contains the partition offsets of a consumer group.
+type ConsumerDetailV2 struct {
+	Cluster string `json:"cluster"`
+	rumour.ConsumerPartitions
+}
+
+// ConsumerHistoryV2 contains the recent lag history of a consumer group.
+type ConsumerHistoryV2 struct {
+	Cluster  string                 `json:"cluster"`
+	Consumer string                 `json:"consumer"`
+	Topics   []ConsumerTopicHistory `json:"topics"`
+}
+
+// ConsumerTopicHistory contains the recent lag history of a consumer group
+// on a topic.
+type ConsumerTopicHistory struct {
+	Topic   string             `json:"topic"`
+	Samples []rumour.LagSample `json:"samples"`
+}
@@ -0,0 +1,260 @@
+// Rumour dashboard, a read-only view of the JSON API.
+(function () {
+  "use strict";
+
+  var REFRESH_INTERVAL = 30000;
+  var main = document.getElementById("main");
+  var crumbs = document.getElementById("crumbs");
+  var updated = document.getElementById("updated");
+
+  // --------------------------------------------------------------------
+  // helpers
+
+  function el(tag, attrs) {
+    var node = document.createElement(tag);
+    Object.keys(attrs || {}).forEach(function (k) {
+      if (k === "text") {
+        node.textContent = attrs[k];
+      } else {
+        node.setAttribute(k, attrs[k]);
+      }
+    });
+    for (var i = 2; i < arguments.length; i++) {
+      var child = arguments[i];
+      if (child == null) continue;
+      node.appendChild(typeof child === "string" ? document.createTextNode(child) : child);
+    }
+    return node;
+  }
+
+  function link(href, text) {
+    return el("a", { href: href, text: text });
+  }
+
+  function path() {
+    return "#/" + Array.prototype.map.call(arguments, encodeURIComponent).join("/");
+  }
+
+  function api(url) {
+    return fetch("../" + url, { credentials: "same-origin" }).then(function (res) {
+      return res.json().then(function (body) {
+        if (!res.ok) throw new Error(body.message || res.statusText);
+        return body;
+      });
+    });
+  }
+
+  function num(n) {
+    return n == null ? "–" : Number(n).toLocaleString();
+  }
+
+  function table(columns, rows) {
+    var head = el("tr");
+    columns.forEach(function (c) {
+      head.appendChild(el("th", { class: c.num ? "num" : "", text: c.title }));
+    });
+
+    var body = el("tbody");
+    rows.forEach(function (row) {
+      var tr = el("tr", row.class ? { class: row.class } : {});
+      columns.forEach(function (c, i) {
+        var v = row.cells[i];
+        tr.appendChild(el("td", { class: c.num ? "num" : "" }, typeof v === "object" && v !== null ? v : String(v)));
+      });
+      body.appendChild(tr);
+    });
+    return el("table", {}, el("thead", {}, head), body);
+  }
+
+  // filterable adds a name filter input to a table of rows.
+  function filterable(columns, rows, name) {
+    var container = el("div");
+    var input = el("input", { class: "filter", type: "search", placeholder: "Filter…" });
+    var render = function () {
+      var q = input.value.toLowerCase();
+      var matching = rows.filter(function (r) { return name(r).toLowerCase().indexOf(q) !== -1; });
+      if (container.lastChild && container.lastChild !== input) container.removeChild(container.lastChild);
+      container.appendChild(matching.length ? table(columns, matching) : el("p", { class: "muted", text: "None" }));
+    };
+    input.addEventListener("input", render);
+    container.appendChild(input);
+    render();
+    return container;
+  }
+
+  function sparkline(samples) {
+    if (!samples || samples.length < 2) return null;
+
+    var w = 160, h = 28, pad = 2;
+    var t0 = samples[0].timestamp, t1 = samples[samples.length - 1].timestamp;
+    var max = Math.max.apply(null, samples.map(function (s) { return s.lag; })) || 1;
+    var points = samples.map(function (s) {
+      var x = t1 === t0 ? 0 : (s.timestamp - t0) / (t1 - t0) * (w - 2 * pad) + pad;
+      var y = h - pad - s.lag / max * (h - 2 * pad);
+      return x.toFixed(1) + "," + y.toFixed(1);
+    });
+
+    var ns = "http://www.w3.org/2000/svg";
+    var svg = document.createElementNS(ns, "svg");
+    svg.setAttribute("class", "sparkline" + (samples[samples.length - 1].lag > samples[0].lag ? " growing" : ""));
+    svg.setAttribute("viewBox", "0 0 " + w + " " + h);
+    var title = document.createElementNS(ns, "title");
+    title.textContent = "Total lag over the last " + samples.length + " samples, max " + num(max);
+    var line = document.createElementNS(ns, "polyline");
+    line.setAttribute("points", points.join(" "));
+    svg.appendChild(title);
+    svg.appendChild(line);
+    return svg;
+  }
+
+  // partitionRows highlights partitions with lag, the partition with the
+  // highest lag is marked separately.
+  function partitionRows(partitions) {
+    var max = 0;
+    partitions.forEach(function (p) { if (p.lag > max) max = p.lag; });
+
+    return partitions.map(function (p) {
+      var cls = "";
+      if (p.offset == null || p.lag == null) {
+        cls = "unknown";
+      } else if (p.lag > 0 && p.lag === max) {
+        cls = "max-lag";
+      } else if (p.lag > 0) {
+        cls = "lagging";
+      }
+      return { class: cls, cells: [p.partition, num(p.offset), num(p.log_end_offset), num(p.lag)] };
+    });
+  }
+
+  function summary(s) {
+    return el("span", { class: "summary", text: "total lag " + num(s.total_lag) + ", max lag " + num(s.max_lag) });
+  }
+
+  // --------------------------------------------------------------------
+  // views
+
+  function showClusters() {
+    return api("v1/clusters").then(function (res) {
+      return [
+        el("h1", { text: "Clusters" }),
+        el("section", {}, table([{ title: "Cluster" }], res.clusters.map(function (c) {
+          return { cells: [link(path("clusters", c), c)] };
+        }))),
+      ];
+    });
+  }
+
+  function showCluster(cluster) {
+    return Promise.all([
+      api("v2/clusters/" + encodeURIComponent(cluster) + "/consumers?expand=true&sort=lag"),
+      api("v1/clusters/" + encodeURIComponent(cluster)),
+    ]).then(function (res) {
+      var consumers = res[0].consumers, detail = res[1];
+
+      return [
+        el("h1", { text: cluster }),
+        el("p", { class: "muted", text: "Brokers: " + (detail.brokers.join(", ") || "unknown") }),
+        el("h2", { text: "Consumer groups" }),
+        el("section", {}, filterable(
+          [{ title: "Group" }, { title: "Topics", num: true }, { title: "Total lag", num: true }, { title: "Max lag", num: true }, { title: "Max lag topic" }],
+          consumers.map(function (c) {
+            return {
+              class: c.summary.total_lag > 0 ? "lagging" : "",
+              cells: [link(path("clusters", cluster, "consumers", c.consumer), c.consumer), c.topics.length, num(c.summary.total_lag), num(c.summary.max_lag), c.summary.max_lag_topic || ""],
+            };
+          }),
+          function (r) { return r.cells[0].textContent; }
+        )),
+        el("h2", { text: "Topics" }),
+        el("section", {}, filterable(
+          [{ title: "Topic" }],
+          detail.topics.map(function (t) {
+            return { cells: [link(path("clusters", cluster, "topics", t), t)] };
+          }),
+          function (r) { return r.cells[0].textContent; }
+        )),
+      ];
+    });
+  }
+
+  function showTopic(cluster, topic) {
+    var base = "v2/clusters/" + encodeURIComponent(cluster) + "/topics/" + encodeURIComponent(topic);
+    return Promise.all([api(base), api(base + "/consumers?sort=lag")]).then(function (res) {
+      var partitions = res[0].partitions, consumers = res[1].consumers;
+
+      var nodes = [
+        el("h1", { text: topic }),
+        el("section", {}, table(
+          [{ title: "Partition" }, { title: "Log end offset", num: true }],
+          partitions.map(function (p) { return { cells: [p.partition, num(p.log_end_offset)] }; })
+        )),
+      ];
+      consumers.forEach(function (c) {
+        nodes.push(el("h2", {}, link(path("clusters", cluster, "consumers", c.consumer), c.consumer), summary(c.summary)));
+        nodes.push(el("section", {}, table(
+          [{ title: "Partition" }, { title: "Offset", num: true }, { title: "Log end offset", num: true }, { title: "Lag", num: true }],
+          partitionRows(c.partitions)
+        )));
+      });
+      if (!consumers.length) nodes.push(el("p", { class: "muted", text: "No consumer groups." }));
+      return nodes;
+    });
+  }
+
+  function showConsumer(cluster, group) {
+    var base = "v2/clusters/" + encodeURIComponent(cluster) + "/consumers/" + encodeURIComponent(group);
+    return Promise.all([api(base), api(base + "/history")]).then(function (res) {
+      var consumer = res[0], history = {};
+      res[1].topics.forEach(function (t) { history[t.topic] = t.samples; });
+
+      var nodes = [el("h1", {}, group + " ", summary(consumer.summary))];
+      consumer.topics.forEach(function (t) {
+        nodes.push(el("h2", {}, link(path("clusters", cluster, "topics", t.topic), t.topic), summary(t.summary), sparkline(history[t.topic])));
+        nodes.push(el("section", {}, table(
+          [{ title: "Partition" }, { title: "Offset", num: true }, { title: "Log end offset", num: true }, { title: "Lag", num: true }],
+          partitionRows(t.partitions)
+        )));
+      });
+      return nodes;
+    });
+  }
+
+  // --------------------------------------------------------------------
+  // routing
+
+  function route() {
+    var parts = location.hash.replace(/^#\/?/, "").split("/").filter(Boolean).map(decodeURIComponent);
+    var trail = [link("#/", "Clusters")];
+    var view;
+
+    if (parts.length === 0) {
+      view = showClusters();
+    } else if (parts[0] === "clusters" && parts.length === 2) {
+      trail.push(link(path("clusters", parts[1]), parts[1]));
+      view = showCluster(parts[1]);
+    } else if (parts[0] === "clusters" && parts.length === 4 && parts[2] === "topics") {
+      trail.push(link(path("clusters", parts[1]), parts[1]), link(path("clusters", parts[1], "topics", parts[3]), parts[3]));
+      view = showTopic(parts[1], parts[3]);
+    } else if (parts[0] === "clusters" && parts.length === 4 && parts[2] === "consumers") {
+      trail.push(link(path("clusters", parts[1]), parts[1]), link(path("clusters", parts[1], "consumers", parts[3]), parts[3]));
+      view = showConsumer(parts[1], parts[3]);
+    } else {
+      view = Promise.reject(new Error("page not found"));
+    }
+
+    crumbs.replaceChildren.apply(crumbs, trail);
+    return view.then(function (nodes) {
+      main.replaceChildren.apply(main, nodes);
+      updated.textContent = "Updated " + new Date().toLocaleTimeString();
+      updated.className = "muted";
+    }, function (err) {
+      main.replaceChildren(el("p", { class: "error", text: "Error: " + err.message }));
+    });
+  }
+
+  window.addEventListener("hashchange", route);
+  setInterval(function () {
+    if (!document.hidden && !document.activeElement.matches("input")) route();
+  }, REFRESH_INTERVAL);
+  route();
+})();
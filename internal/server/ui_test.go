@@ -0,0 +1,51 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/bsm/rumour/internal/rumour"
+	"github.com/bsm/rumour/internal/server"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("UI", func() {
+	var handler http.Handler
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	BeforeEach(func() {
+		handler = server.NewHTTP(":0", rumour.NewState([]string{"main"}), nil, server.Options{}).Handler
+	})
+
+	It("redirects to the index", func() {
+		rec := get("/ui")
+		Expect(rec.Code).To(Equal(http.StatusMovedPermanently))
+		Expect(rec.Header().Get("Location")).To(Equal("/ui/"))
+	})
+
+	It("serves the index", func() {
+		rec := get("/ui/")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Header().Get("Content-Type")).To(HavePrefix("text/html"))
+		Expect(rec.Body.String()).To(ContainSubstring(`<script src="app.js">`))
+	})
+
+	It("serves assets", func() {
+		rec := get("/ui/app.js")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Header().Get("Content-Type")).To(ContainSubstring("javascript"))
+
+		rec = get("/ui/style.css")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Header().Get("Content-Type")).To(HavePrefix("text/css"))
+
+		Expect(get("/ui/missing.js").Code).To(Equal(http.StatusNotFound))
+	})
+})
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/bsm/rumour/internal/rumour"
+	"github.com/go-chi/chi/v5"
+)
+
+func showTopicV2(s *rumour.State) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cluster, state := lookupCluster(s, r)
+		if state == nil {
+			writeError(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		topic := chi.URLParam(r, "topic")
+		partitions, ok := state.TopicPartitions(topic)
+		if !ok || !scopeOf(r).topic(topic) {
+			writeError(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(&TopicDetailV2{
+			Cluster:    cluster,
+			Topic:      topic,
+			Partitions: partitions,
+		})
+	})
+}
+
+func listTopicConsumersV2(s *rumour.State) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cluster, state := lookupCluster(s, r)
+		if state == nil {
+			writeError(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		q, err := parseListQuery(r)
+		if err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		q.scope = scopeOf(r).group
+
+		topic := chi.URLParam(r, "topic")
+		consumers, ok := state.TopicConsumerPartitions(topic)
+		if !ok || !scopeOf(r).topic(topic) {
+			writeError(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		indices, next := q.page(len(consumers), func(i int) listEntry {
+			return consumerEntry(consumers[i].Group, consumers[i].Summary)
+		})
+
+		page := make([]rumour.ConsumerTopicPartitions, len(indices))
+		for i, j := range indices {
+			page[i] = consumers[j]
+		}
+
+		_ = json.NewEncoder(w).Encode(&TopicConsumerListV2{
+			Cluster:   cluster,
+			Topic:     topic,
+			Consumers: page,
+			Next:      next,
+		})
+	})
+}
+
+func listConsumersV2(s *rumour.State) http.HandlerFunc {
+	v1 := listConsumers(s)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if expand, _ := strconv.ParseBool(r.URL.Query().Get("expand")); !expand {
+			v1(w, r)
+			return
+		}
+
+		cluster, state := lookupCluster(s, r)
+		if state == nil {
+			writeError(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		q, err := parseListQuery(r)
+		if err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		q.scope = scopeOf(r).group
+
+		consumers := state.AllConsumerPartitions()
+		if sc := scopeOf(r); sc != nil {
+			for i, c := range consumers {
+				consumers[i] = c.FilterTopics(sc.topic)
+			}
+		}
+		indices, next := q.page(len(consumers), func(i int) listEntry {
+			return consumerEntry(consumers[i].Group, consumers[i].Summary)
+		})
+
+		page := make([]rumour.ConsumerPartitions, len(indices))
+		for i, j := range indices {
+			page[i] = consumers[j]
+		}
+
+		_ = json.NewEncoder(w).Encode(&ConsumerListV2{
+			Cluster:   cluster,
+			Consumers: page,
+			Next:      next,
+		})
+	})
+}
+
+func showConsumerV2(s *rumour.State) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cluster, state := lookupCluster(s, r)
+		if state == nil {
+			writeError(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		consumer := chi.URLParam(r, "consumer")
+		cp, ok := state.ConsumerPartitions(consumer)
+		if !ok || !scopeOf(r).group(consumer) {
+			writeError(w, "not found", http.StatusNotFound)
+			return
+		}
+		if sc := scopeOf(r); sc != nil {
+			cp = cp.FilterTopics(sc.topic)
+		}
+
+		_ = json.NewEncoder(w).Encode(&ConsumerDetailV2{
+			Cluster:            cluster,
+			ConsumerPartitions: cp,
+		})
+	})
+}
+
+func showConsumerHistoryV2(s *rumour.State) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cluster, state := lookupCluster(s, r)
+		if state == nil {
+			writeError(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		consumer := chi.URLParam(r, "consumer")
+		topics, ok := state.ConsumerTopics(consumer)
+		if !ok || !scopeOf(r).group(consumer) {
+			writeError(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		sc := scopeOf(r)
+		history := make([]ConsumerTopicHistory, 0, len(topics))
+		for _, ct := range topics {
+			if !sc.topic(ct.Topic) {
+				continue
+			}
+
+			samples, ok := state.ConsumerLagHistory(consumer, ct.Topic)
+			if !ok {
+				continue
+			}
+			history = append(history, ConsumerTopicHistory{Topic: ct.Topic, Samples: samples})
+		}
+
+		_ = json.NewEncoder(w).Encode(&ConsumerHistoryV2{
+			Cluster:  cluster,
+			Consumer: consumer,
+			Topics:   history,
+		})
+	})
+}
@@ -0,0 +1,5 @@
+// Package rumourpb contains the protobuf messages and gRPC service
+// definitions of the Rumour API.
+package rumourpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative rumour.proto
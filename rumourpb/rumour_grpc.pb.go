@@ -0,0 +1,369 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: rumour.proto
+
+package rumourpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// RumourClient is the client API for Rumour service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RumourClient interface {
+	// ListClusters returns the names of all clusters.
+	ListClusters(ctx context.Context, in *ListClustersRequest, opts ...grpc.CallOption) (*ListClustersResponse, error)
+	// GetCluster returns the brokers, topics and consumer groups of a cluster.
+	GetCluster(ctx context.Context, in *GetClusterRequest, opts ...grpc.CallOption) (*Cluster, error)
+	// ListTopics returns the topic names of a cluster.
+	ListTopics(ctx context.Context, in *ListTopicsRequest, opts ...grpc.CallOption) (*ListTopicsResponse, error)
+	// GetTopic returns the partitions of a topic.
+	GetTopic(ctx context.Context, in *GetTopicRequest, opts ...grpc.CallOption) (*Topic, error)
+	// ListConsumers returns the consumer groups of a cluster with their lag
+	// summaries.
+	ListConsumers(ctx context.Context, in *ListConsumersRequest, opts ...grpc.CallOption) (*ListConsumersResponse, error)
+	// GetConsumer returns the partition offsets of a consumer group.
+	GetConsumer(ctx context.Context, in *GetConsumerRequest, opts ...grpc.CallOption) (*Consumer, error)
+	// WatchConsumerLag streams the lag of matching consumer groups. It sends
+	// the current lag first, followed by an update whenever a group commits
+	// offsets. Streams that cannot keep up are aborted and must resubscribe.
+	WatchConsumerLag(ctx context.Context, in *WatchConsumerLagRequest, opts ...grpc.CallOption) (Rumour_WatchConsumerLagClient, error)
+}
+
+type rumourClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRumourClient(cc grpc.ClientConnInterface) RumourClient {
+	return &rumourClient{cc}
+}
+
+func (c *rumourClient) ListClusters(ctx context.Context, in *ListClustersRequest, opts ...grpc.CallOption) (*ListClustersResponse, error) {
+	out := new(ListClustersResponse)
+	err := c.cc.Invoke(ctx, "/rumour.v1.Rumour/ListClusters", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rumourClient) GetCluster(ctx context.Context, in *GetClusterRequest, opts ...grpc.CallOption) (*Cluster, error) {
+	out := new(Cluster)
+	err := c.cc.Invoke(ctx, "/rumour.v1.Rumour/GetCluster", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rumourClient) ListTopics(ctx context.Context, in *ListTopicsRequest, opts ...grpc.CallOption) (*ListTopicsResponse, error) {
+	out := new(ListTopicsResponse)
+	err := c.cc.Invoke(ctx, "/rumour.v1.Rumour/ListTopics", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rumourClient) GetTopic(ctx context.Context, in *GetTopicRequest, opts ...grpc.CallOption) (*Topic, error) {
+	out := new(Topic)
+	err := c.cc.Invoke(ctx, "/rumour.v1.Rumour/GetTopic", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rumourClient) ListConsumers(ctx context.Context, in *ListConsumersRequest, opts ...grpc.CallOption) (*ListConsumersResponse, error) {
+	out := new(ListConsumersResponse)
+	err := c.cc.Invoke(ctx, "/rumour.v1.Rumour/ListConsumers", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rumourClient) GetConsumer(ctx context.Context, in *GetConsumerRequest, opts ...grpc.CallOption) (*Consumer, error) {
+	out := new(Consumer)
+	err := c.cc.Invoke(ctx, "/rumour.v1.Rumour/GetConsumer", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rumourClient) WatchConsumerLag(ctx context.Context, in *WatchConsumerLagRequest, opts ...grpc.CallOption) (Rumour_WatchConsumerLagClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Rumour_ServiceDesc.Streams[0], "/rumour.v1.Rumour/WatchConsumerLag", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &rumourWatchConsumerLagClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Rumour_WatchConsumerLagClient interface {
+	Recv() (*ConsumerLag, error)
+	grpc.ClientStream
+}
+
+type rumourWatchConsumerLagClient struct {
+	grpc.ClientStream
+}
+
+func (x *rumourWatchConsumerLagClient) Recv() (*ConsumerLag, error) {
+	m := new(ConsumerLag)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// RumourServer is the server API for Rumour service.
+// All implementations must embed UnimplementedRumourServer
+// for forward compatibility
+type RumourServer interface {
+	// ListClusters returns the names of all clusters.
+	ListClusters(context.Context, *ListClustersRequest) (*ListClustersResponse, error)
+	// GetCluster returns the brokers, topics and consumer groups of a cluster.
+	GetCluster(context.Context, *GetClusterRequest) (*Cluster, error)
+	// ListTopics returns the topic names of a cluster.
+	ListTopics(context.Context, *ListTopicsRequest) (*ListTopicsResponse, error)
+	// GetTopic returns the partitions of a topic.
+	GetTopic(context.Context, *GetTopicRequest) (*Topic, error)
+	// ListConsumers returns the consumer groups of a cluster with their lag
+	// summaries.
+	ListConsumers(context.Context, *ListConsumersRequest) (*ListConsumersResponse, error)
+	// GetConsumer returns the partition offsets of a consumer group.
+	GetConsumer(context.Context, *GetConsumerRequest) (*Consumer, error)
+	// WatchConsumerLag streams the lag of matching consumer groups. It sends
+	// the current lag first, followed by an update whenever a group commits
+	// offsets. Streams that cannot keep up are aborted and must resubscribe.
+	WatchConsumerLag(*WatchConsumerLagRequest, Rumour_WatchConsumerLagServer) error
+	mustEmbedUnimplementedRumourServer()
+}
+
+// UnimplementedRumourServer must be embedded to have forward compatible implementations.
+type UnimplementedRumourServer struct {
+}
+
+func (UnimplementedRumourServer) ListClusters(context.Context, *ListClustersRequest) (*ListClustersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListClusters not implemented")
+}
+func (UnimplementedRumourServer) GetCluster(context.Context, *GetClusterRequest) (*Cluster, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCluster not implemented")
+}
+func (UnimplementedRumourServer) ListTopics(context.Context, *ListTopicsRequest) (*ListTopicsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTopics not implemented")
+}
+func (UnimplementedRumourServer) GetTopic(context.Context, *GetTopicRequest) (*Topic, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTopic not implemented")
+}
+func (UnimplementedRumourServer) ListConsumers(context.Context, *ListConsumersRequest) (*ListConsumersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListConsumers not implemented")
+}
+func (UnimplementedRumourServer) GetConsumer(context.Context, *GetConsumerRequest) (*Consumer, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetConsumer not implemented")
+}
+func (UnimplementedRumourServer) WatchConsumerLag(*WatchConsumerLagRequest, Rumour_WatchConsumerLagServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchConsumerLag not implemented")
+}
+func (UnimplementedRumourServer) mustEmbedUnimplementedRumourServer() {}
+
+// UnsafeRumourServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RumourServer will
+// result in compilation errors.
+type UnsafeRumourServer interface {
+	mustEmbedUnimplementedRumourServer()
+}
+
+func RegisterRumourServer(s grpc.ServiceRegistrar, srv RumourServer) {
+	s.RegisterService(&Rumour_ServiceDesc, srv)
+}
+
+func _Rumour_ListClusters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListClustersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RumourServer).ListClusters(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rumour.v1.Rumour/ListClusters",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RumourServer).ListClusters(ctx, req.(*ListClustersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Rumour_GetCluster_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetClusterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RumourServer).GetCluster(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rumour.v1.Rumour/GetCluster",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RumourServer).GetCluster(ctx, req.(*GetClusterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Rumour_ListTopics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTopicsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RumourServer).ListTopics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rumour.v1.Rumour/ListTopics",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RumourServer).ListTopics(ctx, req.(*ListTopicsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Rumour_GetTopic_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTopicRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RumourServer).GetTopic(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rumour.v1.Rumour/GetTopic",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RumourServer).GetTopic(ctx, req.(*GetTopicRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Rumour_ListConsumers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListConsumersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RumourServer).ListConsumers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rumour.v1.Rumour/ListConsumers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RumourServer).ListConsumers(ctx, req.(*ListConsumersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Rumour_GetConsumer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetConsumerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RumourServer).GetConsumer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rumour.v1.Rumour/GetConsumer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RumourServer).GetConsumer(ctx, req.(*GetConsumerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Rumour_WatchConsumerLag_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchConsumerLagRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RumourServer).WatchConsumerLag(m, &rumourWatchConsumerLagServer{stream})
+}
+
+type Rumour_WatchConsumerLagServer interface {
+	Send(*ConsumerLag) error
+	grpc.ServerStream
+}
+
+type rumourWatchConsumerLagServer struct {
+	grpc.ServerStream
+}
+
+func (x *rumourWatchConsumerLagServer) Send(m *ConsumerLag) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Rumour_ServiceDesc is the grpc.ServiceDesc for Rumour service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Rumour_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "rumour.v1.Rumour",
+	HandlerType: (*RumourServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListClusters",
+			Handler:    _Rumour_ListClusters_Handler,
+		},
+		{
+			MethodName: "GetCluster",
+			Handler:    _Rumour_GetCluster_Handler,
+		},
+		{
+			MethodName: "ListTopics",
+			Handler:    _Rumour_ListTopics_Handler,
+		},
+		{
+			MethodName: "GetTopic",
+			Handler:    _Rumour_GetTopic_Handler,
+		},
+		{
+			MethodName: "ListConsumers",
+			Handler:    _Rumour_ListConsumers_Handler,
+		},
+		{
+			MethodName: "GetConsumer",
+			Handler:    _Rumour_GetConsumer_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchConsumerLag",
+			Handler:       _Rumour_WatchConsumerLag_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "rumour.proto",
+}